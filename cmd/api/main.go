@@ -1,19 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 
 	"golang-exercise/config"
+	"golang-exercise/internal/app"
 	"golang-exercise/internal/database"
-	"golang-exercise/internal/handler"
 	"golang-exercise/internal/messaging"
 	"golang-exercise/internal/middleware"
-	"golang-exercise/internal/repository"
-	"golang-exercise/internal/router"
-	"golang-exercise/internal/service"
 )
 
 func main() {
@@ -32,47 +34,49 @@ func main() {
 	rabbitmq := messaging.NewRabbitMQ()
 	if err := rabbitmq.Connect(); err != nil {
 		fmt.Printf("Warning: Failed to connect to RabbitMQ: %v\n", err)
-		fmt.Println("API will run without queue functionality")
-	} else {
-		defer rabbitmq.Close()
-
-		// Declare the transaction queue
-		fmt.Println(config.GetConfig().RabbitMQ.Queue)
-		if err := rabbitmq.DeclareQueue(config.GetConfig().RabbitMQ.Queue); err != nil {
-			fmt.Printf("Warning: Failed to declare queue: %v\n", err)
-		}
+		fmt.Println("API will keep retrying, publishing fails until connected")
+	}
+
+	// Declare the transaction queue, again after every reconnection
+	if err := rabbitmq.DeclareQueue(config.GetConfig().RabbitMQ.Queue); err != nil {
+		fmt.Printf("Warning: Failed to declare queue: %v\n", err)
 	}
 
 	// Health route
 	r.GET("/", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{
-			"message": "Banking Ledger API is healthy!",
-			"service": "api-gateway",
+			"message":  "Banking Ledger API is healthy!",
+			"service":  "api-gateway",
+			"rabbitmq": rabbitmq.IsConnected(),
 		})
 	})
 
-	// Initialize repositories and services
-	accountRepo := repository.NewAccountRepository()
-	txLogRepo := repository.NewTransactionLogRepository()
+	// Initialize services and routes
+	services := app.NewServices()
+	services.SetupRoutes(r, messaging.NewTransactionPublisher(rabbitmq))
+
+	// Start the API server
+	port := config.GetConfig().App.Port
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: r,
+	}
+
+	go func() {
+		fmt.Printf("Starting API server on port %s\n", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("API server failed: %v", err)
+		}
+	}()
 
-	accountService := service.NewAccountService(accountRepo)
-	txLogService := service.NewTransactionLogService(txLogRepo)
-	transactionService := service.NewTransactionService(accountService, txLogService)
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
 
-	// Initialize publishers and handlers
-	transactionPublisher := messaging.NewTransactionPublisher(rabbitmq)
-	accountHandler := handler.NewAccountHandler(accountService, transactionService, txLogService, transactionPublisher)
-	transactionHandler := handler.NewTransactionHandler(accountService, txLogService)
+	log.Println("Shutting down API server")
 
-	// Setup API routes with properly initialized handlers
-	v1 := r.Group("/api/v1")
-	{
-		router.SetupAccountRoutes(v1, accountHandler)
-		router.SetupTransactionRoutes(v1, transactionHandler)
-	}
+	app.Shutdown(config.GetConfig().App.ShutdownTimeout(), server, nil, rabbitmq.Close)
 
-	// Start the API server
-	port := config.GetConfig().App.Port
-	fmt.Printf("Starting API server on port %s\n", port)
-	r.Run(fmt.Sprintf(":%s", port))
+	log.Println("API server stopped")
 }
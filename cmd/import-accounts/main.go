@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/accountimport"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/repository"
+	"golang-exercise/internal/service"
+)
+
+// Opens the accounts of a CSV file and prints the import report as JSON.
+// Exits with status 1 when a row failed or the file is malformed.
+func main() {
+	configFile := flag.String("config", "config.yaml", "path to the config file")
+	file := flag.String("file", "-", "CSV file to import, - reads standard input")
+	dryRun := flag.Bool("dry-run", false, "only validate the rows")
+	chunkSize := flag.Int("chunk-size", service.DEFAULT_IMPORT_CHUNK_SIZE, "accounts created per database transaction")
+	flag.Parse()
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", *file, err)
+		}
+		defer f.Close()
+
+		input = f
+	}
+
+	// Load the environment config
+	config.Load(*configFile)
+
+	// Connect to databases
+	database.ConnectDB()
+
+	txLogService := service.NewTransactionLogService(repository.NewTransactionLogRepository())
+	ledgerService := service.NewLedgerService(repository.NewLedgerRepository())
+	accountService := service.NewAccountService(repository.NewAccountRepository(), ledgerService, txLogService)
+
+	reader, err := accountimport.NewReader(input)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *file, err)
+	}
+
+	report, importErr := accountService.ImportAccounts(context.Background(), reader, service.ImportOptions{
+		DryRun:    *dryRun,
+		ChunkSize: *chunkSize,
+	})
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write the report: %v", err)
+	}
+
+	if importErr != nil {
+		log.Printf("Import stopped: %v", importErr)
+		os.Exit(1)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
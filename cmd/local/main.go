@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/app"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/messaging"
+	"golang-exercise/internal/middleware"
+)
+
+// Runs the API and the transaction worker in one process, connected by the
+// in-process broker instead of RabbitMQ. For local development and end-to-end
+// tests: queued messages do not survive a restart.
+func main() {
+	r := gin.New()
+
+	// Load the environment config
+	config.Load("config.yaml")
+
+	// Add logger middleware
+	r.Use(middleware.Logger())
+
+	// Connect to databases
+	database.ConnectDB()
+
+	// The broker delays retries and keeps dead-lettered messages like the RabbitMQ topology does
+	retryPolicy := messaging.NewRetryPolicy(config.GetConfig().RabbitMQ)
+	broker := messaging.NewLocalBroker(retryPolicy)
+
+	// Health route
+	r.GET("/", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{
+			"message": "Banking Ledger API is healthy!",
+			"service": "local",
+			"broker":  broker.IsConnected(),
+			"pending": broker.Pending(),
+		})
+	})
+
+	// Initialize services and routes, the worker takes from the same broker the API publishes to
+	services := app.NewServices()
+	services.SetupRoutes(r, broker)
+	worker := services.NewWorker(broker, broker, retryPolicy)
+
+	if err := worker.Start(); err != nil {
+		panic(fmt.Sprintf("Failed to start consuming: %v", err))
+	}
+
+	// Start the API server
+	port := config.GetConfig().App.Port
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: r,
+	}
+
+	go func() {
+		fmt.Printf("Starting API server and transaction worker on port %s\n", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("API server failed: %v", err)
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down")
+
+	app.Shutdown(config.GetConfig().App.ShutdownTimeout(), server, worker, func() error {
+		if pending := broker.Pending(); pending > 0 {
+			log.Printf("Warning: dropping %d queued transaction messages", pending)
+		}
+
+		return broker.Close()
+	})
+
+	log.Println("Stopped")
+}
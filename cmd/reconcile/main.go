@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/repository"
+	"golang-exercise/internal/service"
+)
+
+// Reconciles Postgres balances with the Mongo transaction log and prints the
+// report as JSON. Exits with status 1 when discrepancies were found.
+func main() {
+	configFile := flag.String("config", "config.yaml", "path to the config file")
+	accountNumber := flag.String("account", "", "only reconcile this account")
+	repair := flag.Bool("repair", false, "fix transaction log statuses that are provably wrong")
+	flag.Parse()
+
+	// Load the environment config
+	config.Load(*configFile)
+
+	// Connect to databases
+	database.ConnectDB()
+
+	txLogService := service.NewTransactionLogService(repository.NewTransactionLogRepository())
+	reconciliationService := service.NewReconciliationService(
+		repository.NewAccountRepository(),
+		repository.NewLedgerRepository(),
+		repository.NewOutboxRepository(),
+		repository.NewReconciliationRepository(),
+		txLogService,
+		config.GetConfig().Reconciliation,
+	)
+
+	report, err := reconciliationService.Run(context.Background(), *accountNumber, *repair)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write the report: %v", err)
+	}
+
+	if report.AccountsWithIssues > 0 || len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
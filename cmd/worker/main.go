@@ -8,10 +8,9 @@ import (
 	"syscall"
 
 	"golang-exercise/config"
+	"golang-exercise/internal/app"
 	"golang-exercise/internal/database"
 	"golang-exercise/internal/messaging"
-	"golang-exercise/internal/repository"
-	"golang-exercise/internal/service"
 )
 
 func main() {
@@ -24,29 +23,26 @@ func main() {
 	// Connect to RabbitMQ
 	rabbitmq := messaging.NewRabbitMQ()
 	if err := rabbitmq.Connect(); err != nil {
-		panic(fmt.Sprintf("Failed to connect to RabbitMQ: %v", err))
+		log.Printf("Warning: Failed to connect to RabbitMQ, retrying in the background: %v", err)
 	}
-	defer rabbitmq.Close()
 
 	// Declare the transaction queue
 	if err := rabbitmq.DeclareQueue(config.GetConfig().RabbitMQ.Queue); err != nil {
 		panic(fmt.Sprintf("Failed to declare queue: %v", err))
 	}
 
-	// Initialize repositories
-	accountRepo := repository.NewAccountRepository()
-	txLogRepo := repository.NewTransactionLogRepository()
-
-	// Initialize services
-	accountService := service.NewAccountService(accountRepo)
-	txLogService := service.NewTransactionLogService(txLogRepo)
-	transactionService := service.NewTransactionService(accountService, txLogService)
+	// Delay queues for retries and the dead-letter queue for messages given up on
+	retryPolicy := messaging.NewRetryPolicy(config.GetConfig().RabbitMQ)
+	if err := rabbitmq.DeclareRetryTopology(config.GetConfig().RabbitMQ.Queue, retryPolicy); err != nil {
+		panic(fmt.Sprintf("Failed to declare retry queues: %v", err))
+	}
 
-	// Initialize and start the transaction consumer
-	consumer := messaging.NewTransactionConsumer(rabbitmq, accountService, transactionService)
+	// Initialize services and the worker, which relays the outbox to RabbitMQ
+	services := app.NewServices()
+	worker := services.NewWorker(rabbitmq, messaging.NewTransactionPublisher(rabbitmq), retryPolicy)
 
 	log.Println("Starting transaction worker")
-	if err := consumer.StartConsuming(); err != nil {
+	if err := worker.Start(); err != nil {
 		panic(fmt.Sprintf("Failed to start consuming: %v", err))
 	}
 
@@ -58,4 +54,8 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down transaction worker")
+
+	app.Shutdown(config.GetConfig().App.ShutdownTimeout(), nil, worker, rabbitmq.Close)
+
+	log.Println("Transaction worker stopped")
 }
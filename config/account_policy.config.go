@@ -0,0 +1,13 @@
+package config
+
+// BalancePolicy is the default debit limit for one account type. Amounts are
+// decimal strings, so they are read without going through a float.
+type BalancePolicy struct {
+	MinimumBalance string `yaml:"minimum_balance" mapstructure:"minimum_balance"`
+	OverdraftLimit string `yaml:"overdraft_limit" mapstructure:"overdraft_limit"`
+}
+
+type AccountPolicies struct {
+	Checking BalancePolicy `yaml:"checking"`
+	Savings  BalancePolicy `yaml:"savings"`
+}
@@ -1,6 +1,22 @@
 package config
 
+import "time"
+
+const DEFAULT_SHUTDOWN_TIMEOUT = 30 * time.Second
+
 type App struct {
 	Port string `yaml:"port"`
 	Name string `yaml:"name"`
+
+	// How long each shutdown phase waits, for in-flight requests, for in-flight
+	// messages, for the background jobs and for closing the databases
+	ShutdownTimeoutMs int `yaml:"shutdown_timeout_ms" mapstructure:"shutdown_timeout_ms"`
+}
+
+func (app App) ShutdownTimeout() time.Duration {
+	if app.ShutdownTimeoutMs <= 0 {
+		return DEFAULT_SHUTDOWN_TIMEOUT
+	}
+
+	return time.Duration(app.ShutdownTimeoutMs) * time.Millisecond
 }
@@ -0,0 +1,23 @@
+package config
+
+type TransactionFee struct {
+	AccountType     string `yaml:"account_type" mapstructure:"account_type"`
+	TransactionType string `yaml:"transaction_type" mapstructure:"transaction_type"`
+	Flat            string `yaml:"flat"`
+	Percentage      string `yaml:"percentage"`
+	Min             string `yaml:"min"`
+	Max             string `yaml:"max"`
+}
+
+type MaintenanceFee struct {
+	AccountType string `yaml:"account_type" mapstructure:"account_type"`
+	Amount      string `yaml:"amount"`
+	WaiveAbove  string `yaml:"waive_above" mapstructure:"waive_above"`
+}
+
+// Fees is the fee schedule. Amounts are decimal strings so they are read
+// exactly. Percentage is a fraction of the amount, "0.001" is 0.1%.
+type Fees struct {
+	TransactionFees []TransactionFee `yaml:"transaction_fees" mapstructure:"transaction_fees"`
+	MaintenanceFees []MaintenanceFee `yaml:"maintenance_fees" mapstructure:"maintenance_fees"`
+}
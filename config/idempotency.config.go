@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+const DEFAULT_IDEMPOTENCY_RESERVATION_TTL = 5 * time.Minute
+
+// Idempotency configures the Idempotency-Key handling. A key reserved by a
+// request that never finished, for example because the process died, may be
+// taken over by a retry once it is older than ReservationTtlMs.
+type Idempotency struct {
+	ReservationTtlMs int `yaml:"reservation_ttl_ms" mapstructure:"reservation_ttl_ms"`
+}
+
+func (idempotency Idempotency) ReservationTTL() time.Duration {
+	if idempotency.ReservationTtlMs <= 0 {
+		return DEFAULT_IDEMPOTENCY_RESERVATION_TTL
+	}
+
+	return time.Duration(idempotency.ReservationTtlMs) * time.Millisecond
+}
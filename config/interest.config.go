@@ -0,0 +1,14 @@
+package config
+
+type InterestTier struct {
+	MinBalance string `yaml:"min_balance" mapstructure:"min_balance"`
+	AnnualRate string `yaml:"annual_rate" mapstructure:"annual_rate"`
+}
+
+// Interest configures accrual on savings accounts. DayCount is one of ACT/365,
+// ACT/360 or ACT/ACT. Tier balances and rates are decimal strings so they are
+// read exactly, an annual_rate of "0.02" is 2%.
+type Interest struct {
+	DayCount     string         `yaml:"day_count" mapstructure:"day_count"`
+	SavingsTiers []InterestTier `yaml:"savings_tiers" mapstructure:"savings_tiers"`
+}
@@ -13,6 +13,13 @@ type Config struct {
 	App      App      `yaml:"app"`
 	DB       DB       `yaml:"db"`
 	RabbitMQ RabbitMQ `yaml:"rabbitmq"`
+	Outbox   Outbox   `yaml:"outbox"`
+
+	AccountPolicies AccountPolicies `yaml:"account_policies" mapstructure:"account_policies"`
+	Interest        Interest        `yaml:"interest"`
+	Fees            Fees            `yaml:"fees"`
+	Reconciliation  Reconciliation  `yaml:"reconciliation"`
+	Idempotency     Idempotency     `yaml:"idempotency"`
 }
 
 func Load(configFile string) {
@@ -0,0 +1,7 @@
+package config
+
+type Outbox struct {
+	PollIntervalMs int `yaml:"poll_interval_ms" mapstructure:"poll_interval_ms"`
+	BatchSize      int `yaml:"batch_size" mapstructure:"batch_size"`
+	MaxAttempts    int `yaml:"max_attempts" mapstructure:"max_attempts"`
+}
@@ -6,4 +6,17 @@ type RabbitMQ struct {
 	UserName string `yaml:"username"`
 	Password string `yaml:"password"`
 	Queue    string `yaml:"queue"`
+
+	// Failed messages are retried MaxRetries times, waiting RetryBaseDelayMs
+	// doubled on every attempt, before they go to the dead-letter queue
+	MaxRetries       int `yaml:"max_retries" mapstructure:"max_retries"`
+	RetryBaseDelayMs int `yaml:"retry_base_delay_ms" mapstructure:"retry_base_delay_ms"`
+
+	// Concurrency is the number of worker goroutines processing messages,
+	// Prefetch how many unacknowledged messages the broker hands out at once
+	Concurrency int `yaml:"concurrency"`
+	Prefetch    int `yaml:"prefetch"`
+
+	// How long a publish waits for the broker to confirm the message
+	PublishTimeoutMs int `yaml:"publish_timeout_ms" mapstructure:"publish_timeout_ms"`
 }
@@ -0,0 +1,10 @@
+package config
+
+// Reconciliation configures the worker job comparing balances with the
+// transaction log. Logs younger than GraceMinutes are treated as in flight.
+// Repair lets the job fix log statuses, otherwise it only reports.
+type Reconciliation struct {
+	IntervalMinutes int  `yaml:"interval_minutes" mapstructure:"interval_minutes"`
+	GraceMinutes    int  `yaml:"grace_minutes" mapstructure:"grace_minutes"`
+	Repair          bool `yaml:"repair"`
+}
@@ -0,0 +1,134 @@
+// Package accountimport reads the accounts to open from CSV. The header row
+// names the columns in any order: first_name, last_name, account_type,
+// currency and initial_balance, which may be left out and defaults to zero.
+// Other columns are ignored. Rows are read one at a time, so a file of any
+// size is streamed.
+package accountimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	COLUMN_FIRST_NAME      = "first_name"
+	COLUMN_LAST_NAME       = "last_name"
+	COLUMN_ACCOUNT_TYPE    = "account_type"
+	COLUMN_CURRENCY        = "currency"
+	COLUMN_INITIAL_BALANCE = "initial_balance"
+)
+
+var requiredColumns = []string{COLUMN_FIRST_NAME, COLUMN_LAST_NAME, COLUMN_ACCOUNT_TYPE, COLUMN_CURRENCY}
+
+// Row is one data row of the file. Errors lists what is wrong with it, a row
+// without errors has its Account filled in.
+type Row struct {
+	Line    int
+	Account *requestdto.CreateAccount
+	Errors  []string
+}
+
+func (row *Row) Valid() bool {
+	return len(row.Errors) == 0
+}
+
+type Reader struct {
+	csv     *csv.Reader
+	columns map[string]int
+}
+
+// NewReader reads the header and fails when a required column is missing
+func NewReader(r io.Reader) (*Reader, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	csvReader.ReuseRecord = true
+
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		// Excel prefixes UTF-8 files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %s appears twice", name)
+		}
+		columns[name] = i
+	}
+
+	missing := []string{}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	return &Reader{csv: csvReader, columns: columns}, nil
+}
+
+// Next returns the next row, io.EOF after the last one. Other errors mean the
+// file itself is malformed and reading can't go on.
+func (reader *Reader) Next() (*Row, error) {
+	record, err := reader.csv.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	line, _ := reader.csv.FieldPos(0)
+	row := &Row{Line: line}
+
+	field := func(name string) string {
+		i, ok := reader.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	required := func(name string) string {
+		value := field(name)
+		if value == "" {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s is required", name))
+		}
+
+		return value
+	}
+
+	account := &requestdto.CreateAccount{
+		FirstName:   required(COLUMN_FIRST_NAME),
+		LastName:    required(COLUMN_LAST_NAME),
+		AccountType: model.AccountType(strings.ToUpper(required(COLUMN_ACCOUNT_TYPE))),
+		Currency:    strings.ToUpper(required(COLUMN_CURRENCY)),
+	}
+
+	if balance := field(COLUMN_INITIAL_BALANCE); balance != "" {
+		account.InitialBalance, err = decimal.NewFromString(balance)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s %q is not a number", COLUMN_INITIAL_BALANCE, balance))
+		}
+	}
+
+	if row.Valid() {
+		row.Account = account
+	}
+
+	return row, nil
+}
@@ -0,0 +1,99 @@
+// Package app wires the repositories, services and handlers that cmd/api,
+// cmd/worker and cmd/local share, and shuts them down. The commands only pick
+// the broker.
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/handler"
+	"golang-exercise/internal/messaging"
+	"golang-exercise/internal/middleware"
+	"golang-exercise/internal/repository"
+	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
+)
+
+// Services are shared by the handlers and the worker
+type Services struct {
+	TxLog          *service.TransactionLogService
+	Ledger         *service.LedgerService
+	Account        *service.AccountService
+	Hold           *service.HoldService
+	Fee            *service.FeeService
+	Transaction    *service.TransactionService
+	Idempotency    *service.IdempotencyService
+	Outbox         *service.OutboxService
+	Fx             *service.FxService
+	Schedule       *service.ScheduleService
+	Interest       *service.InterestService
+	Statement      *service.StatementService
+	Batch          *service.BatchService
+	Reconciliation *service.ReconciliationService
+}
+
+// NewServices builds every service on the Postgres and Mongo repositories, so
+// the databases must be connected first
+func NewServices() *Services {
+	accountRepo := repository.NewAccountRepository()
+	ledgerRepo := repository.NewLedgerRepository()
+	outboxRepo := repository.NewOutboxRepository()
+
+	txLogService := service.NewTransactionLogService(repository.NewTransactionLogRepository())
+	ledgerService := service.NewLedgerService(ledgerRepo)
+	accountService := service.NewAccountService(accountRepo, ledgerService, txLogService)
+	holdService := service.NewHoldService(repository.NewHoldRepository(), accountService)
+	feeService := service.NewFeeService(repository.NewFeeRepository(), accountService, ledgerService, txLogService, config.GetConfig().Fees)
+	outboxService := service.NewOutboxService(outboxRepo, txLogService, config.GetConfig().Outbox.MaxAttempts)
+	fxService := service.NewFxService(repository.NewFxRateRepository())
+
+	return &Services{
+		TxLog:          txLogService,
+		Ledger:         ledgerService,
+		Account:        accountService,
+		Hold:           holdService,
+		Fee:            feeService,
+		Transaction:    service.NewTransactionService(accountService, txLogService, ledgerService, holdService, feeService),
+		Idempotency:    service.NewIdempotencyService(repository.NewIdempotencyRepository(), config.GetConfig().Idempotency.ReservationTTL()),
+		Outbox:         outboxService,
+		Fx:             fxService,
+		Schedule:       service.NewScheduleService(repository.NewScheduleRepository(), accountService, fxService, outboxService),
+		Interest:       service.NewInterestService(repository.NewInterestRepository(), accountService, ledgerService, txLogService, config.GetConfig().Interest),
+		Statement:      service.NewStatementService(repository.NewStatementRepository(), accountService, ledgerService),
+		Batch:          service.NewBatchService(repository.NewBatchRepository(), accountService, fxService, feeService, outboxService, txLogService),
+		Reconciliation: service.NewReconciliationService(accountRepo, ledgerRepo, outboxRepo, repository.NewReconciliationRepository(), txLogService, config.GetConfig().Reconciliation),
+	}
+}
+
+// SetupRoutes mounts the API under /api/v1. Transactions the API does not
+// queue through the outbox are handed to publisher.
+func (services *Services) SetupRoutes(r *gin.Engine, publisher messaging.Publisher) {
+	accountHandler := handler.NewAccountHandler(services.Account, services.Transaction, services.TxLog, services.Ledger, services.Outbox, services.Fx, services.Fee, publisher)
+	transactionHandler := handler.NewTransactionHandler(services.Account, services.TxLog, services.Outbox, services.Ledger)
+	transferHandler := handler.NewTransferHandler(services.Account, services.Outbox, services.Fx)
+	holdHandler := handler.NewHoldHandler(services.Account, services.Hold, services.Outbox)
+	fxHandler := handler.NewFxHandler(services.Fx)
+	scheduleHandler := handler.NewScheduleHandler(services.Schedule)
+	interestHandler := handler.NewInterestHandler(services.Account, services.Interest)
+	feeHandler := handler.NewFeeHandler(services.Account, services.Fee)
+	statementHandler := handler.NewStatementHandler(services.Account, services.Statement)
+	batchHandler := handler.NewBatchHandler(services.Batch)
+
+	// Money-moving endpoints honor the Idempotency-Key header
+	idempotency := middleware.Idempotency(services.Idempotency)
+
+	v1 := r.Group("/api/v1")
+	{
+		router.SetupAccountRoutes(v1, accountHandler, idempotency)
+		router.SetupTransactionRoutes(v1, transactionHandler, idempotency)
+		router.SetupTransferRoutes(v1, transferHandler, idempotency)
+		router.SetupHoldRoutes(v1, holdHandler, idempotency)
+		router.SetupFxRoutes(v1, fxHandler)
+		router.SetupScheduleRoutes(v1, scheduleHandler, idempotency)
+		router.SetupInterestRoutes(v1, interestHandler)
+		router.SetupFeeRoutes(v1, feeHandler)
+		router.SetupStatementRoutes(v1, statementHandler)
+		router.SetupBatchRoutes(v1, batchHandler, idempotency)
+	}
+}
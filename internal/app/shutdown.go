@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"golang-exercise/internal/database"
+)
+
+// Shutdown stops a process in phases: the server stops accepting connections
+// and finishes its in-flight requests, the worker drains its messages and stops
+// the relay and the jobs, then the broker and finally the databases are closed.
+// Every phase gets the whole timeout, so a slow one does not cut the next
+// short. server and worker are nil when the process does not run them.
+func Shutdown(timeout time.Duration, server *http.Server, worker *Worker, closeBroker func() error) {
+	if server != nil {
+		serverCtx, cancelServer := context.WithTimeout(context.Background(), timeout)
+		defer cancelServer()
+
+		if err := server.Shutdown(serverCtx); err != nil {
+			log.Printf("Warning: in-flight requests did not finish: %v", err)
+		}
+	}
+
+	// The worker acks its in-flight messages, so the broker is still connected
+	if worker != nil {
+		worker.Stop(timeout)
+	}
+
+	if err := closeBroker(); err != nil {
+		log.Printf("Failed to close the broker: %v", err)
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), timeout)
+	defer cancelClose()
+
+	if err := database.Close(closeCtx); err != nil {
+		log.Printf("Failed to close databases: %v", err)
+	}
+}
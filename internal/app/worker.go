@@ -0,0 +1,121 @@
+package app
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/jobs"
+	"golang-exercise/internal/messaging"
+	"golang-exercise/internal/service"
+)
+
+// Worker processes the queued transactions and runs the outbox relay and the
+// scheduled jobs
+type Worker struct {
+	services *Services
+	consumer *messaging.TransactionConsumer
+	relay    *messaging.OutboxRelay
+	cancel   context.CancelFunc
+
+	// cancelProcessing aborts the transactions still in flight once the drain times out
+	cancelProcessing context.CancelFunc
+
+	// Background loops are waited for on shutdown so none is cut off mid-run
+	background sync.WaitGroup
+}
+
+// NewWorker consumes the transactions of subscriber and relays the outbox to publisher
+func (services *Services) NewWorker(subscriber messaging.Subscriber, publisher messaging.Publisher, retryPolicy messaging.RetryPolicy) *Worker {
+	outboxConfig := config.GetConfig().Outbox
+
+	return &Worker{
+		services: services,
+		consumer: messaging.NewTransactionConsumer(subscriber, services.Account, services.Transaction, services.TxLog, retryPolicy),
+		relay:    messaging.NewOutboxRelay(services.Outbox, publisher, time.Duration(outboxConfig.PollIntervalMs)*time.Millisecond, outboxConfig.BatchSize),
+	}
+}
+
+// Start runs the relay and the jobs in the background, then starts consuming
+func (worker *Worker) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	worker.cancel = cancel
+
+	processing, cancelProcessing := context.WithCancel(context.Background())
+	worker.cancelProcessing = cancelProcessing
+
+	services := worker.services
+
+	// Relay outbox messages written by the API to the broker
+	worker.run(func() { worker.relay.Run(ctx) })
+
+	// Release expired holds back to the available balance
+	worker.run(func() { jobs.Every(ctx, "hold-expiry", time.Minute, services.Hold.ExpireDueHolds) })
+
+	// Enqueue standing orders that are due, the relay then publishes them
+	worker.run(func() {
+		jobs.Every(ctx, "scheduled-transfers", service.SCHEDULER_POLL_INTERVAL, services.Schedule.RunDue)
+	})
+
+	// Accrue daily interest on savings accounts and pay it out at month end
+	worker.run(func() { jobs.Every(ctx, "interest-accrual", time.Hour, services.Interest.AccrueDue) })
+
+	// Charge the monthly maintenance fee once per account and month
+	worker.run(func() { jobs.Every(ctx, "maintenance-fees", time.Hour, services.Fee.ChargeMaintenanceDue) })
+
+	// Snapshot ledger balances daily so point-in-time queries stay cheap
+	worker.run(func() { jobs.Every(ctx, "balance-snapshots", time.Hour, services.Ledger.SnapshotBalances) })
+
+	// Issue last month's statements once the month has closed
+	worker.run(func() { jobs.Every(ctx, "statements", time.Hour, services.Statement.GenerateDue) })
+
+	// Compare balances with the transaction log and report any discrepancies
+	if interval := config.GetConfig().Reconciliation.IntervalMinutes; interval > 0 {
+		worker.run(func() {
+			jobs.Every(ctx, "reconciliation", time.Duration(interval)*time.Minute, services.Reconciliation.RunScheduled)
+		})
+	}
+
+	return worker.consumer.StartConsuming(processing)
+}
+
+func (worker *Worker) run(loop func()) {
+	worker.background.Add(1)
+	go func() {
+		defer worker.background.Done()
+		loop()
+	}()
+}
+
+// Stop stops taking messages and lets the in-flight ones finish and ack while
+// the broker is still connected, then stops the relay and the jobs. Both phases
+// get timeout, like the phases of Shutdown. Transactions still running when the
+// drain times out are cancelled and their messages requeued.
+func (worker *Worker) Stop(timeout time.Duration) {
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), timeout)
+	defer cancelDrain()
+
+	if err := worker.consumer.Stop(drainCtx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	worker.cancelProcessing()
+	worker.cancel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		worker.background.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Println("Warning: background jobs did not finish in time")
+	}
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// encodeDecimal stores decimal.Decimal as BSON Decimal128 so amounts keep their
+// exact value and can still be compared and summed inside Mongo
+func encodeDecimal(_ bsoncodec.EncodeContext, writer bsonrw.ValueWriter, value reflect.Value) error {
+	if !value.IsValid() || value.Type() != decimalType {
+		return bsoncodec.ValueEncoderError{Name: "DecimalEncodeValue", Types: []reflect.Type{decimalType}, Received: value}
+	}
+
+	amount := value.Interface().(decimal.Decimal)
+	decimal128, err := primitive.ParseDecimal128(amount.String())
+	if err != nil {
+		return fmt.Errorf("failed to encode decimal %s: %w", amount.String(), err)
+	}
+
+	return writer.WriteDecimal128(decimal128)
+}
+
+func decodeDecimal(_ bsoncodec.DecodeContext, reader bsonrw.ValueReader, value reflect.Value) error {
+	if !value.CanSet() || value.Type() != decimalType {
+		return bsoncodec.ValueDecoderError{Name: "DecimalDecodeValue", Types: []reflect.Type{decimalType}, Received: value}
+	}
+
+	var amount decimal.Decimal
+	var err error
+
+	switch reader.Type() {
+	case bsontype.Decimal128:
+		var decimal128 primitive.Decimal128
+		if decimal128, err = reader.ReadDecimal128(); err == nil {
+			amount, err = decimal.NewFromString(decimal128.String())
+		}
+	case bsontype.String:
+		var text string
+		if text, err = reader.ReadString(); err == nil {
+			amount, err = decimal.NewFromString(text)
+		}
+	case bsontype.Double:
+		var number float64
+		if number, err = reader.ReadDouble(); err == nil {
+			amount = decimal.NewFromFloat(number)
+		}
+	case bsontype.Int32:
+		var number int32
+		if number, err = reader.ReadInt32(); err == nil {
+			amount = decimal.NewFromInt32(number)
+		}
+	case bsontype.Int64:
+		var number int64
+		if number, err = reader.ReadInt64(); err == nil {
+			amount = decimal.NewFromInt(number)
+		}
+	case bsontype.Null:
+		err = reader.ReadNull()
+	default:
+		// Logs written before the codec existed hold an empty document
+		err = reader.Skip()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to decode decimal: %w", err)
+	}
+
+	value.Set(reflect.ValueOf(amount))
+	return nil
+}
+
+func newMongoRegistry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeEncoder(decimalType, bsoncodec.ValueEncoderFunc(encodeDecimal))
+	registry.RegisterTypeDecoder(decimalType, bsoncodec.ValueDecoderFunc(decodeDecimal))
+
+	return registry
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"golang-exercise/config"
 	"log"
@@ -86,7 +87,7 @@ func ConnectMongoDB() {
 
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.DB.Mongo.URI))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.DB.Mongo.URI).SetRegistry(newMongoRegistry()))
 	if err != nil {
 		log.Printf("Failed to connect to MongoDB: %v", err)
 		return
@@ -109,3 +110,26 @@ func GetPostgresDB() *gorm.DB {
 func GetMongoDB() *mongo.Database {
 	return MongoDB
 }
+
+// Close disconnects from Mongo and closes the Postgres connection pool
+func Close(ctx context.Context) error {
+	var errs []error
+
+	if MongoDB != nil {
+		if err := MongoDB.Client().Disconnect(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to disconnect from MongoDB: %w", err))
+		}
+	}
+
+	if PostgresDB != nil {
+		sqlDB, err := PostgresDB.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to close Postgres: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
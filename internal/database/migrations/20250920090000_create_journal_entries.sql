@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    transaction_id VARCHAR(100) NOT NULL UNIQUE,
+    transaction_type VARCHAR(20) NOT NULL,
+    amount NUMERIC(19, 4) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE postings (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    journal_entry_id INTEGER NOT NULL REFERENCES journal_entries (id),
+    account_number VARCHAR(60) NOT NULL,
+    amount NUMERIC(19, 4) NOT NULL CHECK (amount <> 0),
+    currency VARCHAR(3) NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_postings_account_number ON postings (account_number, created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_postings_journal_entry_id ON postings (journal_entry_id);
+-- +goose StatementEnd
+
+-- Existing balances predate the journal, so book them as opening entries
+-- against the cash counter-account to keep every account in balance.
+-- +goose StatementBegin
+INSERT INTO journal_entries (transaction_id, transaction_type, amount, currency, description)
+SELECT 'OPEN_' || account_number, 'OPENING_BALANCE', balance, currency, 'Opening balance (migrated)'
+FROM accounts
+WHERE balance <> 0 AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO postings (journal_entry_id, account_number, amount, currency)
+SELECT je.id, a.account_number, a.balance, a.currency
+FROM journal_entries je
+JOIN accounts a ON je.transaction_id = 'OPEN_' || a.account_number;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO postings (journal_entry_id, account_number, amount, currency)
+SELECT je.id, 'SYSTEM_CASH_' || a.currency, -a.balance, a.currency
+FROM journal_entries je
+JOIN accounts a ON je.transaction_id = 'OPEN_' || a.account_number;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS postings;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS journal_entries;
+-- +goose StatementEnd
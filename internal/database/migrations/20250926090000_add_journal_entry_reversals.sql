@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE journal_entries ADD COLUMN reverses_transaction_id VARCHAR(100) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_journal_entries_reverses_transaction_id ON journal_entries (reverses_transaction_id) WHERE reverses_transaction_id <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS reverses_transaction_id;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts ADD COLUMN held_balance NUMERIC(19, 4) NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE holds (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    hold_id VARCHAR(100) NOT NULL UNIQUE,
+    account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    amount NUMERIC(19, 4) NOT NULL CHECK (amount > 0),
+    captured_amount NUMERIC(19, 4) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+    memo TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ,
+    capture_transaction_id VARCHAR(100) NOT NULL DEFAULT ''
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_holds_account_number ON holds (account_number, status);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_holds_active_expiry ON holds (expires_at) WHERE status = 'ACTIVE';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS holds;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE accounts DROP COLUMN IF EXISTS held_balance;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- NULL means the account follows the default policy of its account type
+-- +goose StatementBegin
+ALTER TABLE accounts
+    ADD COLUMN minimum_balance NUMERIC(19, 4),
+    ADD COLUMN overdraft_limit NUMERIC(19, 4) CHECK (overdraft_limit >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS minimum_balance,
+    DROP COLUMN IF EXISTS overdraft_limit;
+-- +goose StatementEnd
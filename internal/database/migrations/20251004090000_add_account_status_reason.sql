@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts
+    ADD COLUMN status_reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN status_changed_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS status_reason,
+    DROP COLUMN IF EXISTS status_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE schedules (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    schedule_id VARCHAR(100) NOT NULL UNIQUE,
+    from_account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    to_account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    amount NUMERIC(19, 4) NOT NULL CHECK (amount > 0),
+    memo TEXT NOT NULL DEFAULT '',
+    recurrence VARCHAR(100) NOT NULL DEFAULT '',
+    missed_runs VARCHAR(10) NOT NULL CHECK (missed_runs IN ('RUN_ONCE', 'SKIP')),
+    status VARCHAR(10) NOT NULL CHECK (status IN ('ACTIVE', 'PAUSED', 'COMPLETED', 'CANCELLED')),
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_schedules_due ON schedules (next_run_at) WHERE status = 'ACTIVE';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_schedules_from_account ON schedules (from_account_number);
+-- +goose StatementEnd
+
+-- One row per occurrence, the unique key keeps an occurrence from running twice
+-- +goose StatementBegin
+CREATE TABLE schedule_runs (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    schedule_id VARCHAR(100) NOT NULL REFERENCES schedules (schedule_id),
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('ENQUEUED', 'SKIPPED', 'FAILED')),
+    transaction_id VARCHAR(100) NOT NULL DEFAULT '',
+    missed_occurrences INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    UNIQUE (schedule_id, scheduled_for)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS schedule_runs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS schedules;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts
+    ADD COLUMN accrued_interest NUMERIC(24, 10) NOT NULL DEFAULT 0,
+    ADD COLUMN interest_accrued_through DATE;
+-- +goose StatementEnd
+
+-- Existing accounts start accruing from today instead of their creation date
+-- +goose StatementBegin
+UPDATE accounts SET interest_accrued_through = CURRENT_DATE - 1 WHERE account_type = 'SAVINGS';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE interest_accruals (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    accrual_date DATE NOT NULL,
+    balance NUMERIC(19, 4) NOT NULL,
+    annual_rate NUMERIC(10, 6) NOT NULL,
+    amount NUMERIC(24, 10) NOT NULL,
+    UNIQUE (account_number, accrual_date)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS interest_accruals;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS accrued_interest,
+    DROP COLUMN IF EXISTS interest_accrued_through;
+-- +goose StatementEnd
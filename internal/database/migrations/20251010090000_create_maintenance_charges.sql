@@ -0,0 +1,21 @@
+-- +goose Up
+-- One row per account and month, waived fees included, so the fee is decided once
+-- +goose StatementBegin
+CREATE TABLE maintenance_charges (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    period VARCHAR(6) NOT NULL,
+    amount NUMERIC(19, 4) NOT NULL,
+    waived BOOLEAN NOT NULL DEFAULT FALSE,
+    transaction_id VARCHAR(100) NOT NULL DEFAULT '',
+    UNIQUE (account_number, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS maintenance_charges;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE statements (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    statement_id VARCHAR(100) NOT NULL UNIQUE,
+    account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    period VARCHAR(7) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    opening_balance NUMERIC(19, 4) NOT NULL,
+    total_credits NUMERIC(19, 4) NOT NULL,
+    total_debits NUMERIC(19, 4) NOT NULL,
+    closing_balance NUMERIC(19, 4) NOT NULL,
+    transaction_count INTEGER NOT NULL,
+    lines JSONB NOT NULL,
+    UNIQUE (account_number, period)
+);
+-- +goose StatementEnd
+
+-- Statements are issued documents, refuse any change once written
+-- +goose StatementBegin
+CREATE FUNCTION statements_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'statements are immutable';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER statements_immutable
+BEFORE UPDATE OR DELETE ON statements
+FOR EACH ROW EXECUTE FUNCTION statements_immutable();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS statements;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS statements_immutable();
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE balance_snapshots (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    account_number VARCHAR(60) NOT NULL,
+    as_of TIMESTAMPTZ NOT NULL,
+    balance NUMERIC(19, 4) NOT NULL,
+    UNIQUE (account_number, as_of)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS balance_snapshots;
+-- +goose StatementEnd
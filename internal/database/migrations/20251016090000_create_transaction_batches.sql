@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE transaction_batches (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    batch_id VARCHAR(100) NOT NULL UNIQUE,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    item_count INTEGER NOT NULL CHECK (item_count > 0)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE transaction_batch_items (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    batch_id VARCHAR(100) NOT NULL REFERENCES transaction_batches (batch_id),
+    item_index INTEGER NOT NULL,
+    transaction_id VARCHAR(100) NOT NULL UNIQUE,
+    account_number VARCHAR(60) NOT NULL REFERENCES accounts (account_number),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('DEPOSIT', 'WITHDRAWAL')),
+    amount NUMERIC(19, 4) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    memo TEXT NOT NULL DEFAULT '',
+    UNIQUE (batch_id, item_index)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transaction_batch_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transaction_batches;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Account numbers are the account prefix and the next value, zero padded to
+-- 14 digits. Numbers generated before start with the Unix time, never a zero.
+CREATE SEQUENCE account_number_seq;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP SEQUENCE IF EXISTS account_number_seq;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox_messages
+    ADD COLUMN claimed_until TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE outbox_messages
+    DROP COLUMN IF EXISTS claimed_until;
+-- +goose StatementEnd
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
@@ -13,6 +15,19 @@ const (
 	AccountClosed AccountStatus = "CLOSED"
 )
 
+// CanTransitionTo reports whether the lifecycle allows moving to next. Active and
+// frozen accounts can switch back and forth or be closed, closing is final.
+func (status AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	switch status {
+	case AccountActive:
+		return next == AccountFrozen || next == AccountClosed
+	case AccountFrozen:
+		return next == AccountActive || next == AccountClosed
+	}
+
+	return false
+}
+
 type AccountType string
 
 const (
@@ -26,7 +41,37 @@ type Account struct {
 	FirstName     string
 	LastName      string
 	Balance       decimal.Decimal
+	HeldBalance   decimal.Decimal
 	Currency      string
 	AccountType   AccountType
 	AccountStatus AccountStatus
+
+	// Why and when the status last changed
+	StatusReason    string
+	StatusChangedAt *time.Time
+
+	// Interest earned but not yet paid, and the last day it was accrued for
+	AccruedInterest        decimal.Decimal
+	InterestAccruedThrough *time.Time
+
+	// Overrides of the account type's balance policy, nil uses the default
+	MinimumBalance *decimal.Decimal
+	OverdraftLimit *decimal.Decimal
+}
+
+// BalancePolicy limits how far debits may take an account. The available
+// balance may not drop below MinimumBalance minus OverdraftLimit.
+type BalancePolicy struct {
+	MinimumBalance decimal.Decimal `json:"minimum_balance"`
+	OverdraftLimit decimal.Decimal `json:"overdraft_limit"`
+}
+
+// Floor is the lowest available balance a debit may leave behind
+func (policy BalancePolicy) Floor() decimal.Decimal {
+	return policy.MinimumBalance.Sub(policy.OverdraftLimit)
+}
+
+// AvailableBalance is the ledger balance minus funds reserved by active holds
+func (account *Account) AvailableBalance() decimal.Decimal {
+	return account.Balance.Sub(account.HeldBalance)
 }
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// BalanceSnapshot caches the sum of an account's postings made before AsOf, so
+// point-in-time balances only need to add up the postings after it.
+type BalanceSnapshot struct {
+	gorm.Model
+	AccountNumber string
+	AsOf          time.Time
+	Balance       decimal.Decimal
+}
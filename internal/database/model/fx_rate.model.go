@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// FxRate is the price of one unit of BaseCurrency in QuoteCurrency, valid from
+// EffectiveAt until a newer rate for the same pair takes effect.
+type FxRate struct {
+	gorm.Model
+	BaseCurrency  string          `json:"base_currency"`
+	QuoteCurrency string          `json:"quote_currency"`
+	Rate          decimal.Decimal `json:"rate"`
+	EffectiveAt   time.Time       `json:"effective_at"`
+}
+
+// FxConversion describes money converted from SourceCurrency into TargetCurrency
+type FxConversion struct {
+	SourceAmount   decimal.Decimal `json:"source_amount"`
+	SourceCurrency string          `json:"source_currency"`
+	TargetAmount   decimal.Decimal `json:"target_amount"`
+	TargetCurrency string          `json:"target_currency"`
+	Rate           decimal.Decimal `json:"rate"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "ACTIVE"
+	HoldCaptured HoldStatus = "CAPTURED"
+	HoldVoided   HoldStatus = "VOIDED"
+	HoldExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold reserves funds on an account without moving them. While active its
+// amount is counted in the account's HeldBalance.
+type Hold struct {
+	gorm.Model
+	HoldId               string
+	AccountNumber        string
+	Amount               decimal.Decimal
+	CapturedAmount       decimal.Decimal
+	Currency             string
+	Status               HoldStatus
+	Memo                 string
+	ExpiresAt            time.Time
+	ReleasedAt           *time.Time
+	CaptureTransactionId string
+}
+
+func (hold *Hold) IsExpired(now time.Time) bool {
+	return hold.Status == HoldActive && !now.Before(hold.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package model
+
+import "gorm.io/gorm"
+
+// IdempotencyKey remembers a client supplied key together with a fingerprint of
+// the request it was first used with and, once finished, the response sent back.
+type IdempotencyKey struct {
+	gorm.Model
+	IdempotencyKey string
+	Scope          string
+	Fingerprint    string
+	ResponseStatus *int
+	ResponseBody   *string
+}
+
+func (key *IdempotencyKey) IsCompleted() bool {
+	return key.ResponseStatus != nil
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// InterestAccrual is the interest an account earned on one day
+type InterestAccrual struct {
+	gorm.Model
+	AccountNumber string          `json:"account_number"`
+	AccrualDate   time.Time       `json:"accrual_date"`
+	Balance       decimal.Decimal `json:"balance"`
+	AnnualRate    decimal.Decimal `json:"annual_rate"`
+	Amount        decimal.Decimal `json:"amount"`
+}
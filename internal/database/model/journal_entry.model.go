@@ -0,0 +1,39 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SystemAccountPrefix marks ledger accounts owned by the bank itself. They only
+// exist as posting targets and have no row in the accounts table.
+const SystemAccountPrefix = "SYSTEM_"
+
+// MigratedOpeningDescription is the description of the opening entries the
+// ledger migration booked for balances that predate the ledger
+const MigratedOpeningDescription = "Opening balance (migrated)"
+
+// JournalEntry groups the postings of a single money movement. The postings of
+// an entry always sum to zero per currency.
+type JournalEntry struct {
+	gorm.Model
+	TransactionId   string
+	TransactionType TransactionType
+	Amount          decimal.Decimal
+	Currency        string
+	Description     string
+	Postings        []Posting
+
+	// Set on reversals to the transaction being (partially) undone
+	ReversesTransactionId string
+}
+
+// Posting is one leg of a journal entry. A positive amount credits the account
+// (increases its balance), a negative amount debits it.
+type Posting struct {
+	gorm.Model
+	JournalEntryID uint
+	AccountNumber  string
+	Amount         decimal.Decimal
+	Currency       string
+}
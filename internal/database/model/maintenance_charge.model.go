@@ -0,0 +1,17 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// MaintenanceCharge records the monthly maintenance fee decided for an account.
+// Period is the month as YYYYMM.
+type MaintenanceCharge struct {
+	gorm.Model
+	AccountNumber string
+	Period        string
+	Amount        decimal.Decimal
+	Waived        bool
+	TransactionId string
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "PENDING"
+	OutboxStatusSent    OutboxStatus = "SENT"
+	OutboxStatusFailed  OutboxStatus = "FAILED"
+)
+
+// OutboxMessage is a transaction message waiting to be published to the broker.
+// It is written in the same Postgres transaction as the intent it describes and
+// carries the transaction log so the relay can materialize it in Mongo.
+type OutboxMessage struct {
+	gorm.Model
+	TransactionId  string
+	Payload        string
+	TransactionLog string
+	Status         OutboxStatus
+	Attempts       int
+	LastError      string
+	SentAt         *time.Time
+	// ClaimedUntil is set while a relay publishes the message and after a failed
+	// publish, relays skip the message until then
+	ClaimedUntil *time.Time
+}
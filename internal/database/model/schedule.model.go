@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "ACTIVE"
+	SchedulePaused    ScheduleStatus = "PAUSED"
+	ScheduleCompleted ScheduleStatus = "COMPLETED"
+	ScheduleCancelled ScheduleStatus = "CANCELLED"
+)
+
+// MissedRunPolicy decides what happens to occurrences that passed while the
+// scheduler was down
+type MissedRunPolicy string
+
+const (
+	// Run a single catch-up transfer for all missed occurrences
+	MissedRunOnce MissedRunPolicy = "RUN_ONCE"
+	// Record missed occurrences as skipped and wait for the next one
+	MissedRunSkip MissedRunPolicy = "SKIP"
+)
+
+// Schedule is a standing order moving Amount between two accounts. An empty
+// Recurrence runs once at StartAt.
+type Schedule struct {
+	gorm.Model
+	ScheduleId        string
+	FromAccountNumber string
+	ToAccountNumber   string
+	Amount            decimal.Decimal
+	Memo              string
+	Recurrence        string
+	MissedRuns        MissedRunPolicy
+	Status            ScheduleStatus
+	StartAt           time.Time
+	EndAt             *time.Time
+	NextRunAt         *time.Time
+	LastRunAt         *time.Time
+}
+
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunEnqueued ScheduleRunStatus = "ENQUEUED"
+	ScheduleRunSkipped  ScheduleRunStatus = "SKIPPED"
+	ScheduleRunFailed   ScheduleRunStatus = "FAILED"
+)
+
+// ScheduleRun records one occurrence of a schedule and the transaction it queued
+type ScheduleRun struct {
+	gorm.Model
+	ScheduleId        string
+	ScheduledFor      time.Time
+	Status            ScheduleRunStatus
+	TransactionId     string
+	MissedOccurrences int
+	Error             string
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Statement summarizes the completed transactions of an account over one
+// calendar month (Period, YYYY-MM, in UTC). Statements are never changed once
+// stored.
+type Statement struct {
+	gorm.Model
+	StatementId      string          `json:"statement_id"`
+	AccountNumber    string          `json:"account_number"`
+	Period           string          `json:"period"`
+	PeriodStart      time.Time       `json:"period_start"`
+	PeriodEnd        time.Time       `json:"period_end"`
+	Currency         string          `json:"currency"`
+	OpeningBalance   decimal.Decimal `json:"opening_balance"`
+	TotalCredits     decimal.Decimal `json:"total_credits"`
+	TotalDebits      decimal.Decimal `json:"total_debits"`
+	ClosingBalance   decimal.Decimal `json:"closing_balance"`
+	TransactionCount int             `json:"transaction_count"`
+	Lines            []StatementLine `gorm:"serializer:json" json:"lines,omitempty"`
+}
+
+// StatementLine is one posting against the account with the balance after it
+type StatementLine struct {
+	Date            time.Time       `json:"date"`
+	TransactionId   string          `json:"transaction_id"`
+	TransactionType TransactionType `json:"transaction_type"`
+	Description     string          `json:"description"`
+	Amount          decimal.Decimal `json:"amount"`
+	Balance         decimal.Decimal `json:"balance"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// TransactionBatch groups the transactions submitted in one batch request. The
+// progress of its items is read from their transaction logs.
+type TransactionBatch struct {
+	gorm.Model
+	BatchId   string
+	Reference string
+	ItemCount int
+}
+
+// TransactionBatchItem is one deposit or withdrawal of a batch and the
+// transaction it was queued as
+type TransactionBatchItem struct {
+	gorm.Model
+	BatchId       string
+	ItemIndex     int
+	TransactionId string
+	AccountNumber string
+	Type          TransactionType
+	Amount        decimal.Decimal
+	Currency      string
+	Memo          string
+}
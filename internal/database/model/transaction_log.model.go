@@ -12,6 +12,12 @@ type TransactionType string
 const (
 	TransactionTypeDeposit    TransactionType = "DEPOSIT"
 	TransactionTypeWithdrawal TransactionType = "WITHDRAWAL"
+	TransactionTypeTransfer   TransactionType = "TRANSFER"
+	TransactionTypeReversal   TransactionType = "REVERSAL"
+	TransactionTypeInterest   TransactionType = "INTEREST"
+	TransactionTypeFee        TransactionType = "FEE"
+
+	TransactionTypeOpeningBalance TransactionType = "OPENING_BALANCE"
 )
 
 type TransactionStatus string
@@ -36,8 +42,47 @@ type TransactionLog struct {
 	Metadata      map[string]any     `bson:"metadata" json:"metadata"`
 	Timestamp     time.Time          `bson:"timestamp" json:"timestamp"`
 	ProcessedAt   *time.Time         `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
+	FailureReason string             `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+
+	// Set when the amount was converted from another currency
+	SourceAmount    *decimal.Decimal `bson:"source_amount,omitempty" json:"source_amount,omitempty"`
+	SourceCurrency  string           `bson:"source_currency,omitempty" json:"source_currency,omitempty"`
+	FxRate          *decimal.Decimal `bson:"fx_rate,omitempty" json:"fx_rate,omitempty"`
+	ConvertedAmount *decimal.Decimal `bson:"converted_amount,omitempty" json:"converted_amount,omitempty"`
+
+	// Links between a transaction and the fee charged for it
+	FeeTransactionId    string           `bson:"fee_transaction_id,omitempty" json:"fee_transaction_id,omitempty"`
+	FeeAmount           *decimal.Decimal `bson:"fee_amount,omitempty" json:"fee_amount,omitempty"`
+	ParentTransactionId string           `bson:"parent_transaction_id,omitempty" json:"parent_transaction_id,omitempty"`
+
+	// Links between an original transaction and its reversals
+	OriginalTransactionId string          `bson:"original_transaction_id,omitempty" json:"original_transaction_id,omitempty"`
+	ReversalIds           []string        `bson:"reversal_ids,omitempty" json:"reversal_ids,omitempty"`
+	ReversedAmount        decimal.Decimal `bson:"reversed_amount" json:"reversed_amount"`
 
 	// For audit trail
 	InitiatedBy uint `bson:"initiated_by" json:"initiated_by"`
 	RetryCount  int  `bson:"retry_count" json:"retry_count"`
 }
+
+// SetFxConversion records the rate and both sides of a currency conversion
+func (txLog *TransactionLog) SetFxConversion(conversion *FxConversion) {
+	if conversion == nil {
+		return
+	}
+
+	txLog.SourceAmount = &conversion.SourceAmount
+	txLog.SourceCurrency = conversion.SourceCurrency
+	txLog.FxRate = &conversion.Rate
+	txLog.ConvertedAmount = &conversion.TargetAmount
+}
+
+// IsReversible reports whether the transaction type can be reversed at all
+func (txLog *TransactionLog) IsReversible() bool {
+	switch txLog.Type {
+	case TransactionTypeDeposit, TransactionTypeWithdrawal, TransactionTypeTransfer:
+		return txLog.OriginalTransactionId == ""
+	default:
+		return false
+	}
+}
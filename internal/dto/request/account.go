@@ -2,6 +2,7 @@ package requestdto
 
 import (
 	"golang-exercise/internal/database/model"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -19,16 +20,60 @@ type GetAccount struct {
 }
 
 type UpdateAccountStatus struct {
-	Status model.AccountStatus `json:"status"`
+	Status model.AccountStatus `json:"status" validate:"required"`
+	Reason string              `json:"reason" validate:"required"`
 }
 
+// A nil limit clears the account's override so the account type default applies
+type UpdateBalancePolicy struct {
+	MinimumBalance *decimal.Decimal `json:"minimum_balance"`
+	OverdraftLimit *decimal.Decimal `json:"overdraft_limit"`
+}
+
+// Currency is optional and defaults to the account currency. A different
+// currency is converted at the rate in effect.
 type MoveMoneyFromAccount struct {
 	AccountNumber string                `json:"account_number" validate:"required"`
 	Amount        decimal.Decimal       `json:"amount" validate:"required"`
+	Currency      string                `json:"currency"`
 	Type          model.TransactionType `json:"type" validate:"required"`
 	Memo          string                `json:"memo"`
 }
 
+// Items are validated together, the batch is only queued when every item is valid
+type CreateBatch struct {
+	Reference string                 `json:"reference"`
+	Items     []MoveMoneyFromAccount `json:"items" validate:"required"`
+}
+
+// Amount is in the source account's currency and is converted when the
+// destination account holds another currency
+type CreateTransfer struct {
+	FromAccountNumber string          `json:"from_account_number" validate:"required"`
+	ToAccountNumber   string          `json:"to_account_number" validate:"required"`
+	Amount            decimal.Decimal `json:"amount" validate:"required"`
+	Memo              string          `json:"memo"`
+}
+
+// ExpiresAt is optional and defaults to seven days from now
+type CreateHold struct {
+	Amount    decimal.Decimal `json:"amount" validate:"required"`
+	ExpiresAt *time.Time      `json:"expires_at"`
+	Memo      string          `json:"memo"`
+}
+
+// Amount is optional, leaving it out captures the full held amount
+type CaptureHold struct {
+	Amount decimal.Decimal `json:"amount"`
+	Memo   string          `json:"memo"`
+}
+
+// Amount is optional, leaving it out reverses whatever has not been reversed yet
+type ReverseTransaction struct {
+	Amount decimal.Decimal `json:"amount"`
+	Reason string          `json:"reason"`
+}
+
 type GetTransactionHistory struct {
 	AccountNumber string `json:"account_number" validate:"required"`
 	Limit         int    `json:"limit,omitempty"`
@@ -37,3 +82,52 @@ type GetTransactionHistory struct {
 	EndDate       string `json:"end_date,omitempty"`   // Format: YYYY-MM-DD
 	Status        string `json:"status,omitempty"`
 }
+
+type FxRateItem struct {
+	BaseCurrency  string          `json:"base_currency" validate:"required"`
+	QuoteCurrency string          `json:"quote_currency" validate:"required"`
+	Rate          decimal.Decimal `json:"rate" validate:"required"`
+	EffectiveAt   *time.Time      `json:"effective_at"`
+}
+
+type LoadFxRates struct {
+	Rates []FxRateItem `json:"rates" validate:"required"`
+}
+
+// Recurrence is a five field cron expression in UTC, "@every <duration>" or one
+// of @hourly, @daily, @weekly and @monthly. Leaving it empty runs the transfer
+// once at StartAt, which defaults to now.
+type CreateSchedule struct {
+	FromAccountNumber string                `json:"from_account_number" validate:"required"`
+	ToAccountNumber   string                `json:"to_account_number" validate:"required"`
+	Amount            decimal.Decimal       `json:"amount" validate:"required"`
+	Memo              string                `json:"memo"`
+	Recurrence        string                `json:"recurrence"`
+	MissedRuns        model.MissedRunPolicy `json:"missed_runs"`
+	StartAt           *time.Time            `json:"start_at"`
+	EndAt             *time.Time            `json:"end_at"`
+}
+
+// Only the given fields are changed. Status pauses (PAUSED) or resumes (ACTIVE)
+// the schedule. ClearEndAt removes the end so the schedule runs indefinitely.
+type UpdateSchedule struct {
+	Amount     *decimal.Decimal     `json:"amount"`
+	Memo       *string              `json:"memo"`
+	Recurrence *string              `json:"recurrence"`
+	EndAt      *time.Time           `json:"end_at"`
+	ClearEndAt bool                 `json:"clear_end_at"`
+	Status     model.ScheduleStatus `json:"status"`
+}
+
+// Either AccountNumber or AccountType picks the fee schedule to quote from
+type QuoteFee struct {
+	AccountNumber   string                `json:"account_number"`
+	AccountType     model.AccountType     `json:"account_type"`
+	TransactionType model.TransactionType `json:"transaction_type" validate:"required"`
+	Amount          decimal.Decimal       `json:"amount" validate:"required"`
+}
+
+// Period is the calendar month of the statement as YYYY-MM
+type GenerateStatement struct {
+	Period string `json:"period" validate:"required"`
+}
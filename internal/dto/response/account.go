@@ -45,3 +45,27 @@ type AccountBalanceResponse struct {
 	Currency      string          `json:"currency"`
 	Status        string          `json:"status"`
 }
+
+type BatchResponse struct {
+	BatchID   string              `json:"batch_id"`
+	Reference string              `json:"reference,omitempty"`
+	Status    string              `json:"status"`
+	Total     int                 `json:"total"`
+	Pending   int                 `json:"pending"`
+	Completed int                 `json:"completed"`
+	Failed    int                 `json:"failed"`
+	CreatedAt time.Time           `json:"created_at"`
+	Items     []BatchItemResponse `json:"items"`
+}
+
+type BatchItemResponse struct {
+	Index         int                     `json:"index"`
+	TransactionID string                  `json:"transaction_id"`
+	AccountNumber string                  `json:"account_number"`
+	Type          model.TransactionType   `json:"type"`
+	Amount        decimal.Decimal         `json:"amount"`
+	Currency      string                  `json:"currency"`
+	Status        model.TransactionStatus `json:"status"`
+	FailureReason string                  `json:"failure_reason,omitempty"`
+	ProcessedAt   *time.Time              `json:"processed_at,omitempty"`
+}
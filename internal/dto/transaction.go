@@ -16,4 +16,14 @@ type TransactionMessage struct {
 	Currency        string                `json:"currency"`
 	Description     string                `json:"description,omitempty"`
 	CreatedAt       time.Time             `json:"created_at"`
+
+	// For reversals, the transaction being reversed
+	OriginalTransactionId string `json:"original_transaction_id,omitempty"`
+
+	// For withdrawals capturing a previously placed hold
+	HoldId string `json:"hold_id,omitempty"`
+
+	// Set when money changes currency, Amount is then in the account's currency
+	// for deposits and withdrawals and in the source account's currency for transfers
+	Fx *model.FxConversion `json:"fx,omitempty"`
 }
@@ -8,6 +8,7 @@ const (
 	ValidationError     ErrorType = "VALIDATION_ERROR"
 	InternalError       ErrorType = "INTERNAL_ERROR"
 	EntityNotFoundError ErrorType = "NOT_FOUND_ERROR"
+	ConflictError       ErrorType = "CONFLICT_ERROR"
 )
 
 type ApiError struct {
@@ -0,0 +1,426 @@
+// Package export writes the transaction history of an account as CSV, OFX or
+// ISO 20022 camt.053 for spreadsheets and accounting tools. Entries are
+// written one at a time, so a history of any length is streamed.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/reconcile"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	FORMAT_CSV     = "csv"
+	FORMAT_OFX     = "ofx"
+	FORMAT_CAMT053 = "camt053"
+)
+
+// Amounts are exported in the minor unit
+const AMOUNT_PLACES = 2
+
+const (
+	OFX_DATE_LAYOUT  = "20060102150405.000[0:GMT]"
+	CAMT053_XMLNS    = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+	CAMT_DATE_LAYOUT = "2006-01-02T15:04:05Z"
+)
+
+var ErrUnknownFormat = errors.New("format must be csv, ofx or camt053")
+
+// Statement describes the exported range [From, To) of the account. The
+// balances are the account's at those two instants, so they only agree with
+// the entries when every transaction of the range is exported.
+type Statement struct {
+	BankID         string
+	AccountNumber  string
+	AccountType    model.AccountType
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	CreatedAt      time.Time
+}
+
+// Entry is one transaction as the exported account sees it. Amount is signed,
+// credits are positive.
+type Entry struct {
+	TransactionId string
+	Type          model.TransactionType
+	Status        model.TransactionStatus
+	Description   string
+	Amount        decimal.Decimal
+	Timestamp     time.Time
+	BookedAt      time.Time
+}
+
+// NewEntry derives the entry of the account from its log. Reversals need the
+// log of the transaction they reverse; false is returned when the amount
+// cannot be derived.
+func NewEntry(txLog *model.TransactionLog, accountID uint, original *model.TransactionLog) (*Entry, bool) {
+	amount, ok := reconcile.Effect(txLog, accountID, original)
+	if !ok {
+		return nil, false
+	}
+
+	entry := &Entry{
+		TransactionId: txLog.TransactionId,
+		Type:          txLog.Type,
+		Status:        txLog.Status,
+		Description:   txLog.Memo,
+		Amount:        amount,
+		Timestamp:     txLog.Timestamp.UTC(),
+		BookedAt:      txLog.Timestamp.UTC(),
+	}
+	if txLog.ProcessedAt != nil {
+		entry.BookedAt = txLog.ProcessedAt.UTC()
+	}
+
+	return entry, true
+}
+
+// Writer writes one statement: Begin once, Write for every entry, then End.
+// Flush pushes what is buffered to the underlying writer. HasBalances reports
+// whether the format carries the opening and closing balance.
+type Writer interface {
+	ContentType() string
+	Extension() string
+	HasBalances() bool
+	Begin(statement *Statement) error
+	Write(entry *Entry) error
+	End(statement *Statement) error
+	Flush() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FORMAT_CSV:
+		return &csvWriter{csv: csv.NewWriter(w)}, nil
+	case FORMAT_OFX:
+		return &ofxWriter{xmlWriter{xml: xml.NewEncoder(w)}}, nil
+	case FORMAT_CAMT053:
+		return &camtWriter{xmlWriter: xmlWriter{xml: xml.NewEncoder(w)}}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type csvWriter struct {
+	csv      *csv.Writer
+	currency string
+}
+
+func (writer *csvWriter) ContentType() string { return "text/csv" }
+func (writer *csvWriter) Extension() string   { return "csv" }
+func (writer *csvWriter) HasBalances() bool   { return false }
+
+func (writer *csvWriter) Begin(statement *Statement) error {
+	writer.currency = statement.Currency
+	return writer.csv.Write([]string{"date", "transaction_id", "type", "status", "description", "amount", "currency"})
+}
+
+func (writer *csvWriter) Write(entry *Entry) error {
+	return writer.csv.Write([]string{
+		entry.Timestamp.Format(time.RFC3339),
+		entry.TransactionId,
+		string(entry.Type),
+		string(entry.Status),
+		entry.Description,
+		entry.Amount.StringFixed(AMOUNT_PLACES),
+		writer.currency,
+	})
+}
+
+func (writer *csvWriter) End(statement *Statement) error {
+	return writer.Flush()
+}
+
+func (writer *csvWriter) Flush() error {
+	writer.csv.Flush()
+	return writer.csv.Error()
+}
+
+// xmlWriter encodes the document a token at a time, remembering the first error
+type xmlWriter struct {
+	xml *xml.Encoder
+	err error
+}
+
+func (writer *xmlWriter) open(name string, attrs ...xml.Attr) {
+	writer.token(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
+}
+
+func (writer *xmlWriter) close(name string) {
+	writer.token(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func (writer *xmlWriter) element(name string, value string, attrs ...xml.Attr) {
+	writer.open(name, attrs...)
+	writer.token(xml.CharData(value))
+	writer.close(name)
+}
+
+func (writer *xmlWriter) token(token xml.Token) {
+	if writer.err == nil {
+		writer.err = writer.xml.EncodeToken(token)
+	}
+}
+
+func (writer *xmlWriter) Flush() error {
+	if writer.err != nil {
+		return writer.err
+	}
+
+	return writer.xml.Flush()
+}
+
+// ofxWriter writes an OFX 2.2 bank statement. OFX has no notion of pending or
+// failed transactions, so only completed ones are listed.
+type ofxWriter struct {
+	xmlWriter
+}
+
+func (writer *ofxWriter) ContentType() string { return "application/x-ofx" }
+func (writer *ofxWriter) Extension() string   { return "ofx" }
+func (writer *ofxWriter) HasBalances() bool   { return true }
+
+func (writer *ofxWriter) status() {
+	writer.open("STATUS")
+	writer.element("CODE", "0")
+	writer.element("SEVERITY", "INFO")
+	writer.close("STATUS")
+}
+
+func (writer *ofxWriter) Begin(statement *Statement) error {
+	writer.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8" standalone="no"`)})
+	writer.token(xml.ProcInst{Target: "OFX", Inst: []byte(`OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`)})
+
+	writer.open("OFX")
+	writer.open("SIGNONMSGSRSV1")
+	writer.open("SONRS")
+	writer.status()
+	writer.element("DTSERVER", statement.CreatedAt.UTC().Format(OFX_DATE_LAYOUT))
+	writer.element("LANGUAGE", "ENG")
+	writer.close("SONRS")
+	writer.close("SIGNONMSGSRSV1")
+
+	writer.open("BANKMSGSRSV1")
+	writer.open("STMTTRNRS")
+	writer.element("TRNUID", "0")
+	writer.status()
+	writer.open("STMTRS")
+	writer.element("CURDEF", statement.Currency)
+	writer.open("BANKACCTFROM")
+	writer.element("BANKID", statement.BankID)
+	writer.element("ACCTID", statement.AccountNumber)
+	writer.element("ACCTTYPE", string(statement.AccountType))
+	writer.close("BANKACCTFROM")
+
+	writer.open("BANKTRANLIST")
+	writer.element("DTSTART", statement.From.UTC().Format(OFX_DATE_LAYOUT))
+	writer.element("DTEND", statement.To.UTC().Format(OFX_DATE_LAYOUT))
+
+	return writer.err
+}
+
+func (writer *ofxWriter) Write(entry *Entry) error {
+	if entry.Status != model.TransactionStatusCompleted {
+		return nil
+	}
+
+	writer.open("STMTTRN")
+	writer.element("TRNTYPE", ofxTransactionType(entry))
+	writer.element("DTPOSTED", entry.BookedAt.Format(OFX_DATE_LAYOUT))
+	writer.element("DTUSER", entry.Timestamp.Format(OFX_DATE_LAYOUT))
+	writer.element("TRNAMT", entry.Amount.StringFixed(AMOUNT_PLACES))
+	writer.element("FITID", entry.TransactionId)
+	writer.element("NAME", string(entry.Type))
+	if entry.Description != "" {
+		writer.element("MEMO", entry.Description)
+	}
+	writer.close("STMTTRN")
+
+	return writer.err
+}
+
+func ofxTransactionType(entry *Entry) string {
+	switch entry.Type {
+	case model.TransactionTypeDeposit:
+		return "DEP"
+	case model.TransactionTypeTransfer:
+		return "XFER"
+	case model.TransactionTypeInterest:
+		return "INT"
+	case model.TransactionTypeFee:
+		return "FEE"
+	}
+
+	if entry.Amount.IsNegative() {
+		return "DEBIT"
+	}
+
+	return "CREDIT"
+}
+
+func (writer *ofxWriter) End(statement *Statement) error {
+	writer.close("BANKTRANLIST")
+
+	writer.open("LEDGERBAL")
+	writer.element("BALAMT", statement.ClosingBalance.StringFixed(AMOUNT_PLACES))
+	writer.element("DTASOF", statement.To.UTC().Format(OFX_DATE_LAYOUT))
+	writer.close("LEDGERBAL")
+
+	writer.close("STMTRS")
+	writer.close("STMTTRNRS")
+	writer.close("BANKMSGSRSV1")
+	writer.close("OFX")
+
+	return writer.Flush()
+}
+
+// camtWriter writes an ISO 20022 camt.053 bank to customer statement. Entries
+// carry their status: BOOK when completed, PDNG while in flight and INFO when
+// failed.
+type camtWriter struct {
+	xmlWriter
+	currency string
+}
+
+func (writer *camtWriter) ContentType() string { return "application/xml" }
+func (writer *camtWriter) Extension() string   { return "xml" }
+func (writer *camtWriter) HasBalances() bool   { return true }
+
+func (writer *camtWriter) amount(amount decimal.Decimal) {
+	writer.element("Amt", amount.Abs().StringFixed(AMOUNT_PLACES), xml.Attr{Name: xml.Name{Local: "Ccy"}, Value: writer.currency})
+	writer.element("CdtDbtInd", creditDebit(amount))
+}
+
+func creditDebit(amount decimal.Decimal) string {
+	if amount.IsNegative() {
+		return "DBIT"
+	}
+
+	return "CRDT"
+}
+
+func (writer *camtWriter) balance(code string, amount decimal.Decimal, at time.Time) {
+	writer.open("Bal")
+	writer.open("Tp")
+	writer.open("CdOrPrtry")
+	writer.element("Cd", code)
+	writer.close("CdOrPrtry")
+	writer.close("Tp")
+	writer.amount(amount)
+	writer.open("Dt")
+	writer.element("DtTm", at.UTC().Format(CAMT_DATE_LAYOUT))
+	writer.close("Dt")
+	writer.close("Bal")
+}
+
+func (writer *camtWriter) Begin(statement *Statement) error {
+	writer.currency = statement.Currency
+	statementID := fmt.Sprintf("HIST_%s_%s", statement.AccountNumber, statement.CreatedAt.UTC().Format("20060102150405"))
+	createdAt := statement.CreatedAt.UTC().Format(CAMT_DATE_LAYOUT)
+
+	writer.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+
+	writer.open("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: CAMT053_XMLNS})
+	writer.open("BkToCstmrStmt")
+	writer.open("GrpHdr")
+	writer.element("MsgId", statementID)
+	writer.element("CreDtTm", createdAt)
+	writer.close("GrpHdr")
+
+	writer.open("Stmt")
+	writer.element("Id", statementID)
+	writer.element("CreDtTm", createdAt)
+	writer.open("FrToDt")
+	writer.element("FrDtTm", statement.From.UTC().Format(CAMT_DATE_LAYOUT))
+	writer.element("ToDtTm", statement.To.UTC().Format(CAMT_DATE_LAYOUT))
+	writer.close("FrToDt")
+
+	writer.open("Acct")
+	writer.open("Id")
+	writer.open("Othr")
+	writer.element("Id", statement.AccountNumber)
+	writer.close("Othr")
+	writer.close("Id")
+	writer.element("Ccy", statement.Currency)
+	writer.close("Acct")
+
+	writer.balance("OPBD", statement.OpeningBalance, statement.From)
+	writer.balance("CLBD", statement.ClosingBalance, statement.To)
+
+	return writer.err
+}
+
+func (writer *camtWriter) Write(entry *Entry) error {
+	writer.open("Ntry")
+	writer.element("NtryRef", entry.TransactionId)
+	writer.amount(entry.Amount)
+	if entry.Type == model.TransactionTypeReversal {
+		writer.element("RvslInd", "true")
+	}
+
+	writer.open("Sts")
+	writer.element("Cd", camtStatus(entry.Status))
+	writer.close("Sts")
+
+	if entry.Status == model.TransactionStatusCompleted {
+		writer.open("BookgDt")
+		writer.element("DtTm", entry.BookedAt.Format(CAMT_DATE_LAYOUT))
+		writer.close("BookgDt")
+	}
+	writer.open("ValDt")
+	writer.element("DtTm", entry.Timestamp.Format(CAMT_DATE_LAYOUT))
+	writer.close("ValDt")
+	writer.element("AcctSvcrRef", entry.TransactionId)
+
+	writer.open("BkTxCd")
+	writer.open("Prtry")
+	writer.element("Cd", string(entry.Type))
+	writer.close("Prtry")
+	writer.close("BkTxCd")
+
+	writer.open("NtryDtls")
+	writer.open("TxDtls")
+	writer.open("Refs")
+	writer.element("EndToEndId", entry.TransactionId)
+	writer.close("Refs")
+	if entry.Description != "" {
+		writer.open("RmtInf")
+		writer.element("Ustrd", entry.Description)
+		writer.close("RmtInf")
+	}
+	writer.close("TxDtls")
+	writer.close("NtryDtls")
+	writer.close("Ntry")
+
+	return writer.err
+}
+
+func camtStatus(status model.TransactionStatus) string {
+	switch status {
+	case model.TransactionStatusCompleted:
+		return "BOOK"
+	case model.TransactionStatusFailed:
+		return "INFO"
+	default:
+		return "PDNG"
+	}
+}
+
+func (writer *camtWriter) End(statement *Statement) error {
+	writer.close("Stmt")
+	writer.close("BkToCstmrStmt")
+	writer.close("Document")
+
+	return writer.Flush()
+}
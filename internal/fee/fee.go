@@ -0,0 +1,93 @@
+// Package fee prices transaction and maintenance fees from a fee schedule.
+package fee
+
+import (
+	"golang-exercise/internal/database/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fees are charged in the minor unit (cents), percentage fees are rounded half up
+const FEE_PLACES = 2
+
+// TransactionFee charges Flat plus Percentage of the amount, kept between Min
+// and Max. A zero Max means the fee is not capped.
+type TransactionFee struct {
+	AccountType     model.AccountType     `json:"account_type"`
+	TransactionType model.TransactionType `json:"transaction_type"`
+	Flat            decimal.Decimal       `json:"flat"`
+	Percentage      decimal.Decimal       `json:"percentage"`
+	Min             decimal.Decimal       `json:"min"`
+	Max             decimal.Decimal       `json:"max"`
+}
+
+// Quote prices the fee for moving amount
+func (rule *TransactionFee) Quote(amount decimal.Decimal) decimal.Decimal {
+	charge := rule.Flat.Add(amount.Mul(rule.Percentage)).Round(FEE_PLACES)
+
+	if charge.LessThan(rule.Min) {
+		charge = rule.Min
+	}
+
+	if rule.Max.IsPositive() && charge.GreaterThan(rule.Max) {
+		charge = rule.Max
+	}
+
+	return charge
+}
+
+// MaintenanceFee is charged once a month. Accounts whose balance is at least
+// WaiveAbove don't pay it, a zero WaiveAbove never waives the fee.
+type MaintenanceFee struct {
+	AccountType model.AccountType `json:"account_type"`
+	Amount      decimal.Decimal   `json:"amount"`
+	WaiveAbove  decimal.Decimal   `json:"waive_above"`
+}
+
+// Charge returns the fee owed by an account with the given balance
+func (rule *MaintenanceFee) Charge(balance decimal.Decimal) decimal.Decimal {
+	if rule.WaiveAbove.IsPositive() && balance.GreaterThanOrEqual(rule.WaiveAbove) {
+		return decimal.Zero
+	}
+
+	return rule.Amount
+}
+
+type Schedule struct {
+	TransactionFees []TransactionFee `json:"transaction_fees"`
+	MaintenanceFees []MaintenanceFee `json:"maintenance_fees"`
+}
+
+// TransactionFeeFor returns the rule for the combination, or nil when it is free
+func (schedule *Schedule) TransactionFeeFor(accountType model.AccountType, transactionType model.TransactionType) *TransactionFee {
+	for index := range schedule.TransactionFees {
+		rule := &schedule.TransactionFees[index]
+		if rule.AccountType == accountType && rule.TransactionType == transactionType {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// MaintenanceFeeFor returns the monthly fee of the account type, or nil when there is none
+func (schedule *Schedule) MaintenanceFeeFor(accountType model.AccountType) *MaintenanceFee {
+	for index := range schedule.MaintenanceFees {
+		rule := &schedule.MaintenanceFees[index]
+		if rule.AccountType == accountType {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Quote prices the transaction fee, zero when no rule applies
+func (schedule *Schedule) Quote(accountType model.AccountType, transactionType model.TransactionType, amount decimal.Decimal) decimal.Decimal {
+	rule := schedule.TransactionFeeFor(accountType, transactionType)
+	if rule == nil {
+		return decimal.Zero
+	}
+
+	return rule.Quote(amount)
+}
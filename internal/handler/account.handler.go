@@ -1,16 +1,22 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
+	"golang-exercise/internal/accountimport"
 	"golang-exercise/internal/database/model"
 	dto "golang-exercise/internal/dto"
 	requestdto "golang-exercise/internal/dto/request"
 	customError "golang-exercise/internal/error"
 	"golang-exercise/internal/messaging"
+	"io"
+	"mime/multipart"
 	"time"
 
 	"golang-exercise/internal/service"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -21,20 +27,26 @@ type AccountHandler struct {
 	accountService       *service.AccountService
 	transactionService   *service.TransactionService
 	txLogService         *service.TransactionLogService
-	transactionPublisher *messaging.TransactionPublisher
+	ledgerService        *service.LedgerService
+	outboxService        *service.OutboxService
+	fxService            *service.FxService
+	feeService           *service.FeeService
+	transactionPublisher messaging.Publisher
 }
 
-func NewAccountHandler(accountService *service.AccountService, transactionService *service.TransactionService, txLogService *service.TransactionLogService, trxnPublisher *messaging.TransactionPublisher) *AccountHandler {
+func NewAccountHandler(accountService *service.AccountService, transactionService *service.TransactionService, txLogService *service.TransactionLogService, ledgerService *service.LedgerService, outboxService *service.OutboxService, fxService *service.FxService, feeService *service.FeeService, trxnPublisher messaging.Publisher) *AccountHandler {
 	return &AccountHandler{
 		accountService:       accountService,
 		transactionService:   transactionService,
 		txLogService:         txLogService,
+		ledgerService:        ledgerService,
+		outboxService:        outboxService,
+		fxService:            fxService,
+		feeService:           feeService,
 		transactionPublisher: trxnPublisher,
 	}
 }
 
-var MINIMUM_ACCOUNT_BALANCE = decimal.NewFromInt(100)
-
 func (accHandler *AccountHandler) CreateAccount(c *gin.Context) {
 	var req requestdto.CreateAccount
 
@@ -43,6 +55,11 @@ func (accHandler *AccountHandler) CreateAccount(c *gin.Context) {
 		return
 	}
 
+	if !service.IsValidCurrency(req.Currency) {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("currency must be a three letter code"))
+		return
+	}
+
 	account, err := accHandler.accountService.CreateAccount(c, &req)
 	if err != nil {
 		// Ideally we should not flag internal errors to the user, but I am doing it here so that I know if something goes wrong
@@ -58,6 +75,71 @@ func (accHandler *AccountHandler) CreateAccount(c *gin.Context) {
 	})
 }
 
+// ImportAccounts opens the accounts of a CSV file, uploaded as the "file" field
+// of a multipart form or sent as the request body. With dry_run=true the rows
+// are only validated.
+func (accHandler *AccountHandler) ImportAccounts(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := multipartFile(c.Request, "file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, customError.NewValidationError("file is required"))
+			return
+		}
+		defer file.Close()
+
+		body = file
+	}
+
+	reader, err := accountimport.NewReader(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	report, err := accHandler.accountService.ImportAccounts(c, reader, service.ImportOptions{DryRun: dryRun})
+	if err != nil {
+		// The accounts of the rows before the malformed one are already created
+		c.JSON(http.StatusBadRequest, customError.NewCustomError(customError.ValidationError, err.Error(), report))
+		return
+	}
+
+	message := "Accounts imported"
+	if dryRun {
+		message = "Accounts validated, nothing was created"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": report.Failed == 0,
+		"message": message,
+		"data":    report,
+	})
+}
+
+// multipartFile returns the named part of a multipart form as it streams in,
+// rather than buffering the whole upload as FormFile does. Parts before it are
+// skipped.
+func multipartFile(request *http.Request, name string) (*multipart.Part, error) {
+	form, err := request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := form.NextPart()
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FormName() == name {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
 func (accHandler *AccountHandler) doesAccountExistsCheck(c *gin.Context, accountNumber string) *model.Account {
 	req := &requestdto.GetAccount{
 		AccountNumber: accountNumber,
@@ -89,6 +171,51 @@ func (accHandler *AccountHandler) GetAccount(c *gin.Context) {
 	})
 }
 
+func (accHandler *AccountHandler) UpdateAccountStatus(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	var req requestdto.UpdateAccountStatus
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	switch req.Status {
+	case model.AccountActive, model.AccountFrozen, model.AccountClosed:
+	default:
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("status must be one of ACTIVE, FROZEN or CLOSED"))
+		return
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("reason is required"))
+		return
+	}
+
+	if accHandler.doesAccountExistsCheck(c, accountNumber) == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	account, err := accHandler.accountService.UpdateStatus(c, accountNumber, req.Status, req.Reason)
+	if errors.Is(err, service.ErrInvalidStatusTransition) {
+		c.JSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, err.Error(), accountNumber))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account status updated successfully!",
+		"data": gin.H{
+			"account": account,
+		},
+	})
+}
+
 func (accHandler *AccountHandler) DepositFundsAsync(c *gin.Context) {
 	var req requestdto.MoveMoneyFromAccount
 
@@ -103,6 +230,7 @@ func (accHandler *AccountHandler) DepositFundsAsync(c *gin.Context) {
 			"invalid transaction operation",
 			"invalid operation",
 		))
+		return
 	}
 
 	account := accHandler.doesAccountExistsCheck(c, req.AccountNumber)
@@ -156,6 +284,7 @@ func (accHandler *AccountHandler) WithdrawFundsAsync(c *gin.Context) {
 			"invalid transaction operation",
 			"invalid operation",
 		))
+		return
 	}
 
 	account := accHandler.doesAccountExistsCheck(c, req.AccountNumber)
@@ -164,8 +293,9 @@ func (accHandler *AccountHandler) WithdrawFundsAsync(c *gin.Context) {
 		return
 	}
 
-	if account.Balance.Sub(req.Amount).LessThan(MINIMUM_ACCOUNT_BALANCE) {
-		c.JSON(http.StatusBadRequest, customError.NewValidationError("insufficient balance"))
+	if err := accHandler.accountService.CheckDebit(account, req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
 	}
 
 	// Create transaction message
@@ -223,10 +353,39 @@ func (accHandler *AccountHandler) ProcessFunds(c *gin.Context) {
 		return
 	}
 
-	// For withdrawal, check minimum balance
-	if req.Type == model.TransactionTypeWithdrawal {
-		if account.Balance.Sub(req.Amount).LessThan(MINIMUM_ACCOUNT_BALANCE) {
-			c.JSON(http.StatusBadRequest, customError.NewValidationError("insufficient balance"))
+	if req.Currency != "" && !service.IsValidCurrency(req.Currency) {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("currency must be a three letter code"))
+		return
+	}
+
+	// Money in another currency is converted into the account currency up front
+	amount := req.Amount
+	var conversion *model.FxConversion
+	if req.Currency != "" && req.Currency != account.Currency {
+		var err error
+		conversion, err = accHandler.fxService.Convert(c, req.Amount, req.Currency, account.Currency, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+			return
+		}
+
+		amount = conversion.TargetAmount
+	}
+
+	// Reject requests that clearly break the balance policy early, the worker
+	// enforces it again under the row lock. The fee is taken from the same funds.
+	fee := accHandler.feeService.Quote(account.AccountType, req.Type, amount)
+	debit := decimal.Zero
+	switch {
+	case req.Type == model.TransactionTypeWithdrawal:
+		debit = amount.Add(fee)
+	case fee.GreaterThan(amount):
+		debit = fee.Sub(amount)
+	}
+
+	if debit.IsPositive() {
+		if err := accHandler.accountService.CheckDebit(account, debit); err != nil {
+			c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
 			return
 		}
 	}
@@ -239,7 +398,7 @@ func (accHandler *AccountHandler) ProcessFunds(c *gin.Context) {
 		TransactionId: transactionID,
 		FromAccountId: account.ID,
 		ToAccountId:   account.ID, // Same account for single account operations
-		Amount:        req.Amount,
+		Amount:        amount,
 		Currency:      account.Currency,
 		Type:          req.Type,
 		Status:        model.TransactionStatusInprogress,
@@ -247,31 +406,22 @@ func (accHandler *AccountHandler) ProcessFunds(c *gin.Context) {
 		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
 		Timestamp:     time.Now(),
 	}
-
-	if err := accHandler.txLogService.LogTransaction(c, txLog); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to create transaction log",
-			"details": err.Error(),
-		})
-		return
-	}
+	txLog.SetFxConversion(conversion)
 
 	// Create transaction message for RabbitMQ
 	txMsg := &dto.TransactionMessage{
 		ID:            transactionID,
 		Type:          req.Type,
 		AccountNumber: req.AccountNumber,
-		Amount:        req.Amount,
+		Amount:        amount,
 		Currency:      account.Currency,
 		Description:   req.Memo,
+		Fx:            conversion,
 		CreatedAt:     time.Now(),
 	}
 
-	// Publish to RabbitMQ broker instead of processing directly
-	err := accHandler.transactionPublisher.PublishTransaction(txMsg)
-	if err != nil {
-		// Update transaction log to failed status
-		accHandler.txLogService.UpdateTransactionStatus(c, transactionID, model.TransactionStatusFailed)
+	// Record the intent in the outbox, the relay publishes it to the broker
+	if err := accHandler.outboxService.EnqueueTransaction(c, txLog, txMsg, nil); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to queue transaction",
 			"details": err.Error(),
@@ -301,11 +451,137 @@ func (accHandler *AccountHandler) GetAccountBalance(c *gin.Context) {
 		return
 	}
 
+	// Historical balances come from the ledger, the balance column only knows the present
+	if asOfParam := c.Query("as_of"); asOfParam != "" {
+		asOf, err := time.Parse(time.RFC3339Nano, asOfParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, customError.NewValidationError("as_of must be an RFC 3339 timestamp"))
+			return
+		}
+
+		balance, err := accHandler.ledgerService.GetBalanceAsOf(c, account.AccountNumber, asOf)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Your account balance",
+			"data": gin.H{
+				"account_number": account.AccountNumber,
+				"as_of":          asOf.UTC(),
+				"balance":        balance,
+				"currency":       account.Currency,
+			},
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Your account balance",
 		"data": gin.H{
-			"Balance": account.Balance,
+			"Balance":          account.Balance,
+			"HeldBalance":      account.HeldBalance,
+			"AvailableBalance": account.AvailableBalance(),
+		},
+	})
+}
+
+func (accHandler *AccountHandler) GetBalancePolicy(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	account := accHandler.doesAccountExistsCheck(c, accountNumber)
+	if account == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account balance policy",
+		"data": gin.H{
+			"policy":  accHandler.accountService.BalancePolicy(account),
+			"default": service.DefaultBalancePolicy(account.AccountType),
+		},
+	})
+}
+
+func (accHandler *AccountHandler) UpdateBalancePolicy(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	var req requestdto.UpdateBalancePolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	if accHandler.doesAccountExistsCheck(c, accountNumber) == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	account, err := accHandler.accountService.UpdateBalancePolicy(c, accountNumber, req.MinimumBalance, req.OverdraftLimit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Balance policy updated successfully!",
+		"data": gin.H{
+			"policy":  accHandler.accountService.BalancePolicy(account),
+			"default": service.DefaultBalancePolicy(account.AccountType),
+		},
+	})
+}
+
+func (accHandler *AccountHandler) GetAccountPostings(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	account := accHandler.doesAccountExistsCheck(c, accountNumber)
+	if account == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	limit := 10 // default
+	offset := 0 // default
+
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+
+	if parsed, err := strconv.Atoi(c.Query("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+
+	postings, total, err := accHandler.ledgerService.GetPostings(c, account.AccountNumber, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	postedBalance, inBalance, err := accHandler.ledgerService.VerifyBalance(c, account)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account postings retrieved successfully",
+		"data": gin.H{
+			"account_number": account.AccountNumber,
+			"balance":        account.Balance,
+			"posted_balance": postedBalance,
+			"in_balance":     inBalance,
+			"postings":       postings,
+			"total":          total,
+			"limit":          limit,
+			"offset":         offset,
 		},
 	})
 }
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	requestdto "golang-exercise/internal/dto/request"
+	responsedto "golang-exercise/internal/dto/response"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BatchHandler struct {
+	batchService *service.BatchService
+}
+
+func NewBatchHandler(batchService *service.BatchService) *BatchHandler {
+	return &BatchHandler{
+		batchService: batchService,
+	}
+}
+
+// CreateBatch queues many deposits and withdrawals at once. Nothing is queued
+// unless every item is valid, the rejected items are listed in the details.
+func (batchHandler *BatchHandler) CreateBatch(c *gin.Context) {
+	var req requestdto.CreateBatch
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	batch, items, err := batchHandler.batchService.CreateBatch(c, &req)
+
+	var invalid *service.BatchValidationError
+	switch {
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, customError.NewCustomError(customError.ValidationError, invalid.Error(), invalid.Items))
+		return
+	case errors.Is(err, service.ErrInvalidBatch):
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue batch",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	transactionIDs := make([]string, len(items))
+	for i, item := range items {
+		transactionIDs[i] = item.TransactionId
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Batch queued successfully!",
+		"data": gin.H{
+			"BatchID":        batch.BatchId,
+			"TransactionIDs": transactionIDs,
+			"Status":         service.BatchStatusInProgress,
+		},
+	})
+}
+
+func (batchHandler *BatchHandler) GetBatch(c *gin.Context) {
+	batchID := c.Param("batch_id")
+
+	progress, err := batchHandler.batchService.GetBatchProgress(c, batchID)
+	if errors.Is(err, service.ErrBatchNotFound) {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("batch", "not found"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	response := responsedto.BatchResponse{
+		BatchID:   progress.Batch.BatchId,
+		Reference: progress.Batch.Reference,
+		Status:    string(progress.Status),
+		Total:     len(progress.Items),
+		Pending:   progress.Pending,
+		Completed: progress.Completed,
+		Failed:    progress.Failed,
+		CreatedAt: progress.Batch.CreatedAt,
+		Items:     make([]responsedto.BatchItemResponse, len(progress.Items)),
+	}
+
+	for i, itemProgress := range progress.Items {
+		response.Items[i] = responsedto.BatchItemResponse{
+			Index:         itemProgress.Item.ItemIndex,
+			TransactionID: itemProgress.Item.TransactionId,
+			AccountNumber: itemProgress.Item.AccountNumber,
+			Type:          itemProgress.Item.Type,
+			Amount:        itemProgress.Item.Amount,
+			Currency:      itemProgress.Item.Currency,
+			Status:        itemProgress.Status,
+			FailureReason: itemProgress.FailureReason,
+			ProcessedAt:   itemProgress.ProcessedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Batch retrieved successfully",
+		"data":    response,
+	})
+}
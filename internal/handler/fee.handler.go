@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeeHandler struct {
+	accountService *service.AccountService
+	feeService     *service.FeeService
+}
+
+func NewFeeHandler(accountService *service.AccountService, feeService *service.FeeService) *FeeHandler {
+	return &FeeHandler{
+		accountService: accountService,
+		feeService:     feeService,
+	}
+}
+
+func (feeHandler *FeeHandler) GetSchedule(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Active fee schedule",
+		"data": gin.H{
+			"schedule": feeHandler.feeService.Schedule(),
+		},
+	})
+}
+
+// QuoteFee prices a transaction without moving any money
+func (feeHandler *FeeHandler) QuoteFee(c *gin.Context) {
+	var req requestdto.QuoteFee
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("amount must be greater than zero"))
+		return
+	}
+
+	// Fees are only charged on deposits and withdrawals
+	if req.TransactionType != model.TransactionTypeDeposit && req.TransactionType != model.TransactionTypeWithdrawal {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("transaction_type must be DEPOSIT or WITHDRAWAL"))
+		return
+	}
+
+	accountType := req.AccountType
+	if req.AccountNumber != "" {
+		account, err := feeHandler.accountService.GetAccount(c, &requestdto.GetAccount{AccountNumber: req.AccountNumber})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+			return
+		}
+		accountType = account.AccountType
+	}
+
+	if accountType != model.AccountTypeChecking && accountType != model.AccountTypeSaving {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("account_number or a valid account_type is required"))
+		return
+	}
+
+	charge := feeHandler.feeService.Quote(accountType, req.TransactionType, req.Amount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Fee quote",
+		"data": gin.H{
+			"account_type":     accountType,
+			"transaction_type": req.TransactionType,
+			"amount":           req.Amount,
+			"fee":              charge,
+			"total":            req.Amount.Add(charge),
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FxHandler struct {
+	fxService *service.FxService
+}
+
+func NewFxHandler(fxService *service.FxService) *FxHandler {
+	return &FxHandler{
+		fxService: fxService,
+	}
+}
+
+func (fxHandler *FxHandler) LoadRates(c *gin.Context) {
+	var req requestdto.LoadFxRates
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	rates := make([]model.FxRate, 0, len(req.Rates))
+	for _, item := range req.Rates {
+		rate := model.FxRate{
+			BaseCurrency:  strings.ToUpper(item.BaseCurrency),
+			QuoteCurrency: strings.ToUpper(item.QuoteCurrency),
+			Rate:          item.Rate,
+		}
+		if item.EffectiveAt != nil {
+			rate.EffectiveAt = *item.EffectiveAt
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err := fxHandler.fxService.LoadRates(c, rates); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "FX rates loaded successfully!",
+		"data": gin.H{
+			"rates": rates,
+		},
+	})
+}
+
+func (fxHandler *FxHandler) ListRates(c *gin.Context) {
+	limit := 50 // default
+
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+
+	baseCurrency := strings.ToUpper(c.Query("base"))
+	quoteCurrency := strings.ToUpper(c.Query("quote"))
+
+	rates, err := fxHandler.fxService.ListRates(c, baseCurrency, quoteCurrency, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "FX rates retrieved successfully",
+		"data": gin.H{
+			"rates": rates,
+		},
+	})
+}
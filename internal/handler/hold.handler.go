@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldHandler struct {
+	accountService *service.AccountService
+	holdService    *service.HoldService
+	outboxService  *service.OutboxService
+}
+
+func NewHoldHandler(accountService *service.AccountService, holdService *service.HoldService, outboxService *service.OutboxService) *HoldHandler {
+	return &HoldHandler{
+		accountService: accountService,
+		holdService:    holdService,
+		outboxService:  outboxService,
+	}
+}
+
+func (holdHandler *HoldHandler) CreateHold(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	var req requestdto.CreateHold
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("amount must be greater than zero"))
+		return
+	}
+
+	expiresAt := time.Now().Add(service.DEFAULT_HOLD_DURATION)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, customError.NewValidationError("expires_at must be in the future"))
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	if _, err := holdHandler.accountService.GetAccount(c, &requestdto.GetAccount{AccountNumber: accountNumber}); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	hold, err := holdHandler.holdService.PlaceHold(c, accountNumber, req.Amount, expiresAt, req.Memo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Hold placed successfully!",
+		"data": gin.H{
+			"hold": hold,
+		},
+	})
+}
+
+func (holdHandler *HoldHandler) ListHolds(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	holds, err := holdHandler.holdService.ListHolds(c, accountNumber, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Holds retrieved successfully",
+		"data": gin.H{
+			"holds": holds,
+		},
+	})
+}
+
+func (holdHandler *HoldHandler) CaptureHold(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+	holdID := c.Param("hold_id")
+
+	// The body is optional, an empty one captures the full held amount
+	var req requestdto.CaptureHold
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	hold, err := holdHandler.holdService.GetHold(c, accountNumber, holdID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("hold", "not found"))
+		return
+	}
+
+	// The worker re-checks the hold under lock, this is an early check
+	if hold.Status != model.HoldActive || hold.IsExpired(time.Now()) {
+		c.JSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, service.ErrHoldNotActive.Error(), hold.Status))
+		return
+	}
+
+	amount := req.Amount
+	if amount.IsZero() {
+		amount = hold.Amount
+	}
+
+	if amount.IsNegative() || amount.GreaterThan(hold.Amount) {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(fmt.Sprintf("amount must be between 0 and %s", hold.Amount.String())))
+		return
+	}
+
+	account, err := holdHandler.accountService.GetAccount(c, &requestdto.GetAccount{AccountNumber: accountNumber})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	transactionID := fmt.Sprintf("TXN_%d", time.Now().UnixNano())
+
+	txLog := &model.TransactionLog{
+		TransactionId: transactionID,
+		FromAccountId: account.ID,
+		ToAccountId:   account.ID,
+		Amount:        amount,
+		Currency:      account.Currency,
+		Type:          model.TransactionTypeWithdrawal,
+		Status:        model.TransactionStatusInprogress,
+		Memo:          req.Memo,
+		Metadata:      map[string]any{"hold_id": hold.HoldId},
+		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:     time.Now(),
+	}
+
+	txMsg := &dto.TransactionMessage{
+		ID:            transactionID,
+		Type:          model.TransactionTypeWithdrawal,
+		AccountNumber: account.AccountNumber,
+		Amount:        amount,
+		Currency:      account.Currency,
+		Description:   req.Memo,
+		CreatedAt:     time.Now(),
+		HoldId:        hold.HoldId,
+	}
+
+	if err := holdHandler.outboxService.EnqueueTransaction(c, txLog, txMsg, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue capture",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Capture queued successfully!",
+		"data": gin.H{
+			"TransactionID": transactionID,
+			"HoldID":        hold.HoldId,
+			"Amount":        amount,
+			"Status":        "IN_PROGRESS",
+		},
+	})
+}
+
+func (holdHandler *HoldHandler) VoidHold(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+	holdID := c.Param("hold_id")
+
+	hold, err := holdHandler.holdService.VoidHold(c, accountNumber, holdID)
+	switch {
+	case errors.Is(err, service.ErrHoldNotFound):
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("hold", "not found"))
+		return
+	case errors.Is(err, service.ErrHoldNotActive):
+		c.JSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, err.Error(), holdID))
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Hold voided successfully!",
+		"data": gin.H{
+			"hold": hold,
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type InterestHandler struct {
+	accountService  *service.AccountService
+	interestService *service.InterestService
+}
+
+func NewInterestHandler(accountService *service.AccountService, interestService *service.InterestService) *InterestHandler {
+	return &InterestHandler{
+		accountService:  accountService,
+		interestService: interestService,
+	}
+}
+
+func (interestHandler *InterestHandler) GetAccountInterest(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	account, err := interestHandler.accountService.GetAccount(c, &requestdto.GetAccount{AccountNumber: accountNumber})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	limit := service.DEFAULT_INTEREST_ACCRUALS_LIMIT
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+
+	accruals, err := interestHandler.interestService.ListAccruals(c, account.AccountNumber, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	// Only savings accounts earn interest
+	rate := decimal.Zero
+	if account.AccountType == model.AccountTypeSaving {
+		rate = interestHandler.interestService.RateFor(account.Balance)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account interest",
+		"data": gin.H{
+			"account_number":   account.AccountNumber,
+			"annual_rate":      rate,
+			"accrued_interest": account.AccruedInterest,
+			"accrued_through":  account.InterestAccruedThrough,
+			"accruals":         accruals,
+		},
+	})
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	scheduleService *service.ScheduleService
+}
+
+func NewScheduleHandler(scheduleService *service.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService: scheduleService,
+	}
+}
+
+// respondScheduleError maps schedule service errors to responses
+func respondScheduleError(c *gin.Context, scheduleID string, err error) {
+	switch {
+	case errors.Is(err, service.ErrScheduleNotFound):
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("schedule", "not found"))
+	case errors.Is(err, service.ErrScheduleNotEditable):
+		c.JSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, err.Error(), scheduleID))
+	default:
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+	}
+}
+
+func (scheduleHandler *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req requestdto.CreateSchedule
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	schedule, err := scheduleHandler.scheduleService.CreateSchedule(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule created successfully!",
+		"data": gin.H{
+			"schedule": schedule,
+		},
+	})
+}
+
+func (scheduleHandler *ScheduleHandler) ListSchedules(c *gin.Context) {
+	schedules, err := scheduleHandler.scheduleService.ListSchedules(c, c.Query("account_number"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedules retrieved successfully",
+		"data": gin.H{
+			"schedules": schedules,
+		},
+	})
+}
+
+func (scheduleHandler *ScheduleHandler) GetSchedule(c *gin.Context) {
+	scheduleID := c.Param("schedule_id")
+
+	schedule, err := scheduleHandler.scheduleService.GetSchedule(c, scheduleID)
+	if err != nil {
+		respondScheduleError(c, scheduleID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule details",
+		"data": gin.H{
+			"schedule": schedule,
+		},
+	})
+}
+
+func (scheduleHandler *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	scheduleID := c.Param("schedule_id")
+
+	var req requestdto.UpdateSchedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	schedule, err := scheduleHandler.scheduleService.UpdateSchedule(c, scheduleID, &req)
+	if err != nil {
+		respondScheduleError(c, scheduleID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule updated successfully!",
+		"data": gin.H{
+			"schedule": schedule,
+		},
+	})
+}
+
+func (scheduleHandler *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	scheduleID := c.Param("schedule_id")
+
+	schedule, err := scheduleHandler.scheduleService.CancelSchedule(c, scheduleID)
+	if err != nil {
+		respondScheduleError(c, scheduleID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule cancelled successfully!",
+		"data": gin.H{
+			"schedule": schedule,
+		},
+	})
+}
+
+func (scheduleHandler *ScheduleHandler) ListRuns(c *gin.Context) {
+	scheduleID := c.Param("schedule_id")
+
+	limit := service.DEFAULT_SCHEDULE_RUNS_LIMIT
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+
+	runs, err := scheduleHandler.scheduleService.ListRuns(c, scheduleID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule runs retrieved successfully",
+		"data": gin.H{
+			"runs": runs,
+		},
+	})
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type StatementHandler struct {
+	accountService   *service.AccountService
+	statementService *service.StatementService
+}
+
+func NewStatementHandler(accountService *service.AccountService, statementService *service.StatementService) *StatementHandler {
+	return &StatementHandler{
+		accountService:   accountService,
+		statementService: statementService,
+	}
+}
+
+func (statementHandler *StatementHandler) accountExists(c *gin.Context, accountNumber string) bool {
+	_, err := statementHandler.accountService.GetAccount(c, &requestdto.GetAccount{AccountNumber: accountNumber})
+	return err == nil
+}
+
+func (statementHandler *StatementHandler) ListStatements(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	if !statementHandler.accountExists(c, accountNumber) {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	statements, err := statementHandler.statementService.ListStatements(c, accountNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Account statements",
+		"data":    statements,
+	})
+}
+
+func (statementHandler *StatementHandler) GenerateStatement(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+
+	var req requestdto.GenerateStatement
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	if _, err := service.ParseStatementPeriod(req.Period); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	if !statementHandler.accountExists(c, accountNumber) {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	statement, err := statementHandler.statementService.GenerateStatement(c, accountNumber, req.Period)
+	if errors.Is(err, service.ErrStatementPeriodOpen) {
+		c.JSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, err.Error(), req.Period))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Statement generated",
+		"data":    statement,
+	})
+}
+
+// GetStatement returns the statement as JSON, or as a CSV download with format=csv
+func (statementHandler *StatementHandler) GetStatement(c *gin.Context) {
+	accountNumber := c.Param("account_number")
+	period := c.Param("period")
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("format must be json or csv"))
+		return
+	}
+
+	statement, err := statementHandler.statementService.GetStatement(c, accountNumber, period)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("statement", "not found in system"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Statement",
+			"data":    statement,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.StatementId+".csv"))
+	c.Status(http.StatusOK)
+
+	if err := service.WriteStatementCSV(c.Writer, statement); err != nil {
+		c.Error(err)
+	}
+}
@@ -1,12 +1,22 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
 	requestdto "golang-exercise/internal/dto/request"
 	responsedto "golang-exercise/internal/dto/response"
 	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/export"
 	"golang-exercise/internal/service"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,12 +24,16 @@ import (
 type TransactionHandler struct {
 	accountService *service.AccountService
 	txLogService   *service.TransactionLogService
+	outboxService  *service.OutboxService
+	ledgerService  *service.LedgerService
 }
 
-func NewTransactionHandler(accountService *service.AccountService, txLogService *service.TransactionLogService) *TransactionHandler {
+func NewTransactionHandler(accountService *service.AccountService, txLogService *service.TransactionLogService, outboxService *service.OutboxService, ledgerService *service.LedgerService) *TransactionHandler {
 	return &TransactionHandler{
 		accountService: accountService,
 		txLogService:   txLogService,
+		outboxService:  outboxService,
+		ledgerService:  ledgerService,
 	}
 }
 
@@ -53,6 +67,12 @@ func (txHandler *TransactionHandler) GetTransactionHistory(c *gin.Context) {
 	endDate := c.Query("end_date")
 	status := c.Query("status")
 
+	// A format downloads the whole filtered history as a file instead of a page
+	if format := c.Query("format"); format != "" {
+		txHandler.exportTransactionHistory(c, account, format, startDate, endDate, status)
+		return
+	}
+
 	// Get transaction history from service
 	transactions, total, err := txHandler.txLogService.GetTransactionHistory(c, account.ID, limit, offset, startDate, endDate, status)
 	if err != nil {
@@ -96,6 +116,159 @@ func (txHandler *TransactionHandler) GetTransactionHistory(c *gin.Context) {
 	})
 }
 
+// exportTransactionHistory streams the history in the requested format. The
+// status is sent with the first entry, so a failure after that can only cut
+// the file short.
+func (txHandler *TransactionHandler) exportTransactionHistory(c *gin.Context, account *model.Account, format string, startDate, endDate, status string) {
+	writer, err := export.NewWriter(format, c.Writer)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	// The balances cover every transaction of the range, a filtered statement
+	// would not add up to them
+	if status != "" && writer.HasBalances() {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(fmt.Sprintf("status filter is only supported for %s exports", export.FORMAT_CSV)))
+		return
+	}
+
+	// The range the filters select, the same way the history reads them
+	now := time.Now().UTC()
+	statement := &export.Statement{
+		BankID:        config.GetConfig().App.Name,
+		AccountNumber: account.AccountNumber,
+		AccountType:   account.AccountType,
+		Currency:      account.Currency,
+		From:          account.CreatedAt.UTC(),
+		To:            now,
+		CreatedAt:     now,
+	}
+	if start, err := time.Parse("2006-01-02", startDate); err == nil {
+		statement.From = start
+	}
+	if end, err := time.Parse("2006-01-02", endDate); err == nil && end.Add(24*time.Hour).Before(now) {
+		statement.To = end.Add(24 * time.Hour)
+	}
+
+	if writer.HasBalances() {
+		statement.OpeningBalance, err = txHandler.ledgerService.GetBalanceAt(c, account.AccountNumber, statement.From)
+		if err == nil {
+			statement.ClosingBalance, err = txHandler.ledgerService.GetBalanceAt(c, account.AccountNumber, statement.To)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+			return
+		}
+	}
+
+	started := false
+	begin := func() error {
+		started = true
+
+		c.Header("Content-Type", writer.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s_history.%s", account.AccountNumber, writer.Extension())))
+		c.Status(http.StatusOK)
+
+		return writer.Begin(statement)
+	}
+
+	// Logs are written a batch at a time, reading the originals of the
+	// reversals in the batch with one query
+	batch := make([]model.TransactionLog, 0, service.HISTORY_EXPORT_BATCH_SIZE)
+	writeBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+
+		originals, err := txHandler.reversalOriginals(c, batch)
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			txLog := &batch[i]
+			entry, ok := export.NewEntry(txLog, account.ID, originals[txLog.OriginalTransactionId])
+			if !ok {
+				log.Printf("Skipped transaction %s in the history export of account %s: amount unknown", txLog.TransactionId, account.AccountNumber)
+				continue
+			}
+
+			if err := writer.Write(entry); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+
+		return nil
+	}
+
+	err = txHandler.txLogService.StreamTransactionHistory(c, account.ID, startDate, endDate, status, func(txLog *model.TransactionLog) error {
+		batch = append(batch, *txLog)
+		if len(batch) < service.HISTORY_EXPORT_BATCH_SIZE {
+			return nil
+		}
+
+		return writeBatch()
+	})
+	if err == nil {
+		err = writeBatch()
+	}
+	if err == nil && !started {
+		err = begin()
+	}
+	if err == nil {
+		err = writer.End(statement)
+	}
+
+	if err != nil && !started {
+		c.JSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+		return
+	}
+	if err != nil {
+		log.Printf("History export of account %s stopped: %v", account.AccountNumber, err)
+		c.Error(err)
+		return
+	}
+
+	c.Writer.Flush()
+}
+
+// reversalOriginals reads the logs the reversals among logs reverse, by id
+func (txHandler *TransactionHandler) reversalOriginals(ctx context.Context, logs []model.TransactionLog) (map[string]*model.TransactionLog, error) {
+	var originalIDs []string
+	for i := range logs {
+		if logs[i].Type == model.TransactionTypeReversal {
+			originalIDs = append(originalIDs, logs[i].OriginalTransactionId)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return nil, nil
+	}
+
+	originals, err := txHandler.txLogService.GetTransactionsByIDs(ctx, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.TransactionLog, len(originals))
+	for i := range originals {
+		byID[originals[i].TransactionId] = &originals[i]
+	}
+
+	return byID, nil
+}
+
 func (txHandler *TransactionHandler) GetTransactionStatus(c *gin.Context) {
 	transactionID := c.Param("transaction_id")
 
@@ -119,3 +292,100 @@ func (txHandler *TransactionHandler) GetTransactionStatus(c *gin.Context) {
 		},
 	})
 }
+
+func (txHandler *TransactionHandler) ReverseTransaction(c *gin.Context) {
+	transactionID := c.Param("transaction_id")
+
+	// The body is optional, an empty one reverses the full remaining amount
+	var req requestdto.ReverseTransaction
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	original, err := txHandler.txLogService.GetTransactionByID(c, transactionID)
+	if err != nil || original == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("transaction", "not found"))
+		return
+	}
+
+	if !original.IsReversible() {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("this transaction cannot be reversed"))
+		return
+	}
+
+	if original.Status != model.TransactionStatusCompleted {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("only completed transactions can be reversed"))
+		return
+	}
+
+	// The worker enforces the remaining amount under lock, this is an early check
+	remaining := original.Amount.Sub(original.ReversedAmount)
+	if !remaining.IsPositive() {
+		c.JSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, "transaction is already fully reversed", transactionID))
+		return
+	}
+
+	amount := req.Amount
+	if amount.IsZero() {
+		amount = remaining
+	}
+
+	if amount.IsNegative() || amount.GreaterThan(remaining) {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(fmt.Sprintf("amount must be between 0 and %s", remaining.String())))
+		return
+	}
+
+	account, err := txHandler.accountService.GetAccountByID(c, original.FromAccountId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("account", "not found in system"))
+		return
+	}
+
+	reversalID := fmt.Sprintf("TXN_%d", time.Now().UnixNano())
+
+	// Money flows back, so the sides of the original are swapped
+	txLog := &model.TransactionLog{
+		TransactionId:         reversalID,
+		FromAccountId:         original.ToAccountId,
+		ToAccountId:           original.FromAccountId,
+		Amount:                amount,
+		Currency:              original.Currency,
+		Type:                  model.TransactionTypeReversal,
+		Status:                model.TransactionStatusInprogress,
+		Memo:                  req.Reason,
+		OriginalTransactionId: original.TransactionId,
+		InitiatedBy:           1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:             time.Now(),
+	}
+
+	txMsg := &dto.TransactionMessage{
+		ID:                    reversalID,
+		Type:                  model.TransactionTypeReversal,
+		AccountNumber:         account.AccountNumber,
+		Amount:                amount,
+		Currency:              original.Currency,
+		Description:           req.Reason,
+		CreatedAt:             time.Now(),
+		OriginalTransactionId: original.TransactionId,
+	}
+
+	if err := txHandler.outboxService.EnqueueTransaction(c, txLog, txMsg, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue reversal",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reversal queued successfully!",
+		"data": gin.H{
+			"TransactionID":         reversalID,
+			"OriginalTransactionID": original.TransactionId,
+			"Amount":                amount,
+			"Status":                "IN_PROGRESS",
+		},
+	})
+}
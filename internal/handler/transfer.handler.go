@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	requestdto "golang-exercise/internal/dto/request"
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHandler struct {
+	accountService *service.AccountService
+	outboxService  *service.OutboxService
+	fxService      *service.FxService
+}
+
+func NewTransferHandler(accountService *service.AccountService, outboxService *service.OutboxService, fxService *service.FxService) *TransferHandler {
+	return &TransferHandler{
+		accountService: accountService,
+		outboxService:  outboxService,
+		fxService:      fxService,
+	}
+}
+
+func (transferHandler *TransferHandler) getAccount(c *gin.Context, accountNumber string) *model.Account {
+	account, err := transferHandler.accountService.GetAccount(c, &requestdto.GetAccount{AccountNumber: accountNumber})
+	if err != nil {
+		return nil
+	}
+
+	return account
+}
+
+func (transferHandler *TransferHandler) CreateTransfer(c *gin.Context) {
+	var req requestdto.CreateTransfer
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	if !req.Amount.IsPositive() {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("amount must be greater than zero"))
+		return
+	}
+
+	if req.FromAccountNumber == req.ToAccountNumber {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError("cannot transfer to the same account"))
+		return
+	}
+
+	fromAccount := transferHandler.getAccount(c, req.FromAccountNumber)
+	if fromAccount == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("source account", "not found in system"))
+		return
+	}
+
+	toAccount := transferHandler.getAccount(c, req.ToAccountNumber)
+	if toAccount == nil {
+		c.JSON(http.StatusBadRequest, customError.NewEntityNotFoundError("destination account", "not found in system"))
+		return
+	}
+
+	// The destination is credited in its own currency at the rate in effect now
+	conversion, err := transferHandler.fxService.Convert(c, req.Amount, fromAccount.Currency, toAccount.Currency, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, customError.NewValidationError(err.Error()))
+		return
+	}
+
+	transactionID := fmt.Sprintf("TXN_%d", time.Now().UnixNano())
+
+	// A single log entry carries both sides of the transfer
+	txLog := &model.TransactionLog{
+		TransactionId: transactionID,
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        req.Amount,
+		Currency:      fromAccount.Currency,
+		Type:          model.TransactionTypeTransfer,
+		Status:        model.TransactionStatusInprogress,
+		Memo:          req.Memo,
+		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:     time.Now(),
+	}
+	txLog.SetFxConversion(conversion)
+
+	txMsg := &dto.TransactionMessage{
+		ID:              transactionID,
+		Type:            model.TransactionTypeTransfer,
+		AccountNumber:   fromAccount.AccountNumber,
+		ToAccountNumber: toAccount.AccountNumber,
+		Amount:          req.Amount,
+		Currency:        fromAccount.Currency,
+		Description:     req.Memo,
+		Fx:              conversion,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := transferHandler.outboxService.EnqueueTransaction(c, txLog, txMsg, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue transaction",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Transfer queued successfully!",
+		"data": gin.H{
+			"TransactionID": transactionID,
+			"Status":        "IN_PROGRESS",
+		},
+	})
+}
@@ -0,0 +1,112 @@
+// Package interest holds the arithmetic of interest accrual: day-count
+// conventions, tiered rates and the rounding applied when interest is paid.
+package interest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Daily accruals keep this many decimal places so rounding only happens once,
+// when the interest is paid
+const ACCRUAL_PLACES = 10
+
+// Paid interest is rounded down to the minor unit (cents), the remainder stays
+// accrued and is paid with the next posting
+const PAYMENT_PLACES = 2
+
+// DayCount is the convention turning an annual rate into a daily one
+type DayCount string
+
+const (
+	// Every year has 365 days, leap years included
+	Actual365Fixed DayCount = "ACT/365"
+	// Every year has 360 days
+	Actual360 DayCount = "ACT/360"
+	// A year has 365 or 366 days depending on whether it is a leap year
+	ActualActual DayCount = "ACT/ACT"
+)
+
+func ParseDayCount(value string) (DayCount, error) {
+	switch DayCount(value) {
+	case "":
+		return Actual365Fixed, nil
+	case Actual365Fixed, Actual360, ActualActual:
+		return DayCount(value), nil
+	}
+
+	return "", fmt.Errorf("unknown day count convention %q", value)
+}
+
+// DaysInYear is the divisor of the annual rate for interest earned on day
+func (dayCount DayCount) DaysInYear(day time.Time) int64 {
+	switch dayCount {
+	case Actual360:
+		return 360
+	case ActualActual:
+		year := day.Year()
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 366
+		}
+		return 365
+	}
+
+	return 365
+}
+
+// Tier applies AnnualRate to balances of at least MinBalance
+type Tier struct {
+	MinBalance decimal.Decimal
+	AnnualRate decimal.Decimal
+}
+
+// RateFor returns the rate of the highest tier the balance reaches. The whole
+// balance earns that rate. Without a matching tier the rate is zero.
+func RateFor(tiers []Tier, balance decimal.Decimal) decimal.Decimal {
+	rate := decimal.Zero
+	var reached *Tier
+
+	for index := range tiers {
+		tier := &tiers[index]
+		if balance.GreaterThanOrEqual(tier.MinBalance) && (reached == nil || tier.MinBalance.GreaterThan(reached.MinBalance)) {
+			reached = tier
+			rate = tier.AnnualRate
+		}
+	}
+
+	return rate
+}
+
+// DailyAmount is the interest balance earns on day at annualRate. Balances at or
+// below zero earn nothing.
+func DailyAmount(balance decimal.Decimal, annualRate decimal.Decimal, day time.Time, dayCount DayCount) decimal.Decimal {
+	if !balance.IsPositive() || !annualRate.IsPositive() {
+		return decimal.Zero
+	}
+
+	return balance.Mul(annualRate).DivRound(decimal.NewFromInt(dayCount.DaysInYear(day)), ACCRUAL_PLACES)
+}
+
+// Payable splits accrued interest into the amount paid now and the remainder
+// that stays accrued
+func Payable(accrued decimal.Decimal) (decimal.Decimal, decimal.Decimal) {
+	if !accrued.IsPositive() {
+		return decimal.Zero, accrued
+	}
+
+	paid := accrued.Truncate(PAYMENT_PLACES)
+	return paid, accrued.Sub(paid)
+}
+
+// Date strips the time of day, interest is accrued per calendar day in UTC
+func Date(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsMonthEnd reports whether day is the last day of its month
+func IsMonthEnd(day time.Time) bool {
+	return day.AddDate(0, 0, 1).Day() == 1
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs job on every tick of interval until ctx is cancelled. A failing run
+// is logged and retried on the next tick.
+func Every(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Started %s job, running every %s", name, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Stopped %s job", name)
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("Job %s failed: %v", name, err)
+			}
+		}
+	}
+}
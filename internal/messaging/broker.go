@@ -0,0 +1,52 @@
+package messaging
+
+import (
+	dto "golang-exercise/internal/dto"
+)
+
+// Publisher hands transaction messages to the broker for the worker to process
+type Publisher interface {
+	PublishTransaction(txMsg *dto.TransactionMessage) error
+	IsConnected() bool
+}
+
+// Subscriber delivers the messages of a queue to a consumer
+type Subscriber interface {
+	// Subscribe passes the deliveries of queueName to handle, at most prefetch
+	// of them unsettled at a time. handle must not block. It is called again
+	// with a new channel whenever the subscription is renewed, and every channel
+	// is closed once its subscription ends.
+	Subscribe(queueName string, prefetch int, handle func(deliveries <-chan Delivery)) error
+
+	// Unsubscribe stops the deliveries. Deliveries already handed out can still be settled.
+	Unsubscribe() error
+}
+
+// Delivery is a received transaction message. It is settled exactly once,
+// with Ack or Nack, after it has been processed or moved on.
+type Delivery interface {
+	MessageID() string
+	Body() []byte
+
+	// Attempts is how often the message has already been retried
+	Attempts() int
+
+	Ack() error
+
+	// Nack gives the message back to the queue when requeue is set, and drops it otherwise
+	Nack(requeue bool) error
+
+	// Retry schedules a copy of the message for the given attempt after the
+	// retry policy's delay. The original still has to be settled.
+	Retry(attempt int, cause error) error
+
+	// DeadLetter parks a copy of the message for inspection. The original still has to be settled.
+	DeadLetter(attempts int, cause error) error
+}
+
+var (
+	_ Publisher  = (*TransactionPublisher)(nil)
+	_ Publisher  = (*LocalBroker)(nil)
+	_ Subscriber = (*RabbitMQ)(nil)
+	_ Subscriber = (*LocalBroker)(nil)
+)
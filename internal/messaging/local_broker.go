@@ -0,0 +1,285 @@
+package messaging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	dto "golang-exercise/internal/dto"
+)
+
+var (
+	ErrBrokerClosed      = errors.New("broker is closed")
+	ErrAlreadySettled    = errors.New("delivery was already acked or nacked")
+	ErrAlreadySubscribed = errors.New("queue already has a subscriber")
+)
+
+// LocalMessage is a message held by the in-process broker
+type LocalMessage struct {
+	ID        string
+	Body      []byte
+	Attempts  int
+	LastError string
+}
+
+// LocalBroker is an in-process broker with a single queue, so the API and the
+// worker can run in one binary without RabbitMQ. Like RabbitMQ it delivers at
+// most prefetch unsettled messages, requeues nacked ones, delays retries by the
+// retry policy and keeps dead-lettered messages for inspection. Nothing is
+// persisted: messages still queued when the process exits are lost, the outbox
+// only knows they were published.
+type LocalBroker struct {
+	retryPolicy RetryPolicy
+
+	mu       sync.Mutex
+	queue    []LocalMessage
+	unacked  int
+	prefetch int
+	dead     []LocalMessage
+	retries  map[*time.Timer]struct{}
+	cancel   chan struct{}
+	closed   bool
+
+	// Wakes the subscription when a message is queued or a delivery is settled
+	notify chan struct{}
+}
+
+func NewLocalBroker(retryPolicy RetryPolicy) *LocalBroker {
+	return &LocalBroker{
+		retryPolicy: retryPolicy,
+		retries:     map[*time.Timer]struct{}{},
+		notify:      make(chan struct{}, 1),
+	}
+}
+
+func (broker *LocalBroker) IsConnected() bool {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	return !broker.closed
+}
+
+func (broker *LocalBroker) PublishTransaction(txMsg *dto.TransactionMessage) error {
+	body, err := json.Marshal(txMsg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize the txMsg")
+	}
+
+	return broker.enqueue(LocalMessage{ID: txMsg.ID, Body: body})
+}
+
+func (broker *LocalBroker) enqueue(message LocalMessage) error {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.closed {
+		return ErrBrokerClosed
+	}
+
+	broker.queue = append(broker.queue, message)
+	broker.wake()
+
+	return nil
+}
+
+// wake signals the subscription without blocking. Callers hold mu.
+func (broker *LocalBroker) wake() {
+	select {
+	case broker.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe delivers the queue to handle. The queue name is only there to
+// match RabbitMQ, every message goes to the one subscriber.
+func (broker *LocalBroker) Subscribe(queueName string, prefetch int, handle func(deliveries <-chan Delivery)) error {
+	broker.mu.Lock()
+	if broker.closed {
+		broker.mu.Unlock()
+		return ErrBrokerClosed
+	}
+
+	if broker.cancel != nil {
+		broker.mu.Unlock()
+		return ErrAlreadySubscribed
+	}
+
+	cancel := make(chan struct{})
+	broker.cancel = cancel
+	broker.prefetch = prefetch
+	broker.mu.Unlock()
+
+	deliveries := make(chan Delivery)
+	go func() {
+		defer close(deliveries)
+		for {
+			message, ok := broker.next(cancel)
+			if !ok {
+				return
+			}
+
+			deliveries <- &localDelivery{broker: broker, message: message}
+		}
+	}()
+
+	handle(deliveries)
+
+	return nil
+}
+
+// next waits for a queued message and a free prefetch slot, or for cancel
+func (broker *LocalBroker) next(cancel chan struct{}) (LocalMessage, bool) {
+	for {
+		broker.mu.Lock()
+		if len(broker.queue) > 0 && (broker.prefetch <= 0 || broker.unacked < broker.prefetch) {
+			message := broker.queue[0]
+			broker.queue = broker.queue[1:]
+			broker.unacked++
+			broker.mu.Unlock()
+
+			return message, true
+		}
+		broker.mu.Unlock()
+
+		select {
+		case <-broker.notify:
+		case <-cancel:
+			return LocalMessage{}, false
+		}
+	}
+}
+
+// Unsubscribe stops the deliveries. Messages still queued wait for the next subscriber.
+func (broker *LocalBroker) Unsubscribe() error {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.cancel != nil {
+		close(broker.cancel)
+		broker.cancel = nil
+	}
+
+	return nil
+}
+
+// Close stops the deliveries and drops the retries still waiting for their delay
+func (broker *LocalBroker) Close() error {
+	broker.Unsubscribe()
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	broker.closed = true
+	for timer := range broker.retries {
+		timer.Stop()
+	}
+	broker.retries = map[*time.Timer]struct{}{}
+
+	return nil
+}
+
+// Pending is the number of messages queued or delivered and not yet settled
+func (broker *LocalBroker) Pending() int {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	return len(broker.queue) + broker.unacked + len(broker.retries)
+}
+
+// DeadLettered returns the messages given up on, oldest first
+func (broker *LocalBroker) DeadLettered() []LocalMessage {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	return append([]LocalMessage{}, broker.dead...)
+}
+
+type localDelivery struct {
+	broker  *LocalBroker
+	message LocalMessage
+	settled bool
+}
+
+func (d *localDelivery) MessageID() string {
+	return d.message.ID
+}
+
+func (d *localDelivery) Body() []byte {
+	return d.message.Body
+}
+
+func (d *localDelivery) Attempts() int {
+	return d.message.Attempts
+}
+
+func (d *localDelivery) Ack() error {
+	return d.settle(false)
+}
+
+func (d *localDelivery) Nack(requeue bool) error {
+	return d.settle(requeue)
+}
+
+func (d *localDelivery) settle(requeue bool) error {
+	broker := d.broker
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if d.settled {
+		return ErrAlreadySettled
+	}
+
+	d.settled = true
+	broker.unacked--
+	if requeue && !broker.closed {
+		broker.queue = append(broker.queue, d.message)
+	}
+	broker.wake()
+
+	return nil
+}
+
+// Retry queues the copy again once the attempt's delay has passed
+func (d *localDelivery) Retry(attempt int, cause error) error {
+	broker := d.broker
+	retry := LocalMessage{ID: d.message.ID, Body: d.message.Body, Attempts: attempt, LastError: cause.Error()}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.closed {
+		return ErrBrokerClosed
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(broker.retryPolicy.Delay(attempt), func() {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+
+		// Dropped by Close
+		if _, scheduled := broker.retries[timer]; !scheduled {
+			return
+		}
+
+		delete(broker.retries, timer)
+		broker.queue = append(broker.queue, retry)
+		broker.wake()
+	})
+	broker.retries[timer] = struct{}{}
+
+	return nil
+}
+
+func (d *localDelivery) DeadLetter(attempts int, cause error) error {
+	broker := d.broker
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	broker.dead = append(broker.dead, LocalMessage{ID: d.message.ID, Body: d.message.Body, Attempts: attempts, LastError: cause.Error()})
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package messaging
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"golang-exercise/internal/service"
+)
+
+type OutboxRelay struct {
+	outboxService *service.OutboxService
+	publisher     Publisher
+	interval      time.Duration
+	batchSize     int
+}
+
+const (
+	DEFAULT_OUTBOX_POLL_INTERVAL = 500 * time.Millisecond
+	DEFAULT_OUTBOX_BATCH_SIZE    = 100
+)
+
+func NewOutboxRelay(outboxService *service.OutboxService, publisher Publisher, interval time.Duration, batchSize int) *OutboxRelay {
+	if interval <= 0 {
+		interval = DEFAULT_OUTBOX_POLL_INTERVAL
+	}
+
+	if batchSize <= 0 {
+		batchSize = DEFAULT_OUTBOX_BATCH_SIZE
+	}
+
+	return &OutboxRelay{
+		outboxService: outboxService,
+		publisher:     publisher,
+		interval:      interval,
+		batchSize:     batchSize,
+	}
+}
+
+// Run publishes pending outbox messages until ctx is cancelled
+func (relay *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	log.Println("Started outbox relay...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopped outbox relay")
+			return
+		case <-ticker.C:
+			relay.drain(ctx)
+		}
+	}
+}
+
+// drain keeps publishing while full batches come back so a backlog clears quickly
+func (relay *OutboxRelay) drain(ctx context.Context) {
+	// Publishing now would only count failed attempts against the messages
+	if !relay.publisher.IsConnected() {
+		return
+	}
+
+	for ctx.Err() == nil {
+		sent, err := relay.outboxService.ProcessPending(ctx, relay.batchSize, relay.publisher.PublishTransaction)
+		if err != nil {
+			log.Printf("Outbox relay failed: %v", err)
+			return
+		}
+
+		if sent < relay.batchSize {
+			return
+		}
+	}
+}
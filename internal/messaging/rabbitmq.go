@@ -1,17 +1,54 @@
 package messaging
 
 import (
+	"errors"
 	"fmt"
 	"golang-exercise/config"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/streadway/amqp"
 )
 
+const (
+	RECONNECT_MIN_DELAY     = time.Second
+	RECONNECT_MAX_DELAY     = 30 * time.Second
+	DEFAULT_PUBLISH_TIMEOUT = 5 * time.Second
+)
+
+var (
+	// ErrNotConnected is returned while the broker connection is down. Publishes
+	// fail fast instead of blocking, callers retry them later.
+	ErrNotConnected = errors.New("not connected to RabbitMQ")
+
+	ErrPublishNacked    = errors.New("broker refused the message")
+	ErrPublishReturned  = errors.New("broker could not route the message to a queue")
+	ErrPublishTimeout   = errors.New("timed out waiting for the broker to confirm the message")
+	ErrMissingMessageID = errors.New("message has no id to match its return")
+)
+
+// RabbitMQ keeps a connection and channel to the broker. When either closes
+// unexpectedly it reconnects in the background, declares the registered
+// topology again and restarts the registered consumers.
 type RabbitMQ struct {
+	mu         sync.RWMutex
 	connection *amqp.Connection
 	channel    *amqp.Channel
 	connected  bool
+	closing    bool
+
+	// Publishes go through their own channel in confirm mode
+	publishMu sync.Mutex
+	confirmer *Confirmer
+
+	// Re-run on every connection, in registration order
+	topology  []func(channel *amqp.Channel) error
+	consumers []func() error
+
+	// The queue subscription, renewed on every connection
+	subscribeMu  sync.Mutex
+	subscription *subscription
 }
 
 func NewRabbitMQ() *RabbitMQ {
@@ -27,12 +64,21 @@ func (r *RabbitMQ) createConnectionString(rabbitMqConfig config.RabbitMQ) string
 		rabbitMqConfig.Port,
 	)
 
-	log.Println(connectionString, "SSS", rabbitMqConfig.UserName)
-
 	return connectionString
 }
 
+// Connect dials the broker. When the first attempt fails the error is returned
+// and connecting continues in the background.
 func (r *RabbitMQ) Connect() error {
+	if err := r.connect(); err != nil {
+		go r.reconnect()
+		return err
+	}
+
+	return nil
+}
+
+func (r *RabbitMQ) connect() error {
 	dns := r.createConnectionString(config.GetConfig().RabbitMQ)
 
 	// Connect to RabbitMQ
@@ -41,45 +87,245 @@ func (r *RabbitMQ) Connect() error {
 		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
-	r.connection = connection
+	channel, err := connection.Channel()
+	if err != nil {
+		connection.Close()
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	r.mu.Lock()
+	topology := append([]func(channel *amqp.Channel) error{}, r.topology...)
+	r.mu.Unlock()
 
-	r.channel, err = r.connection.Channel()
+	for _, declare := range topology {
+		if err := declare(channel); err != nil {
+			connection.Close()
+			return err
+		}
+	}
+
+	publishChannel, err := connection.Channel()
 	if err != nil {
-		return fmt.Errorf("faile to opn up channel: %w", err)
+		connection.Close()
+		return fmt.Errorf("failed to open a publishing channel: %w", err)
 	}
 
+	if err := publishChannel.Confirm(false); err != nil {
+		connection.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	confirms := publishChannel.NotifyPublish(make(chan amqp.Confirmation, PUBLISH_CONFIRM_BUFFER))
+	returns := publishChannel.NotifyReturn(make(chan amqp.Return, PUBLISH_CONFIRM_BUFFER))
+
+	r.publishMu.Lock()
+	r.confirmer = NewConfirmer(publishChannel, confirms, returns)
+	r.publishMu.Unlock()
+
+	r.mu.Lock()
+	r.connection = connection
+	r.channel = channel
 	r.connected = true
+	r.mu.Unlock()
+
+	go r.watch(
+		connection.NotifyClose(make(chan *amqp.Error, 1)),
+		channel.NotifyClose(make(chan *amqp.Error, 1)),
+		publishChannel.NotifyClose(make(chan *amqp.Error, 1)),
+	)
+
 	log.Println("Connected to RabbitMQ successfully!")
 
 	return nil
 }
 
+// watch waits for the connection or channel to close and reconnects unless
+// the close was requested
+func (r *RabbitMQ) watch(connectionClosed chan *amqp.Error, channelClosed chan *amqp.Error, publishChannelClosed chan *amqp.Error) {
+	var reason *amqp.Error
+	select {
+	case reason = <-connectionClosed:
+	case reason = <-channelClosed:
+	case reason = <-publishChannelClosed:
+	}
+
+	r.mu.Lock()
+	r.connected = false
+	connection := r.connection
+	closing := r.closing
+	r.mu.Unlock()
+
+	if closing {
+		return
+	}
+
+	log.Printf("Lost connection to RabbitMQ: %v", reason)
+
+	// A closed channel leaves the connection open, start over on a fresh one
+	connection.Close()
+
+	r.reconnect()
+}
+
+// reconnect retries with a growing delay until connected, then restarts the consumers
+func (r *RabbitMQ) reconnect() {
+	delay := RECONNECT_MIN_DELAY
+
+	for {
+		time.Sleep(delay)
+
+		r.mu.RLock()
+		closing := r.closing
+		r.mu.RUnlock()
+
+		if closing {
+			return
+		}
+
+		if err := r.connect(); err != nil {
+			log.Printf("Reconnecting to RabbitMQ failed, retrying in %s: %v", delay, err)
+			delay = min(delay*2, RECONNECT_MAX_DELAY)
+			continue
+		}
+
+		break
+	}
+
+	r.mu.RLock()
+	consumers := append([]func() error{}, r.consumers...)
+	r.mu.RUnlock()
+
+	for _, consume := range consumers {
+		if err := consume(); err != nil {
+			log.Printf("Failed to resume consumer: %v", err)
+		}
+	}
+}
+
 func (r *RabbitMQ) Close() error {
-	if r.channel != nil {
-		r.channel.Close()
+	r.mu.Lock()
+	r.closing = true
+	r.connected = false
+	channel, connection := r.channel, r.connection
+	r.mu.Unlock()
+
+	if channel != nil {
+		channel.Close()
 	}
 
-	if r.connection != nil {
-		return r.connection.Close()
+	r.publishMu.Lock()
+	if r.confirmer != nil {
+		r.confirmer.channel.Close()
+	}
+	r.publishMu.Unlock()
+
+	if connection != nil {
+		return connection.Close()
 	}
 
 	return nil
 }
 
 func (r *RabbitMQ) IsConnected() bool {
-	return r.connected
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.connected && r.connection != nil && !r.connection.IsClosed()
+}
+
+// Channel returns the current channel, or ErrNotConnected while reconnecting
+func (r *RabbitMQ) Channel() (*amqp.Channel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.connected {
+		return nil, ErrNotConnected
+	}
+
+	return r.channel, nil
+}
+
+// Publish sends the message as mandatory and waits until the broker confirms
+// it. It fails when the broker nacks the message, returns it as unroutable or
+// does not answer within timeout. Publishes do not wait for each other, and
+// returns are told apart by MessageId, so every message needs one.
+func (r *RabbitMQ) Publish(exchange string, routingKey string, message amqp.Publishing, timeout time.Duration) error {
+	if !r.IsConnected() {
+		return ErrNotConnected
+	}
+
+	if message.MessageId == "" {
+		return ErrMissingMessageID
+	}
+
+	if timeout <= 0 {
+		timeout = DEFAULT_PUBLISH_TIMEOUT
+	}
+
+	r.publishMu.Lock()
+	confirmer := r.confirmer
+	r.publishMu.Unlock()
+
+	result, err := confirmer.Publish(exchange, routingKey, message)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return ErrPublishTimeout
+	}
+}
+
+func publishTimeout() time.Duration {
+	return time.Duration(config.GetConfig().RabbitMQ.PublishTimeoutMs) * time.Millisecond
+}
+
+// Declare registers topology to declare on every connection and declares it
+// right away when connected
+func (r *RabbitMQ) Declare(declare func(channel *amqp.Channel) error) error {
+	r.mu.Lock()
+	r.topology = append(r.topology, declare)
+	r.mu.Unlock()
+
+	channel, err := r.Channel()
+	if err != nil {
+		return nil
+	}
+
+	return declare(channel)
+}
+
+// OnReconnect registers consume to be called again after every reconnection.
+// It is not called for the current connection.
+func (r *RabbitMQ) OnReconnect(consume func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.consumers = append(r.consumers, consume)
 }
 
 func (r *RabbitMQ) DeclareQueue(queueName string) error {
+	return r.Declare(func(channel *amqp.Channel) error {
+		return declareQueue(channel, queueName, nil)
+	})
+}
+
+func declareQueue(channel *amqp.Channel, queueName string, args amqp.Table) error {
 	// Setting values for following options
 	// durable, autoDelete, exclusive, noWait bool, args
-	_, err := r.channel.QueueDeclare(
+	_, err := channel.QueueDeclare(
 		queueName,
 		true,
 		false,
 		false,
 		false,
-		nil,
+		args,
 	)
 
 	if err != nil {
@@ -88,3 +334,17 @@ func (r *RabbitMQ) DeclareQueue(queueName string) error {
 
 	return nil
 }
+
+// SetPrefetch limits how many unacknowledged messages the broker delivers to the channel's consumers
+func (r *RabbitMQ) SetPrefetch(count int) error {
+	channel, err := r.Channel()
+	if err != nil {
+		return err
+	}
+
+	if err := channel.Qos(count, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package messaging
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// Confirmations and returns buffered before the confirmer reads them
+const PUBLISH_CONFIRM_BUFFER = 64
+
+// ConfirmChannel is the part of a channel in confirm mode the confirmer
+// publishes on. *amqp.Channel implements it.
+type ConfirmChannel interface {
+	Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// Confirmer publishes on one channel in confirm mode and hands every
+// confirmation to the message it belongs to, so any number of publishes can
+// wait for the broker at once. Confirmations are matched by delivery tag and
+// returns by message id.
+type Confirmer struct {
+	mu        sync.Mutex
+	channel   ConfirmChannel
+	published uint64
+	pending   map[uint64]*pendingConfirm
+	returned  map[string]string
+	closed    bool
+}
+
+// pendingConfirm is a published message waiting for the broker. The result is
+// buffered, so a message whose publish timed out can still be settled.
+type pendingConfirm struct {
+	messageID string
+	result    chan error
+}
+
+// NewConfirmer settles publishes on channel from the confirmations and returns
+// the broker sends for it. Closing confirms fails the publishes still waiting.
+func NewConfirmer(channel ConfirmChannel, confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) *Confirmer {
+	c := &Confirmer{
+		channel:  channel,
+		pending:  map[uint64]*pendingConfirm{},
+		returned: map[string]string{},
+	}
+
+	go c.run(confirms, returns)
+
+	return c
+}
+
+// Publish sends the message and returns where its outcome will arrive. The
+// delivery tag is the number of the publish on the channel, so taking it and
+// publishing happen together.
+func (c *Confirmer) Publish(exchange string, routingKey string, message amqp.Publishing) (<-chan error, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, ErrNotConnected
+	}
+
+	// mandatory: return the message instead of dropping it when no queue is bound
+	if err := c.channel.Publish(exchange, routingKey, true, false, message); err != nil {
+		return nil, err
+	}
+
+	c.published++
+	pending := &pendingConfirm{messageID: message.MessageId, result: make(chan error, 1)}
+	c.pending[c.published] = pending
+
+	return pending.result, nil
+}
+
+func (c *Confirmer) run(confirms <-chan amqp.Confirmation, returns <-chan amqp.Return) {
+	for {
+		select {
+		case returned, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			c.addReturn(returned)
+		case confirmation, ok := <-confirms:
+			if !ok {
+				c.close()
+				return
+			}
+
+			// The broker sends the return of a message before its ack, but
+			// both may be waiting here at once
+			c.drainReturns(returns)
+			c.confirm(confirmation)
+		}
+	}
+}
+
+func (c *Confirmer) addReturn(returned amqp.Return) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.returned[returned.MessageId] = returned.ReplyText
+}
+
+func (c *Confirmer) drainReturns(returns <-chan amqp.Return) {
+	for returns != nil {
+		select {
+		case returned, ok := <-returns:
+			if !ok {
+				return
+			}
+			c.addReturn(returned)
+		default:
+			return
+		}
+	}
+}
+
+func (c *Confirmer) confirm(confirmation amqp.Confirmation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[confirmation.DeliveryTag]
+	if !ok {
+		return
+	}
+	delete(c.pending, confirmation.DeliveryTag)
+
+	replyText, returned := c.returned[pending.messageID]
+	delete(c.returned, pending.messageID)
+
+	switch {
+	case returned:
+		pending.result <- fmt.Errorf("%w: %s", ErrPublishReturned, replyText)
+	case !confirmation.Ack:
+		pending.result <- ErrPublishNacked
+	default:
+		pending.result <- nil
+	}
+}
+
+// close fails the messages still waiting once the channel is gone
+func (c *Confirmer) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for deliveryTag, pending := range c.pending {
+		pending.result <- ErrNotConnected
+		delete(c.pending, deliveryTag)
+	}
+}
@@ -0,0 +1,175 @@
+package messaging
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+type subscription struct {
+	queueName string
+	prefetch  int
+	handle    func(deliveries <-chan Delivery)
+	channel   *amqp.Channel
+	cancelled bool
+}
+
+// Subscribe consumes queueName on the current channel and again after every
+// reconnection. While disconnected it subscribes once the connection is back.
+func (r *RabbitMQ) Subscribe(queueName string, prefetch int, handle func(deliveries <-chan Delivery)) error {
+	r.subscribeMu.Lock()
+	r.subscription = &subscription{
+		queueName: queueName,
+		prefetch:  prefetch,
+		handle:    handle,
+	}
+	r.subscribeMu.Unlock()
+
+	r.OnReconnect(r.consume)
+
+	if err := r.consume(); err != nil && !errors.Is(err, ErrNotConnected) {
+		return err
+	}
+
+	return nil
+}
+
+// consume subscribes to the queue on the current channel
+func (r *RabbitMQ) consume() error {
+	r.subscribeMu.Lock()
+	defer r.subscribeMu.Unlock()
+
+	sub := r.subscription
+	if sub == nil || sub.cancelled {
+		return nil
+	}
+
+	if err := r.SetPrefetch(sub.prefetch); err != nil {
+		return err
+	}
+
+	channel, err := r.Channel()
+	if err != nil {
+		return err
+	}
+
+	// A reconnection racing with Subscribe may get here twice for one channel
+	if sub.channel == channel {
+		return nil
+	}
+
+	// Start consuming messages from the queue
+	messages, err := channel.Consume(
+		sub.queueName, // queue name
+		CONSUMER_TAG,  // consumer tag
+		false,         // auto-ack (we'll manually acknowledge)
+		false,         // exclusive
+		false,         // no-local
+		false,         // no-wait
+		nil,           // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register consumer: %w", err)
+	}
+
+	sub.channel = channel
+
+	// Closed with messages, when the subscription is cancelled or the channel is lost
+	deliveries := make(chan Delivery)
+	go func() {
+		defer close(deliveries)
+		for message := range messages {
+			deliveries <- &amqpDelivery{rabbitmq: r, queueName: sub.queueName, delivery: message}
+		}
+	}()
+
+	sub.handle(deliveries)
+
+	return nil
+}
+
+// Unsubscribe cancels the consumer so the broker stops delivering. Prefetched
+// messages nobody settles are redelivered by the broker.
+func (r *RabbitMQ) Unsubscribe() error {
+	r.subscribeMu.Lock()
+	sub := r.subscription
+	if sub == nil {
+		r.subscribeMu.Unlock()
+		return nil
+	}
+	sub.cancelled = true
+	channel := sub.channel
+	r.subscribeMu.Unlock()
+
+	// A channel lost to a disconnect already ended its deliveries
+	if channel == nil {
+		return nil
+	}
+
+	if err := channel.Cancel(CONSUMER_TAG, false); err != nil {
+		return fmt.Errorf("failed to cancel consumer: %w", err)
+	}
+
+	return nil
+}
+
+type amqpDelivery struct {
+	rabbitmq  *RabbitMQ
+	queueName string
+	delivery  amqp.Delivery
+}
+
+func (d *amqpDelivery) MessageID() string {
+	return d.delivery.MessageId
+}
+
+func (d *amqpDelivery) Body() []byte {
+	return d.delivery.Body
+}
+
+func (d *amqpDelivery) Attempts() int {
+	return retryCount(d.delivery.Headers)
+}
+
+func (d *amqpDelivery) Ack() error {
+	return d.delivery.Ack(false)
+}
+
+func (d *amqpDelivery) Nack(requeue bool) error {
+	return d.delivery.Nack(false, requeue)
+}
+
+// Retry routes the copy through the attempt's delay queue, which expires it back into the queue
+func (d *amqpDelivery) Retry(attempt int, cause error) error {
+	return d.republish(RetryQueueName(d.queueName, attempt), EXCHANGE_NAME, attempt, cause)
+}
+
+func (d *amqpDelivery) DeadLetter(attempts int, cause error) error {
+	return d.republish(d.queueName, DeadLetterExchangeName(d.queueName), attempts, cause)
+}
+
+// republish copies the delivery to another queue with its retry count and last error
+func (d *amqpDelivery) republish(routingKey string, exchange string, attempts int, cause error) error {
+	headers := amqp.Table{}
+	for key, value := range d.delivery.Headers {
+		headers[key] = value
+	}
+	headers[RETRY_COUNT_HEADER] = int32(attempts)
+	headers[LAST_ERROR_HEADER] = cause.Error()
+
+	// Confirmed before the original is acked, so the message is never lost in between
+	return d.rabbitmq.Publish(
+		exchange,
+		routingKey,
+		amqp.Publishing{
+			ContentType:  d.delivery.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    d.delivery.MessageId,
+			Timestamp:    d.delivery.Timestamp,
+			Headers:      headers,
+			Body:         d.delivery.Body,
+		},
+		publishTimeout(),
+	)
+}
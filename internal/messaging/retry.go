@@ -0,0 +1,110 @@
+package messaging
+
+import (
+	"fmt"
+	"golang-exercise/config"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	RETRY_COUNT_HEADER = "x-retry-count"
+	LAST_ERROR_HEADER  = "x-last-error"
+
+	DEFAULT_MAX_RETRIES      = 5
+	DEFAULT_RETRY_BASE_DELAY = time.Second
+)
+
+// RetryPolicy bounds how often a failed message is retried and how long it
+// waits in between. The delay doubles on every attempt.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+}
+
+func NewRetryPolicy(rabbitMqConfig config.RabbitMQ) RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries: rabbitMqConfig.MaxRetries,
+		BaseDelay:  time.Duration(rabbitMqConfig.RetryBaseDelayMs) * time.Millisecond,
+	}
+
+	if policy.MaxRetries <= 0 {
+		policy.MaxRetries = DEFAULT_MAX_RETRIES
+	}
+
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DEFAULT_RETRY_BASE_DELAY
+	}
+
+	return policy
+}
+
+// Delay is how long the message waits before the given attempt, counting from 1
+func (policy RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	return policy.BaseDelay << (attempt - 1)
+}
+
+// RetryQueueName is the delay queue holding messages waiting for the given attempt
+func RetryQueueName(queueName string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, attempt)
+}
+
+func DeadLetterExchangeName(queueName string) string {
+	return queueName + ".dlx"
+}
+
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// retryCount reads how often the delivery has already been retried
+func retryCount(headers amqp.Table) int {
+	switch count := headers[RETRY_COUNT_HEADER].(type) {
+	case int:
+		return count
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	default:
+		return 0
+	}
+}
+
+// DeclareRetryTopology declares one delay queue per attempt and the dead-letter
+// exchange and queue of queueName. A delay queue has no consumer: its messages
+// expire after the attempt's delay and are dead-lettered back to queueName.
+func (r *RabbitMQ) DeclareRetryTopology(queueName string, policy RetryPolicy) error {
+	return r.Declare(func(channel *amqp.Channel) error {
+		for attempt := 1; attempt <= policy.MaxRetries; attempt++ {
+			err := declareQueue(channel, RetryQueueName(queueName, attempt), amqp.Table{
+				"x-message-ttl":             policy.Delay(attempt).Milliseconds(),
+				"x-dead-letter-exchange":    EXCHANGE_NAME,
+				"x-dead-letter-routing-key": queueName,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to declare retry queue: %w", err)
+			}
+		}
+
+		// name, kind, durable, autoDelete, internal, noWait, args
+		if err := channel.ExchangeDeclare(DeadLetterExchangeName(queueName), amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+		}
+
+		if err := declareQueue(channel, DeadLetterQueueName(queueName), nil); err != nil {
+			return err
+		}
+
+		if err := channel.QueueBind(DeadLetterQueueName(queueName), queueName, DeadLetterExchangeName(queueName), false, nil); err != nil {
+			return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+		}
+
+		return nil
+	})
+}
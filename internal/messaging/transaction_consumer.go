@@ -5,85 +5,280 @@ import (
 	"encoding/json"
 	"fmt"
 	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
 	dto "golang-exercise/internal/dto"
 	"golang-exercise/internal/service"
+	"hash/fnv"
 	"log"
+	"sync"
+	"time"
+)
 
-	"github.com/streadway/amqp"
+const (
+	DEFAULT_CONSUMER_CONCURRENCY = 1
+	DEFAULT_PREFETCH_PER_WORKER  = 4
+	CONSUMER_TAG                 = "transaction-processor"
 )
 
 type TransactionConsumer struct {
-	rabbitmq       *RabbitMQ
+	subscriber     Subscriber
 	txService      *service.TransactionService
 	accountService *service.AccountService
+	txLogService   *service.TransactionLogService
+	retryPolicy    RetryPolicy
+	partitions     []chan Delivery
+
+	// ctx is passed to the processing, cancelling it aborts the transactions in flight
+	ctx context.Context
+
+	dispatching sync.WaitGroup
+	workers     sync.WaitGroup
 }
 
 func NewTransactionConsumer(
-	rabbitmq *RabbitMQ,
+	subscriber Subscriber,
 	accountService *service.AccountService,
 	txService *service.TransactionService,
+	txLogService *service.TransactionLogService,
+	retryPolicy RetryPolicy,
 ) *TransactionConsumer {
 
 	return &TransactionConsumer{
-		rabbitmq:       rabbitmq,
+		subscriber:     subscriber,
 		txService:      txService,
 		accountService: accountService,
+		txLogService:   txLogService,
+		retryPolicy:    retryPolicy,
 	}
 }
 
-func (trxnConsumer *TransactionConsumer) StartConsuming() error {
-	// Start consuming messages from the queue
-	messages, err := trxnConsumer.rabbitmq.channel.Consume(
-		config.GetConfig().RabbitMQ.Queue, // queue name
-		"transaction-processor",           // consumer tag
-		false,                             // auto-ack (we'll manually acknowledge)
-		false,                             // exclusive
-		false,                             // no-local
-		false,                             // no-wait
-		nil,                               // args
-	)
-	if err != nil {
-		return fmt.Errorf("failed to register consumer: %w", err)
+// StartConsuming processes the deliveries with ctx until Stop. Cancelling ctx
+// aborts the transactions in flight, their messages are requeued.
+func (trxnConsumer *TransactionConsumer) StartConsuming(ctx context.Context) error {
+	trxnConsumer.ctx = ctx
+	rabbitMqConfig := config.GetConfig().RabbitMQ
+
+	concurrency := rabbitMqConfig.Concurrency
+	if concurrency <= 0 {
+		concurrency = DEFAULT_CONSUMER_CONCURRENCY
+	}
+
+	// Enough prefetched messages to keep every worker busy
+	prefetch := rabbitMqConfig.Prefetch
+	if prefetch <= 0 {
+		prefetch = concurrency * DEFAULT_PREFETCH_PER_WORKER
+	}
+
+	// Every worker owns a partition of the accounts, so messages of one account
+	// are still processed one at a time and in delivery order. Each partition
+	// buffers its share of the prefetch, so a busy worker does not hold up the
+	// messages of the others until its buffer is full
+	buffer := prefetch / concurrency
+	if buffer < 1 {
+		buffer = 1
 	}
 
-	log.Println("Started consuming transaction messages...")
+	trxnConsumer.partitions = make([]chan Delivery, concurrency)
+	for i := range trxnConsumer.partitions {
+		trxnConsumer.partitions[i] = make(chan Delivery, buffer)
+		trxnConsumer.workers.Add(1)
+		go func(partition chan Delivery) {
+			defer trxnConsumer.workers.Done()
+			trxnConsumer.processMessages(partition)
+		}(trxnConsumer.partitions[i])
+	}
+
+	// Workers survive a renewed subscription, every new channel is dispatched to them
+	err := trxnConsumer.subscriber.Subscribe(rabbitMqConfig.Queue, prefetch, func(deliveries <-chan Delivery) {
+		trxnConsumer.dispatching.Add(1)
+		go func() {
+			defer trxnConsumer.dispatching.Done()
+			trxnConsumer.dispatch(deliveries)
+		}()
+	})
+	if err != nil {
+		return err
+	}
 
-	// Process messages in a goroutine
-	go trxnConsumer.processMessages(messages)
+	log.Printf("Started consuming transaction messages with %d workers and prefetch %d", concurrency, prefetch)
 
 	return nil
 }
 
-func (trxnConsumer *TransactionConsumer) processMessages(messages <-chan amqp.Delivery) {
-	for delivery := range messages {
-		log.Printf("Received transaction message: %s", delivery.MessageId)
+// Stop cancels the subscription so the broker stops delivering, then waits
+// until the workers have finished and acked the messages they already got,
+// including those waiting in their partition. Prefetched messages that were
+// not dispatched yet are redelivered by the broker.
+func (trxnConsumer *TransactionConsumer) Stop(ctx context.Context) error {
+	if err := trxnConsumer.subscriber.Unsubscribe(); err != nil {
+		log.Printf("Failed to cancel consumer: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		trxnConsumer.dispatching.Wait()
+		for _, partition := range trxnConsumer.partitions {
+			close(partition)
+		}
+		trxnConsumer.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("Transaction consumer stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("in-flight messages did not finish: %w", ctx.Err())
+	}
+}
+
+// dispatch hands every delivery to the worker owning its account until the channel closes
+func (trxnConsumer *TransactionConsumer) dispatch(deliveries <-chan Delivery) {
+	partitions := trxnConsumer.partitions
+
+	for delivery := range deliveries {
+		var key struct {
+			AccountNumber string `json:"account_number"`
+		}
+
+		// Unreadable messages land on any worker, which dead-letters them
+		json.Unmarshal(delivery.Body(), &key)
+
+		partitions[Partition(key.AccountNumber, len(partitions))] <- delivery
+	}
+}
+
+// Partition maps an account number onto one of n workers
+func Partition(accountNumber string, n int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(accountNumber))
+
+	return int(hash.Sum32() % uint32(n))
+}
+
+func (trxnConsumer *TransactionConsumer) processMessages(deliveries <-chan Delivery) {
+	for delivery := range deliveries {
+		log.Printf("Received transaction message: %s", delivery.MessageID())
 
 		var txMsg dto.TransactionMessage
 
-		if err := json.Unmarshal(delivery.Body, &txMsg); err != nil {
+		// A message that can't be read will never succeed
+		if err := json.Unmarshal(delivery.Body(), &txMsg); err != nil {
 			log.Printf("failed to unmarshal transaction: %v", err)
-			delivery.Nack(false, false)
+			trxnConsumer.deadLetter(delivery, delivery.Attempts(), err)
 			continue
 		}
 
-		err := trxnConsumer.processTransaction(&txMsg)
-		if err != nil {
+		if err := trxnConsumer.processTransaction(trxnConsumer.ctx, &txMsg); err != nil {
+			// Aborted by the shutdown, not a failed attempt
+			if trxnConsumer.ctx.Err() != nil {
+				log.Printf("Processing of transaction %s was cancelled, requeueing it", txMsg.ID)
+				delivery.Nack(true)
+				continue
+			}
+
 			log.Printf("Failed to process transaction %s: %v", txMsg.ID, err)
-			delivery.Nack(false, true) // Reject and requeue for retry
+			trxnConsumer.handleFailure(delivery, &txMsg, err)
+			continue
 		}
 
 		log.Printf("Successfully processed transaction %s", txMsg.ID)
-		delivery.Ack(false) // Acknowledge successful processing
+		delivery.Ack() // Acknowledge successful processing
 	}
 }
 
-func (trxnConsumer *TransactionConsumer) processTransaction(txMsg *dto.TransactionMessage) error {
+// handleFailure schedules another attempt after the retry delay, or
+// dead-letters the message when the failure is permanent or retries ran out
+func (trxnConsumer *TransactionConsumer) handleFailure(delivery Delivery, txMsg *dto.TransactionMessage, cause error) {
+	ctx := context.Background()
+	attempts := delivery.Attempts()
+
+	if service.IsPermanent(cause) || attempts >= trxnConsumer.retryPolicy.MaxRetries {
+		if err := trxnConsumer.txLogService.MarkDeadLettered(ctx, txMsg.ID, attempts, cause.Error()); err != nil {
+			log.Printf("Failed to record dead-lettered transaction %s: %v", txMsg.ID, err)
+		}
+
+		trxnConsumer.deadLetter(delivery, attempts, cause)
+		return
+	}
+
+	next := attempts + 1
+	if err := delivery.Retry(next, cause); err != nil {
+		log.Printf("Failed to schedule retry of transaction %s: %v", txMsg.ID, err)
+		trxnConsumer.requeue(delivery, trxnConsumer.retryPolicy.Delay(next))
+		return
+	}
+
+	if err := trxnConsumer.txLogService.RecordRetry(ctx, txMsg.ID, next, cause.Error()); err != nil {
+		log.Printf("Failed to record retry of transaction %s: %v", txMsg.ID, err)
+	}
+
+	log.Printf("Retrying transaction %s in %s (attempt %d of %d)", txMsg.ID, trxnConsumer.retryPolicy.Delay(next), next, trxnConsumer.retryPolicy.MaxRetries)
+	delivery.Ack()
+}
+
+// deadLetter parks the message for inspection
+func (trxnConsumer *TransactionConsumer) deadLetter(delivery Delivery, attempts int, cause error) {
+	if err := delivery.DeadLetter(attempts, cause); err != nil {
+		log.Printf("Failed to dead-letter message %s: %v", delivery.MessageID(), err)
+		trxnConsumer.requeue(delivery, trxnConsumer.retryPolicy.Delay(attempts))
+		return
+	}
+
+	log.Printf("Dead-lettered message %s after %d retries: %v", delivery.MessageID(), attempts, cause)
+	delivery.Ack()
+}
+
+// requeue gives the message back to the queue after delay. The broker refused
+// its retry or dead-letter copy, and requeueing at once would only redeliver
+// it straight away in a tight loop. A shutdown cuts the wait short.
+func (trxnConsumer *TransactionConsumer) requeue(delivery Delivery, delay time.Duration) {
+	select {
+	case <-time.After(delay):
+	case <-trxnConsumer.ctx.Done():
+	}
+
+	delivery.Nack(true)
+}
+
+func (trxnConsumer *TransactionConsumer) processTransaction(ctx context.Context, txMsg *dto.TransactionMessage) error {
+	switch txMsg.Type {
+	case model.TransactionTypeTransfer:
+		return trxnConsumer.txService.ProcessTransfer(
+			ctx,
+			txMsg.ID,
+			txMsg.AccountNumber,
+			txMsg.ToAccountNumber,
+			txMsg.Amount,
+			txMsg.Fx,
+		)
+	case model.TransactionTypeWithdrawal:
+		if txMsg.HoldId != "" {
+			return trxnConsumer.txService.ProcessHoldCapture(
+				ctx,
+				txMsg.ID,
+				txMsg.AccountNumber,
+				txMsg.HoldId,
+				txMsg.Amount,
+			)
+		}
+	case model.TransactionTypeReversal:
+		return trxnConsumer.txService.ProcessReversal(
+			ctx,
+			txMsg.ID,
+			txMsg.OriginalTransactionId,
+			txMsg.Amount,
+		)
+	}
+
 	// Process the transaction using the refactored method
 	return trxnConsumer.txService.ProcessTransaction(
-		context.Background(),
+		ctx,
 		txMsg.ID,
 		txMsg.AccountNumber,
 		txMsg.Amount,
 		txMsg.Type,
+		txMsg.Fx,
 	)
 }
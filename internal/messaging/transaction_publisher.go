@@ -22,6 +22,10 @@ func NewTransactionPublisher(rabbitmq *RabbitMQ) *TransactionPublisher {
 	}
 }
 
+func (TrxnPublisher *TransactionPublisher) IsConnected() bool {
+	return TrxnPublisher.rabbitmq.IsConnected()
+}
+
 func (TrxnPublisher *TransactionPublisher) PublishTransaction(txMsg *dto.TransactionMessage) error {
 	body, err := json.Marshal(txMsg)
 	if err != nil {
@@ -31,17 +35,19 @@ func (TrxnPublisher *TransactionPublisher) PublishTransaction(txMsg *dto.Transac
 	queueName := config.GetConfig().RabbitMQ.Queue
 	fmt.Printf("Publishing to queue: %s\n", queueName)
 
-	// Publish to the Queue
-	err = TrxnPublisher.rabbitmq.channel.Publish(
+	// Fails fast while the broker is unreachable and fails when the broker
+	// does not confirm the message, the caller retries later
+	err = TrxnPublisher.rabbitmq.Publish(
 		EXCHANGE_NAME,
 		queueName,
-		false,
-		false,
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-			Timestamp:   time.Now(),
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    txMsg.ID,
+			Body:         body,
+			Timestamp:    time.Now(),
 		},
+		publishTimeout(),
 	)
 
 	if err != nil {
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	customError "golang-exercise/internal/error"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Request bodies larger than this are spooled to a temporary file to be hashed
+const IDEMPOTENCY_BODY_MEMORY_LIMIT = 1 << 20
+
+// responseRecorder keeps a copy of the response body while it is written out
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *responseRecorder) WriteString(data string) (int, error) {
+	recorder.body.WriteString(data)
+	return recorder.ResponseWriter.WriteString(data)
+}
+
+// Idempotency replays the stored response when a request is retried with the
+// same Idempotency-Key header. Requests without the header pass through.
+func Idempotency(idempotencyService *service.IdempotencyService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idempotencyKey := ctx.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			ctx.Next()
+			return
+		}
+
+		body, fingerprint, err := spoolBody(ctx.Request.Body, service.NewFingerprintHash(ctx.Request.Method, ctx.Request.URL.Path))
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, customError.NewValidationError("failed to read request body"))
+			return
+		}
+		defer body.Close()
+		ctx.Request.Body = body
+
+		scope := ctx.Request.Method + " " + ctx.FullPath()
+
+		key, replay, err := idempotencyService.Begin(ctx, idempotencyKey, scope, fingerprint)
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, customError.NewCustomError(customError.ConflictError, err.Error(), idempotencyKey))
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			ctx.AbortWithStatusJSON(http.StatusConflict, customError.NewCustomError(customError.ConflictError, err.Error(), idempotencyKey))
+			return
+		case err != nil:
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, customError.NewInternalServerError(err.Error()))
+			return
+		}
+
+		if replay {
+			ctx.Header("Idempotent-Replayed", "true")
+			ctx.Data(*key.ResponseStatus, "application/json; charset=utf-8", []byte(*key.ResponseBody))
+			ctx.Abort()
+			return
+		}
+
+		release := func() {
+			if err := idempotencyService.Release(context.WithoutCancel(ctx), key); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", idempotencyKey, err)
+			}
+		}
+
+		// A panicking handler answers 500 through the recovery middleware, so
+		// the key is released like for any other server error
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = recorder
+
+		ctx.Next()
+
+		// Server errors are not cached so the client can safely retry them
+		if recorder.Status() >= http.StatusInternalServerError {
+			release()
+			return
+		}
+
+		if err := idempotencyService.Complete(ctx, key, recorder.Status(), recorder.body.Bytes()); err != nil {
+			log.Printf("Failed to store response for idempotency key %s: %v", idempotencyKey, err)
+		}
+	}
+}
+
+// spooledFile is a request body spooled to disk, removed once closed
+type spooledFile struct {
+	*os.File
+}
+
+func (file spooledFile) Close() error {
+	file.File.Close()
+	return os.Remove(file.Name())
+}
+
+// spoolBody reads the body through hash so it can be read again afterwards.
+// Small bodies stay in memory, larger ones are spooled to a temporary file.
+func spoolBody(body io.Reader, hash hash.Hash) (io.ReadCloser, string, error) {
+	buffer := &bytes.Buffer{}
+	n, err := io.CopyN(io.MultiWriter(buffer, hash), body, IDEMPOTENCY_BODY_MEMORY_LIMIT+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", err
+	}
+
+	if n <= IDEMPOTENCY_BODY_MEMORY_LIMIT {
+		return io.NopCloser(buffer), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	file, err := os.CreateTemp("", "request-body-*")
+	if err != nil {
+		return nil, "", err
+	}
+	spooled := spooledFile{file}
+
+	if _, err := buffer.WriteTo(file); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := io.Copy(io.MultiWriter(file, hash), body); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+
+	return spooled, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,323 @@
+// Package reconcile replays the Mongo transaction log of an account and
+// compares it with the Postgres balance and the ledger.
+package reconcile
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+
+	"github.com/shopspring/decimal"
+)
+
+type Kind string
+
+const (
+	// Posted in the ledger but there is no log for it in Mongo
+	KindMissingLog Kind = "MISSING_LOG"
+	// Posted but not logged as COMPLETED, or never posted and pending for too long
+	KindStuckStatus Kind = "STUCK_STATUS"
+	// Logged as COMPLETED but nothing was posted in the ledger
+	KindUnposted Kind = "UNPOSTED_COMPLETED"
+	// The replayed log amount differs from what the ledger posted
+	KindAmountMismatch Kind = "AMOUNT_MISMATCH"
+	// The replayed completed logs don't add up to the account balance
+	KindBalanceDrift Kind = "BALANCE_DRIFT"
+)
+
+type Discrepancy struct {
+	Kind          Kind                    `json:"kind"`
+	TransactionId string                  `json:"transaction_id,omitempty"`
+	Status        model.TransactionStatus `json:"status,omitempty"`
+	Logged        *decimal.Decimal        `json:"logged,omitempty"`
+	Posted        *decimal.Decimal        `json:"posted,omitempty"`
+	Detail        string                  `json:"detail"`
+
+	// The status the log can safely be repaired to, empty when it needs a human
+	RepairTo model.TransactionStatus `json:"repair_to,omitempty"`
+	Repaired bool                    `json:"repaired"`
+}
+
+// Account is the Postgres side of the comparison
+type Account struct {
+	ID            uint
+	AccountNumber string
+	Balance       decimal.Decimal
+
+	// When the first journal entry was posted. Logs completed before it predate
+	// the ledger and have no postings of their own, the ledger migration booked
+	// their net effect as the opening entry of the account.
+	LedgerStartedAt time.Time
+}
+
+type AccountReport struct {
+	AccountNumber   string          `json:"account_number"`
+	Balance         decimal.Decimal `json:"balance"`
+	ReplayedBalance decimal.Decimal `json:"replayed_balance"`
+	Drift           decimal.Decimal `json:"drift"`
+	LogCount        int             `json:"log_count"`
+	Discrepancies   []Discrepancy   `json:"discrepancies"`
+}
+
+func (report *AccountReport) HasDiscrepancies() bool {
+	return len(report.Discrepancies) > 0
+}
+
+// Report is the outcome of one reconciliation run. Only accounts with
+// discrepancies are listed.
+type Report struct {
+	StartedAt          time.Time       `json:"started_at"`
+	FinishedAt         time.Time       `json:"finished_at"`
+	Repair             bool            `json:"repair"`
+	AccountsChecked    int             `json:"accounts_checked"`
+	AccountsWithIssues int             `json:"accounts_with_issues"`
+	Discrepancies      map[Kind]int    `json:"discrepancies"`
+	Accounts           []AccountReport `json:"accounts"`
+	Errors             []string        `json:"errors,omitempty"`
+}
+
+func NewReport(startedAt time.Time, repair bool) *Report {
+	return &Report{
+		StartedAt:     startedAt,
+		Repair:        repair,
+		Discrepancies: map[Kind]int{},
+		Accounts:      []AccountReport{},
+	}
+}
+
+func (report *Report) Add(account AccountReport) {
+	report.AccountsChecked++
+	if !account.HasDiscrepancies() {
+		return
+	}
+
+	report.AccountsWithIssues++
+	for _, discrepancy := range account.Discrepancies {
+		report.Discrepancies[discrepancy.Kind]++
+	}
+	report.Accounts = append(report.Accounts, account)
+}
+
+// Effect returns how much the logged transaction changed the balance of the
+// account. Reversals need the log of the transaction they reverse; false is
+// returned when the effect cannot be derived from the logs.
+func Effect(txLog *model.TransactionLog, accountID uint, original *model.TransactionLog) (decimal.Decimal, bool) {
+	switch txLog.Type {
+	case model.TransactionTypeDeposit, model.TransactionTypeInterest, model.TransactionTypeOpeningBalance:
+		if txLog.ToAccountId == accountID {
+			return txLog.Amount, true
+		}
+	case model.TransactionTypeWithdrawal, model.TransactionTypeFee:
+		if txLog.FromAccountId == accountID {
+			return txLog.Amount.Neg(), true
+		}
+	case model.TransactionTypeTransfer:
+		effect := decimal.Zero
+		if txLog.FromAccountId == accountID {
+			effect = effect.Sub(txLog.Amount)
+		}
+		if txLog.ToAccountId == accountID {
+			// The destination is credited in its own currency
+			credit := txLog.Amount
+			if txLog.ConvertedAmount != nil {
+				credit = *txLog.ConvertedAmount
+			}
+			effect = effect.Add(credit)
+		}
+		return effect, true
+	case model.TransactionTypeReversal:
+		if original == nil || original.Amount.IsZero() {
+			return decimal.Zero, false
+		}
+
+		originalEffect, ok := Effect(original, accountID, nil)
+		if !ok {
+			return decimal.Zero, false
+		}
+
+		// Reversals undo the same share of every leg, as the ledger does
+		ratio := txLog.Amount.Div(original.Amount)
+		return originalEffect.Mul(ratio).Round(4).Neg(), true
+	default:
+		return decimal.Zero, false
+	}
+
+	return decimal.Zero, true
+}
+
+// Posting is what the ledger booked on the account for one transaction
+type Posting struct {
+	Amount   decimal.Decimal
+	PostedAt time.Time
+
+	// The opening balance the ledger migration carried over, it has no log
+	Migrated bool
+}
+
+// Reconciler replays the logs of one account, fed to Add oldest first, and
+// compares them with its balance and with what the ledger posted per
+// transaction. Logs and postings younger than grace are in flight: the worker
+// commits Postgres before it updates Mongo, so they count with their posted
+// amount and are not reported.
+//
+// The balance and postings are read before the logs are streamed. A log whose
+// posting was committed after that read must be left out, the balance does not
+// include it yet.
+type Reconciler struct {
+	account Account
+	posted  map[string]Posting
+	now     time.Time
+	grace   time.Duration
+	report  AccountReport
+
+	// What reversals need to know about the logs added so far
+	logged map[string]model.TransactionLog
+}
+
+func NewReconciler(account Account, posted map[string]Posting, now time.Time, grace time.Duration) *Reconciler {
+	return &Reconciler{
+		account: account,
+		posted:  posted,
+		now:     now,
+		grace:   grace,
+		report: AccountReport{
+			AccountNumber:   account.AccountNumber,
+			Balance:         account.Balance,
+			ReplayedBalance: decimal.Zero,
+			Discrepancies:   []Discrepancy{},
+		},
+		logged: map[string]model.TransactionLog{},
+	}
+}
+
+func (r *Reconciler) Add(txLog *model.TransactionLog) {
+	r.report.LogCount++
+	r.logged[txLog.TransactionId] = model.TransactionLog{
+		Type:            txLog.Type,
+		FromAccountId:   txLog.FromAccountId,
+		ToAccountId:     txLog.ToAccountId,
+		Amount:          txLog.Amount,
+		ConvertedAmount: txLog.ConvertedAmount,
+	}
+
+	posting, isPosted := r.posted[txLog.TransactionId]
+	inFlight := r.now.Sub(txLog.Timestamp) < r.grace
+
+	if txLog.Status != model.TransactionStatusCompleted {
+		switch {
+		case isPosted && inFlight:
+			r.report.ReplayedBalance = r.report.ReplayedBalance.Add(posting.Amount)
+		case isPosted:
+			r.report.Discrepancies = append(r.report.Discrepancies, Discrepancy{
+				Kind:          KindStuckStatus,
+				TransactionId: txLog.TransactionId,
+				Status:        txLog.Status,
+				Posted:        &posting.Amount,
+				Detail:        fmt.Sprintf("posted in the ledger but logged as %s", txLog.Status),
+				RepairTo:      model.TransactionStatusCompleted,
+			})
+		case !inFlight && txLog.Status != model.TransactionStatusFailed:
+			r.report.Discrepancies = append(r.report.Discrepancies, Discrepancy{
+				Kind:          KindStuckStatus,
+				TransactionId: txLog.TransactionId,
+				Status:        txLog.Status,
+				Detail:        fmt.Sprintf("%s since %s and never posted", txLog.Status, txLog.Timestamp.UTC().Format(time.RFC3339)),
+				RepairTo:      model.TransactionStatusFailed,
+			})
+		}
+		return
+	}
+
+	// The migrated opening entry already accounts for it
+	if !isPosted && txLog.Timestamp.Before(r.account.LedgerStartedAt) {
+		return
+	}
+
+	var original *model.TransactionLog
+	if logged, ok := r.logged[txLog.OriginalTransactionId]; ok && txLog.OriginalTransactionId != "" {
+		original = &logged
+	}
+
+	effect, ok := Effect(txLog, r.account.ID, original)
+	if !ok && isPosted {
+		effect = posting.Amount
+	}
+
+	r.report.ReplayedBalance = r.report.ReplayedBalance.Add(effect)
+
+	if !isPosted {
+		logged := effect
+		r.report.Discrepancies = append(r.report.Discrepancies, Discrepancy{
+			Kind:          KindUnposted,
+			TransactionId: txLog.TransactionId,
+			Status:        txLog.Status,
+			Logged:        &logged,
+			Detail:        "logged as COMPLETED but not posted in the ledger",
+		})
+	} else if ok && !effect.Equal(posting.Amount) {
+		logged := effect
+		r.report.Discrepancies = append(r.report.Discrepancies, Discrepancy{
+			Kind:          KindAmountMismatch,
+			TransactionId: txLog.TransactionId,
+			Status:        txLog.Status,
+			Logged:        &logged,
+			Posted:        &posting.Amount,
+			Detail:        "logged amount differs from the ledger",
+		})
+	}
+}
+
+// Finish reports the postings without a log and the balance drift. It is
+// called once, after the last log was added.
+func (r *Reconciler) Finish() AccountReport {
+	report := r.report
+
+	var missing []string
+	for transactionID, posting := range r.posted {
+		if _, ok := r.logged[transactionID]; ok {
+			continue
+		}
+
+		// The migrated opening balance never had a log, and the log of a recent
+		// posting may simply not have been written yet
+		if posting.Migrated || r.now.Sub(posting.PostedAt) < r.grace {
+			report.ReplayedBalance = report.ReplayedBalance.Add(posting.Amount)
+			continue
+		}
+
+		missing = append(missing, transactionID)
+	}
+	sort.Strings(missing)
+
+	for _, transactionID := range missing {
+		posting := r.posted[transactionID]
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{
+			Kind:          KindMissingLog,
+			TransactionId: transactionID,
+			Posted:        &posting.Amount,
+			Detail:        "posted in the ledger but missing from the transaction log",
+		})
+	}
+
+	report.Drift = r.account.Balance.Sub(report.ReplayedBalance)
+	if !report.Drift.IsZero() {
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{
+			Kind:   KindBalanceDrift,
+			Detail: fmt.Sprintf("balance %s, completed logs add up to %s", r.account.Balance.String(), report.ReplayedBalance.String()),
+		})
+	}
+
+	return report
+}
+
+// ReconcileAccount runs a Reconciler over logs, which are ordered oldest first
+func ReconcileAccount(account Account, logs []model.TransactionLog, posted map[string]Posting, now time.Time, grace time.Duration) AccountReport {
+	reconciler := NewReconciler(account, posted, now, grace)
+	for i := range logs {
+		reconciler.Add(&logs[i])
+	}
+
+	return reconciler.Finish()
+}
@@ -0,0 +1,221 @@
+// Package recurrence computes the run times of recurring schedules. A spec is
+// either a five field cron expression (minute hour day-of-month month
+// day-of-week, evaluated in UTC), "@every <duration>" anchored at the start of
+// the schedule, or one of the @hourly, @daily, @weekly and @monthly shorthands.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron searches give up after this long without a match, e.g. for "0 0 30 2 *"
+const MAX_SEARCH_YEARS = 5
+
+type Schedule interface {
+	// Next returns the first run strictly after the given time, or the zero
+	// time when there is none
+	Next(after time.Time) time.Time
+}
+
+var shorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Parse reads spec. Interval schedules run at anchor and every interval after it.
+func Parse(spec string, anchor time.Time) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if expanded, ok := shorthands[spec]; ok {
+		spec = expanded
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+
+		if interval < time.Minute {
+			return nil, errors.New("interval must be at least one minute")
+		}
+
+		return &Interval{Anchor: anchor, Every: interval}, nil
+	}
+
+	return ParseCron(spec)
+}
+
+// Interval runs at Anchor and every Every after it
+type Interval struct {
+	Anchor time.Time
+	Every  time.Duration
+}
+
+func (interval *Interval) Next(after time.Time) time.Time {
+	if after.Before(interval.Anchor) {
+		return interval.Anchor
+	}
+
+	elapsed := after.Sub(interval.Anchor)
+	return interval.Anchor.Add((elapsed/interval.Every + 1) * interval.Every)
+}
+
+// Cron matches times whose fields are all in the allowed sets
+type Cron struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	// When both day fields are restricted a day matching either one runs
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type field struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+func ParseCron(spec string) (*Cron, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression needs %d fields, got %d", len(cronFields), len(parts))
+	}
+
+	sets := make([]uint64, len(cronFields))
+	for index, part := range parts {
+		set, err := parseField(part, cronFields[index])
+		if err != nil {
+			return nil, err
+		}
+
+		sets[index] = set
+	}
+
+	return &Cron{
+		minutes:       sets[0],
+		hours:         sets[1],
+		daysOfMonth:   sets[2],
+		months:        sets[3],
+		daysOfWeek:    sets[4],
+		anyDayOfMonth: parts[2] == "*",
+		anyDayOfWeek:  parts[4] == "*",
+	}, nil
+}
+
+// parseField turns a comma separated list of values, ranges (a-b) and steps
+// (*/n, a-b/n) into a bit set
+func parseField(expression string, f field) (uint64, error) {
+	var set uint64
+
+	for _, item := range strings.Split(expression, ",") {
+		rangePart, step := item, 1
+
+		if before, after, found := strings.Cut(item, "/"); found {
+			parsed, err := strconv.Atoi(after)
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", after, f.name)
+			}
+
+			rangePart, step = before, parsed
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", from, f.name)
+			}
+
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", to, f.name)
+				}
+			} else if step > 1 {
+				high = f.max
+			}
+		}
+
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%s field %q is out of range %d-%d", f.name, item, f.min, f.max)
+		}
+
+		for value := low; value <= high; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+
+	return set, nil
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+func (cron *Cron) matchesDay(t time.Time) bool {
+	dayOfMonth := has(cron.daysOfMonth, t.Day())
+	dayOfWeek := has(cron.daysOfWeek, int(t.Weekday()))
+
+	switch {
+	case cron.anyDayOfMonth && cron.anyDayOfWeek:
+		return true
+	case cron.anyDayOfMonth:
+		return dayOfWeek
+	case cron.anyDayOfWeek:
+		return dayOfMonth
+	}
+
+	return dayOfMonth || dayOfWeek
+}
+
+func (cron *Cron) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(MAX_SEARCH_YEARS, 0, 0)
+
+	for t.Before(limit) {
+		if !has(cron.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !cron.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !has(cron.hours, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+
+		if !has(cron.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
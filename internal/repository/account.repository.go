@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"golang-exercise/internal/database"
 	"golang-exercise/internal/database/model"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AccountRepository struct {
@@ -29,8 +32,12 @@ func (repo *AccountRepository) GetDB() *gorm.DB {
 	return repo.db
 }
 
-func (repo *AccountRepository) Create(ctx context.Context, account *model.Account) error {
-	result := repo.db.WithContext(ctx).Create(&account)
+func (repo *AccountRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *AccountRepository) Create(ctx context.Context, account *model.Account, tx Tx) error {
+	result := gormConn(tx, repo.db).WithContext(ctx).Create(&account)
 	if result.Error != nil {
 		return fmt.Errorf("failed to create the user: %w", result.Error)
 	}
@@ -49,6 +56,31 @@ func (repo *AccountRepository) GetByAccountNumber(ctx context.Context, accountNu
 	return account, nil
 }
 
+func (repo *AccountRepository) GetByID(ctx context.Context, id uint) (*model.Account, error) {
+	account := &model.Account{}
+	result := repo.db.WithContext(ctx).First(account, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return account, nil
+}
+
+// GetForUpdate loads the account inside tx and holds a row lock on it (SELECT ... FOR UPDATE)
+func (repo *AccountRepository) GetForUpdate(ctx context.Context, accountNumber string, tx Tx) (*model.Account, error) {
+	account := &model.Account{}
+	result := gormConn(tx, repo.db).WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(account, "account_number = ?", accountNumber)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return account, nil
+}
+
 func (repo *AccountRepository) Count(ctx context.Context, accountNumber string) (int64, error) {
 	var count int64
 
@@ -57,8 +89,16 @@ func (repo *AccountRepository) Count(ctx context.Context, accountNumber string)
 	return count, result.Error
 }
 
-func (repo *AccountRepository) Update(ctx context.Context, accountNumber string, account *model.Account) error {
-	result := repo.db.WithContext(ctx).Model(&model.Account{}).Where("account_number = ?", accountNumber).Updates(account)
+func (repo *AccountRepository) NextAccountNumber(ctx context.Context) (int64, error) {
+	var next int64
+
+	result := repo.db.WithContext(ctx).Raw("SELECT nextval('account_number_seq')").Scan(&next)
+
+	return next, result.Error
+}
+
+func (repo *AccountRepository) Update(ctx context.Context, accountNumber string, account *model.Account, tx Tx) error {
+	result := gormConn(tx, repo.db).WithContext(ctx).Model(&model.Account{}).Where("account_number = ?", accountNumber).Updates(account)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update the user: %w", result.Error)
 	}
@@ -70,6 +110,87 @@ func (repo *AccountRepository) Update(ctx context.Context, accountNumber string,
 	return nil
 }
 
+func (repo *AccountRepository) UpdateBalance(ctx context.Context, accountNumber string, balance decimal.Decimal, tx Tx) error {
+	result := gormConn(tx, repo.db).WithContext(ctx).Model(&model.Account{}).Where("account_number = ?", accountNumber).Updates(&model.Account{Balance: balance})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update account balance: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to find account with number %s for balance update", accountNumber)
+	}
+
+	return nil
+}
+
+// UpdateHeldBalance sets the total amount reserved by active holds on the account
+func (repo *AccountRepository) UpdateHeldBalance(ctx context.Context, accountNumber string, heldBalance decimal.Decimal, tx Tx) error {
+	result := gormConn(tx, repo.db).WithContext(ctx).Model(&model.Account{}).Where("account_number = ?", accountNumber).Update("held_balance", heldBalance)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update account held balance: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to find account with number %s for held balance update", accountNumber)
+	}
+
+	return nil
+}
+
+// UpdateBalancePolicy stores the account's policy overrides, nil clears an override
+func (repo *AccountRepository) UpdateBalancePolicy(ctx context.Context, accountNumber string, minimumBalance *decimal.Decimal, overdraftLimit *decimal.Decimal) error {
+	result := repo.db.WithContext(ctx).Model(&model.Account{}).Where("account_number = ?", accountNumber).Updates(map[string]interface{}{
+		"minimum_balance": minimumBalance,
+		"overdraft_limit": overdraftLimit,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update the balance policy: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to find account with number %s for policy update", accountNumber)
+	}
+
+	return nil
+}
+
+// ListOpenAccountNumbers returns the numbers of every account of the type that is not closed
+func (repo *AccountRepository) ListOpenAccountNumbers(ctx context.Context, accountType model.AccountType) ([]string, error) {
+	var accountNumbers []string
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Account{}).
+		Where("account_type = ? AND account_status <> ?", accountType, model.AccountClosed).
+		Order("account_number").
+		Pluck("account_number", &accountNumbers)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", result.Error)
+	}
+
+	return accountNumbers, nil
+}
+
+// ListAccountNumbersOpenDuring returns the numbers of every account open at
+// some point of [start, end). Closing is final, so a closed account's status
+// change is when it was closed.
+func (repo *AccountRepository) ListAccountNumbersOpenDuring(ctx context.Context, start time.Time, end time.Time) ([]string, error) {
+	var accountNumbers []string
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Account{}).
+		Where("created_at < ?", end).
+		Where("(account_status <> ? OR status_changed_at IS NULL OR status_changed_at >= ?)", model.AccountClosed, start).
+		Order("account_number").
+		Pluck("account_number", &accountNumbers)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", result.Error)
+	}
+
+	return accountNumbers, nil
+}
+
 func (repo *AccountRepository) GetAll() ([]*model.Account, error) {
 	// Logic for getting all accounts, can be used by the admins API
 	return nil, nil
@@ -79,3 +200,20 @@ func (repo *AccountRepository) Delete() error {
 	// Logic for deleting the account, if needed
 	return nil
 }
+
+// ListAfterID pages through all accounts in id order
+func (repo *AccountRepository) ListAfterID(ctx context.Context, afterID uint, limit int) ([]model.Account, error) {
+	var accounts []model.Account
+
+	result := repo.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&accounts)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", result.Error)
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+
+	"gorm.io/gorm"
+)
+
+type BatchRepository struct {
+	db *gorm.DB
+}
+
+func NewBatchRepository() *BatchRepository {
+	return &BatchRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewBatchRepositoryWithDB(db *gorm.DB) *BatchRepository {
+	return &BatchRepository{
+		db: db,
+	}
+}
+
+func (repo *BatchRepository) GetDB() *gorm.DB {
+	return repo.db
+}
+
+func (repo *BatchRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *BatchRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+func (repo *BatchRepository) Create(ctx context.Context, batch *model.TransactionBatch, items []model.TransactionBatchItem, tx Tx) error {
+	db := repo.conn(tx).WithContext(ctx)
+
+	if err := db.Create(batch).Error; err != nil {
+		return fmt.Errorf("failed to create batch: %w", err)
+	}
+
+	if err := db.Create(&items).Error; err != nil {
+		return fmt.Errorf("failed to create batch items: %w", err)
+	}
+
+	return nil
+}
+
+func (repo *BatchRepository) GetByBatchID(ctx context.Context, batchID string) (*model.TransactionBatch, error) {
+	var batch model.TransactionBatch
+	if err := repo.db.WithContext(ctx).Where("batch_id = ?", batchID).First(&batch).Error; err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+func (repo *BatchRepository) ListItems(ctx context.Context, batchID string) ([]model.TransactionBatchItem, error) {
+	var items []model.TransactionBatchItem
+	err := repo.db.WithContext(ctx).
+		Where("batch_id = ?", batchID).
+		Order("item_index ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list batch items: %w", err)
+	}
+
+	return items, nil
+}
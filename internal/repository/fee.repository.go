@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeeRepository struct {
+	db *gorm.DB
+}
+
+func NewFeeRepository() *FeeRepository {
+	return &FeeRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewFeeRepositoryWithDB(db *gorm.DB) *FeeRepository {
+	return &FeeRepository{
+		db: db,
+	}
+}
+
+func (repo *FeeRepository) GetDB() *gorm.DB {
+	return repo.db
+}
+
+func (repo *FeeRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *FeeRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+// HasMaintenanceCharge reports whether the fee of the period was already decided
+func (repo *FeeRepository) HasMaintenanceCharge(ctx context.Context, accountNumber string, period string, tx Tx) (bool, error) {
+	var count int64
+
+	result := repo.conn(tx).WithContext(ctx).
+		Model(&model.MaintenanceCharge{}).
+		Where("account_number = ? AND period = ?", accountNumber, period).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// CreateMaintenanceCharge records the charge and reports false when the period
+// was already recorded
+func (repo *FeeRepository) CreateMaintenanceCharge(ctx context.Context, charge *model.MaintenanceCharge, tx Tx) (bool, error) {
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_number"}, {Name: "period"}},
+			DoNothing: true,
+		}).
+		Create(charge)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record maintenance charge: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FxRateRepository struct {
+	db *gorm.DB
+}
+
+func NewFxRateRepository() *FxRateRepository {
+	return &FxRateRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewFxRateRepositoryWithDB(db *gorm.DB) *FxRateRepository {
+	return &FxRateRepository{
+		db: db,
+	}
+}
+
+// Upsert stores the rates, replacing an existing rate for the same pair and effective time
+func (repo *FxRateRepository) Upsert(ctx context.Context, rates []model.FxRate) error {
+	result := repo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_at"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+		}).
+		Create(&rates)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to store fx rates: %w", result.Error)
+	}
+
+	return nil
+}
+
+// GetEffective returns the latest rate for the pair that took effect at or before at
+func (repo *FxRateRepository) GetEffective(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (*model.FxRate, error) {
+	rate := &model.FxRate{}
+	result := repo.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_at <= ?", baseCurrency, quoteCurrency, at).
+		Order("effective_at DESC").
+		First(rate)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return rate, nil
+}
+
+func (repo *FxRateRepository) List(ctx context.Context, baseCurrency string, quoteCurrency string, limit int) ([]model.FxRate, error) {
+	query := repo.db.WithContext(ctx)
+	if baseCurrency != "" {
+		query = query.Where("base_currency = ?", baseCurrency)
+	}
+	if quoteCurrency != "" {
+		query = query.Where("quote_currency = ?", quoteCurrency)
+	}
+
+	var rates []model.FxRate
+	result := query.Order("effective_at DESC").Limit(limit).Find(&rates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HoldRepository struct {
+	db *gorm.DB
+}
+
+func NewHoldRepository() *HoldRepository {
+	return &HoldRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewHoldRepositoryWithDB(db *gorm.DB) *HoldRepository {
+	return &HoldRepository{
+		db: db,
+	}
+}
+
+func (repo *HoldRepository) GetDB() *gorm.DB {
+	return repo.db
+}
+
+func (repo *HoldRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *HoldRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+func (repo *HoldRepository) Create(ctx context.Context, hold *model.Hold, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).Create(hold)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create hold: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (repo *HoldRepository) GetByHoldID(ctx context.Context, holdID string) (*model.Hold, error) {
+	hold := &model.Hold{}
+	result := repo.db.WithContext(ctx).First(hold, "hold_id = ?", holdID)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return hold, nil
+}
+
+// GetForUpdate loads the hold inside tx and holds a row lock on it
+func (repo *HoldRepository) GetForUpdate(ctx context.Context, holdID string, tx Tx) (*model.Hold, error) {
+	hold := &model.Hold{}
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(hold, "hold_id = ?", holdID)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return hold, nil
+}
+
+func (repo *HoldRepository) ListByAccount(ctx context.Context, accountNumber string, status string) ([]model.Hold, error) {
+	query := repo.db.WithContext(ctx).Where("account_number = ?", accountNumber)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var holds []model.Hold
+	result := query.Order("id DESC").Find(&holds)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return holds, nil
+}
+
+// ListExpired returns active holds of the account whose expiry has passed
+func (repo *HoldRepository) ListExpired(ctx context.Context, accountNumber string, now time.Time, tx Tx) ([]model.Hold, error) {
+	var holds []model.Hold
+
+	result := repo.conn(tx).WithContext(ctx).
+		Where("account_number = ? AND status = ? AND expires_at <= ?", accountNumber, model.HoldActive, now).
+		Find(&holds)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return holds, nil
+}
+
+// ListAccountsWithExpiredHolds returns the accounts that still count expired holds as active
+func (repo *HoldRepository) ListAccountsWithExpiredHolds(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var accountNumbers []string
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Hold{}).
+		Distinct("account_number").
+		Where("status = ? AND expires_at <= ?", model.HoldActive, now).
+		Limit(limit).
+		Pluck("account_number", &accountNumbers)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return accountNumbers, nil
+}
+
+func (repo *HoldRepository) Save(ctx context.Context, hold *model.Hold, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).Save(hold)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update hold: %w", result.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewIdempotencyRepositoryWithDB(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Reserve inserts the key unless it already exists for the scope. It reports
+// whether this call created the record.
+func (repo *IdempotencyRepository) Reserve(ctx context.Context, key *model.IdempotencyKey) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(key)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *IdempotencyRepository) Get(ctx context.Context, idempotencyKey string, scope string) (*model.IdempotencyKey, error) {
+	key := &model.IdempotencyKey{}
+	result := repo.db.WithContext(ctx).First(key, "idempotency_key = ? AND scope = ?", idempotencyKey, scope)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return key, nil
+}
+
+// TakeOver claims an abandoned reservation for a retry. The condition on
+// updated_at makes sure only one of several concurrent retries gets it.
+func (repo *IdempotencyRepository) TakeOver(ctx context.Context, id uint, staleBefore time.Time) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Model(&model.IdempotencyKey{}).
+		Where("id = ? AND response_status IS NULL AND updated_at < ?", id, staleBefore).
+		Update("updated_at", time.Now())
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to take over idempotency key: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *IdempotencyRepository) SaveResponse(ctx context.Context, id uint, status int, body string) error {
+	result := repo.db.WithContext(ctx).
+		Model(&model.IdempotencyKey{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"response_status": status,
+			"response_body":   body,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (repo *IdempotencyRepository) Delete(ctx context.Context, id uint) error {
+	result := repo.db.WithContext(ctx).Unscoped().Delete(&model.IdempotencyKey{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", result.Error)
+	}
+
+	return nil
+}
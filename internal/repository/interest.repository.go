@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InterestRepository struct {
+	db *gorm.DB
+}
+
+func NewInterestRepository() *InterestRepository {
+	return &InterestRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewInterestRepositoryWithDB(db *gorm.DB) *InterestRepository {
+	return &InterestRepository{
+		db: db,
+	}
+}
+
+func (repo *InterestRepository) GetDB() *gorm.DB {
+	return repo.db
+}
+
+func (repo *InterestRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *InterestRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+// ListAccountsDue returns the open accounts of the given type that have not
+// accrued interest through the given day yet
+func (repo *InterestRepository) ListAccountsDue(ctx context.Context, accountType model.AccountType, through time.Time) ([]string, error) {
+	var accountNumbers []string
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Account{}).
+		Where("account_type = ? AND account_status <> ?", accountType, model.AccountClosed).
+		Where("interest_accrued_through IS NULL OR interest_accrued_through < ?", through).
+		Order("account_number").
+		Pluck("account_number", &accountNumbers)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list accounts due for interest: %w", result.Error)
+	}
+
+	return accountNumbers, nil
+}
+
+func (repo *InterestRepository) CreateAccruals(ctx context.Context, accruals []model.InterestAccrual, tx Tx) error {
+	if len(accruals) == 0 {
+		return nil
+	}
+
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&accruals)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to record interest accruals: %w", result.Error)
+	}
+
+	return nil
+}
+
+// UpdateAccrued stores the unpaid interest of the account and the day it was accrued through
+func (repo *InterestRepository) UpdateAccrued(ctx context.Context, accountNumber string, accrued decimal.Decimal, through time.Time, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).
+		Model(&model.Account{}).
+		Where("account_number = ?", accountNumber).
+		Updates(map[string]interface{}{
+			"accrued_interest":         accrued,
+			"interest_accrued_through": through,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update accrued interest: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (repo *InterestRepository) ListAccruals(ctx context.Context, accountNumber string, limit int) ([]model.InterestAccrual, error) {
+	var accruals []model.InterestAccrual
+
+	result := repo.db.WithContext(ctx).
+		Where("account_number = ?", accountNumber).
+		Order("accrual_date DESC").
+		Limit(limit).
+		Find(&accruals)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return accruals, nil
+}
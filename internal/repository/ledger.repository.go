@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository() *LedgerRepository {
+	return &LedgerRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewLedgerRepositoryWithDB(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{
+		db: db,
+	}
+}
+
+func (repo *LedgerRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+// CreateEntry inserts the journal entry together with its postings
+func (repo *LedgerRepository) CreateEntry(ctx context.Context, entry *model.JournalEntry, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).Create(entry)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create journal entry: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (repo *LedgerRepository) ExistsByTransactionID(ctx context.Context, transactionID string, tx Tx) (bool, error) {
+	var count int64
+
+	result := repo.conn(tx).WithContext(ctx).
+		Model(&model.JournalEntry{}).
+		Where("transaction_id = ?", transactionID).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+func (repo *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string, tx Tx) (*model.JournalEntry, error) {
+	entry := &model.JournalEntry{}
+	result := repo.conn(tx).WithContext(ctx).Preload("Postings").First(entry, "transaction_id = ?", transactionID)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entry, nil
+}
+
+// SumReversedAmount totals the amounts of every reversal posted for the transaction
+func (repo *LedgerRepository) SumReversedAmount(ctx context.Context, transactionID string, tx Tx) (decimal.Decimal, error) {
+	var sum decimal.Decimal
+
+	row := repo.conn(tx).WithContext(ctx).
+		Model(&model.JournalEntry{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("reverses_transaction_id = ?", transactionID).
+		Row()
+
+	if err := row.Scan(&sum); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum reversals: %w", err)
+	}
+
+	return sum, nil
+}
+
+func (repo *LedgerRepository) GetPostingsByAccount(ctx context.Context, accountNumber string, limit int, offset int) ([]model.Posting, int64, error) {
+	var total int64
+	result := repo.db.WithContext(ctx).Model(&model.Posting{}).Where("account_number = ?", accountNumber).Count(&total)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var postings []model.Posting
+	result = repo.db.WithContext(ctx).
+		Where("account_number = ?", accountNumber).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&postings)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return postings, total, nil
+}
+
+// SumByAccount returns the sum of every posting made against the account
+func (repo *LedgerRepository) SumByAccount(ctx context.Context, accountNumber string, tx Tx) (decimal.Decimal, error) {
+	var sum decimal.Decimal
+
+	row := repo.conn(tx).WithContext(ctx).
+		Model(&model.Posting{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("account_number = ?", accountNumber).
+		Row()
+
+	if err := row.Scan(&sum); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum postings: %w", err)
+	}
+
+	return sum, nil
+}
+
+// SumByAccountBetween returns the sum of the account's postings made in [from, before)
+func (repo *LedgerRepository) SumByAccountBetween(ctx context.Context, accountNumber string, from time.Time, before time.Time) (decimal.Decimal, error) {
+	var sum decimal.Decimal
+
+	row := repo.db.WithContext(ctx).
+		Model(&model.Posting{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("account_number = ? AND created_at >= ? AND created_at < ?", accountNumber, from, before).
+		Row()
+
+	if err := row.Scan(&sum); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum postings: %w", err)
+	}
+
+	return sum, nil
+}
+
+// ListLines returns the postings of the account in [from, to) together with the
+// entry they belong to, oldest first. Balances are left for the caller to fill in.
+func (repo *LedgerRepository) ListLines(ctx context.Context, accountNumber string, from time.Time, to time.Time) ([]model.StatementLine, error) {
+	var lines []model.StatementLine
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Posting{}).
+		Select("postings.created_at AS date, journal_entries.transaction_id, journal_entries.transaction_type, journal_entries.description, postings.amount").
+		Joins("JOIN journal_entries ON journal_entries.id = postings.journal_entry_id").
+		Where("postings.account_number = ? AND postings.created_at >= ? AND postings.created_at < ?", accountNumber, from, to).
+		Order("postings.created_at, postings.id").
+		Scan(&lines)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list postings: %w", result.Error)
+	}
+
+	return lines, nil
+}
+
+// GetLatestSnapshot returns the newest snapshot of the account taken at or before the given time
+func (repo *LedgerRepository) GetLatestSnapshot(ctx context.Context, accountNumber string, at time.Time) (*model.BalanceSnapshot, error) {
+	snapshot := &model.BalanceSnapshot{}
+
+	result := repo.db.WithContext(ctx).
+		Where("account_number = ? AND as_of <= ?", accountNumber, at).
+		Order("as_of DESC").
+		First(snapshot)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return snapshot, nil
+}
+
+// ListAccountsToSnapshot returns the accounts with postings before the given
+// time that are not yet covered by their latest snapshot
+func (repo *LedgerRepository) ListAccountsToSnapshot(ctx context.Context, before time.Time) ([]string, error) {
+	var accountNumbers []string
+
+	result := repo.db.WithContext(ctx).
+		Table("postings").
+		Joins("LEFT JOIN (SELECT account_number, MAX(as_of) AS as_of FROM balance_snapshots GROUP BY account_number) latest ON latest.account_number = postings.account_number").
+		Where("postings.created_at < ?", before).
+		Where("latest.as_of IS NULL OR postings.created_at >= latest.as_of").
+		Distinct().
+		Order("postings.account_number").
+		Pluck("postings.account_number", &accountNumbers)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list accounts to snapshot: %w", result.Error)
+	}
+
+	return accountNumbers, nil
+}
+
+// CreateSnapshot stores the snapshot unless the account already has one at that time
+func (repo *LedgerRepository) CreateSnapshot(ctx context.Context, snapshot *model.BalanceSnapshot) error {
+	result := repo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_number"}, {Name: "as_of"}},
+			DoNothing: true,
+		}).
+		Create(snapshot)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to store balance snapshot: %w", result.Error)
+	}
+
+	return nil
+}
+
+// TransactionPosting is the net amount a transaction posted to one account.
+// Migrated is set on the opening entry the ledger migration booked.
+type TransactionPosting struct {
+	TransactionId string
+	Amount        decimal.Decimal
+	PostedAt      time.Time
+	Migrated      bool
+}
+
+// ListTransactionPostings returns the net amount every transaction posted to the account
+func (repo *LedgerRepository) ListTransactionPostings(ctx context.Context, accountNumber string, tx Tx) ([]TransactionPosting, error) {
+	var postings []TransactionPosting
+
+	result := repo.conn(tx).WithContext(ctx).
+		Model(&model.Posting{}).
+		Select("journal_entries.transaction_id, SUM(postings.amount) AS amount, MAX(postings.created_at) AS posted_at, "+
+			"BOOL_OR(journal_entries.description = ?) AS migrated", model.MigratedOpeningDescription).
+		Joins("JOIN journal_entries ON journal_entries.id = postings.journal_entry_id").
+		Where("postings.account_number = ?", accountNumber).
+		Group("journal_entries.transaction_id").
+		Scan(&postings)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list postings by transaction: %w", result.Error)
+	}
+
+	return postings, nil
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type AccountRepository struct {
+	*Store
+	accounts map[string]*model.Account
+}
+
+func NewAccountRepository(store *Store) *AccountRepository {
+	return &AccountRepository{
+		Store:    store,
+		accounts: map[string]*model.Account{},
+	}
+}
+
+func (repo *AccountRepository) Create(ctx context.Context, account *model.Account, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.accounts[account.AccountNumber]; ok {
+		return fmt.Errorf("failed to create the user: %w", gorm.ErrDuplicatedKey)
+	}
+
+	account.ID = repo.nextID("accounts")
+	stamp(&account.CreatedAt, &account.UpdatedAt)
+
+	row := *account
+	repo.accounts[account.AccountNumber] = &row
+	onRollback(txOf(tx), func() { delete(repo.accounts, row.AccountNumber) })
+
+	return nil
+}
+
+func (repo *AccountRepository) GetByAccountNumber(ctx context.Context, accountNumber string) (*model.Account, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.get(accountNumber)
+}
+
+// get returns a copy of the account. Callers hold mu.
+func (repo *AccountRepository) get(accountNumber string) (*model.Account, error) {
+	row, ok := repo.accounts[accountNumber]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	account := *row
+	return &account, nil
+}
+
+func (repo *AccountRepository) GetByID(ctx context.Context, id uint) (*model.Account, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, row := range repo.accounts {
+		if row.ID == id {
+			account := *row
+			return &account, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetForUpdate waits for the account's lock and holds it until tx ends
+func (repo *AccountRepository) GetForUpdate(ctx context.Context, accountNumber string, tx repository.Tx) (*model.Account, error) {
+	if err := repo.lock(ctx, txOf(tx), "accounts/"+accountNumber); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByAccountNumber(ctx, accountNumber)
+}
+
+func (repo *AccountRepository) Count(ctx context.Context, accountNumber string) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.accounts[accountNumber]; ok {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func (repo *AccountRepository) NextAccountNumber(ctx context.Context) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return int64(repo.nextID("account_number_seq")), nil
+}
+
+// Update copies the non-zero fields of account, as gorm's Updates does with a
+// struct. Unlike gorm it ignores the embedded gorm.Model: a non-zero ID,
+// CreatedAt or DeletedAt on account is not written, and UpdatedAt is always
+// set to now.
+func (repo *AccountRepository) Update(ctx context.Context, accountNumber string, account *model.Account, tx repository.Tx) error {
+	return repo.update(accountNumber, tx, func(row *model.Account) {
+		source := reflect.ValueOf(account).Elem()
+		target := reflect.ValueOf(row).Elem()
+
+		for i := 0; i < source.NumField(); i++ {
+			if source.Type().Field(i).Anonymous || source.Field(i).IsZero() {
+				continue
+			}
+
+			target.Field(i).Set(source.Field(i))
+		}
+	})
+}
+
+func (repo *AccountRepository) UpdateBalance(ctx context.Context, accountNumber string, balance decimal.Decimal, tx repository.Tx) error {
+	return repo.update(accountNumber, tx, func(row *model.Account) {
+		row.Balance = balance
+	})
+}
+
+func (repo *AccountRepository) UpdateHeldBalance(ctx context.Context, accountNumber string, heldBalance decimal.Decimal, tx repository.Tx) error {
+	return repo.update(accountNumber, tx, func(row *model.Account) {
+		row.HeldBalance = heldBalance
+	})
+}
+
+func (repo *AccountRepository) UpdateBalancePolicy(ctx context.Context, accountNumber string, minimumBalance *decimal.Decimal, overdraftLimit *decimal.Decimal) error {
+	return repo.update(accountNumber, nil, func(row *model.Account) {
+		row.MinimumBalance = minimumBalance
+		row.OverdraftLimit = overdraftLimit
+	})
+}
+
+func (repo *AccountRepository) update(accountNumber string, tx repository.Tx, apply func(row *model.Account)) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.accounts[accountNumber]
+	if !ok {
+		return fmt.Errorf("failed to find account with number %s for update", accountNumber)
+	}
+
+	keep(txOf(tx), row)
+	apply(row)
+	row.UpdatedAt = time.Now()
+
+	return nil
+}
+
+func (repo *AccountRepository) ListOpenAccountNumbers(ctx context.Context, accountType model.AccountType) ([]string, error) {
+	return repo.listNumbers(func(row *model.Account) bool {
+		return row.AccountType == accountType && row.AccountStatus != model.AccountClosed
+	}), nil
+}
+
+func (repo *AccountRepository) ListAccountNumbersOpenDuring(ctx context.Context, start time.Time, end time.Time) ([]string, error) {
+	return repo.listNumbers(func(row *model.Account) bool {
+		closedBefore := row.AccountStatus == model.AccountClosed && row.StatusChangedAt != nil && row.StatusChangedAt.Before(start)
+		return row.CreatedAt.Before(end) && !closedBefore
+	}), nil
+}
+
+func (repo *AccountRepository) listNumbers(match func(row *model.Account) bool) []string {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	accountNumbers := []string{}
+	for accountNumber, row := range repo.accounts {
+		if match(row) {
+			accountNumbers = append(accountNumbers, accountNumber)
+		}
+	}
+	sort.Strings(accountNumbers)
+
+	return accountNumbers
+}
+
+func (repo *AccountRepository) ListAfterID(ctx context.Context, afterID uint, limit int) ([]model.Account, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	accounts := []model.Account{}
+	for _, row := range repo.accounts {
+		if row.ID > afterID {
+			accounts = append(accounts, *row)
+		}
+	}
+
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	if len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type BatchRepository struct {
+	*Store
+	batches map[string]*model.TransactionBatch
+	items   map[string][]model.TransactionBatchItem
+}
+
+func NewBatchRepository(store *Store) *BatchRepository {
+	return &BatchRepository{
+		Store:   store,
+		batches: map[string]*model.TransactionBatch{},
+		items:   map[string][]model.TransactionBatchItem{},
+	}
+}
+
+func (repo *BatchRepository) Create(ctx context.Context, batch *model.TransactionBatch, items []model.TransactionBatchItem, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.batches[batch.BatchId]; ok {
+		return fmt.Errorf("failed to create batch: %w", gorm.ErrDuplicatedKey)
+	}
+
+	batch.ID = repo.nextID("transaction_batches")
+	stamp(&batch.CreatedAt, &batch.UpdatedAt)
+	for i := range items {
+		items[i].ID = repo.nextID("transaction_batch_items")
+		stamp(&items[i].CreatedAt, &items[i].UpdatedAt)
+	}
+
+	batchID := batch.BatchId
+	row := *batch
+	repo.batches[batchID] = &row
+	repo.items[batchID] = append([]model.TransactionBatchItem{}, items...)
+	onRollback(txOf(tx), func() {
+		delete(repo.batches, batchID)
+		delete(repo.items, batchID)
+	})
+
+	return nil
+}
+
+func (repo *BatchRepository) GetByBatchID(ctx context.Context, batchID string) (*model.TransactionBatch, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.batches[batchID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	batch := *row
+	return &batch, nil
+}
+
+func (repo *BatchRepository) ListItems(ctx context.Context, batchID string) ([]model.TransactionBatchItem, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return append([]model.TransactionBatchItem{}, repo.items[batchID]...), nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+)
+
+type FeeRepository struct {
+	*Store
+	charges map[string]*model.MaintenanceCharge
+}
+
+func NewFeeRepository(store *Store) *FeeRepository {
+	return &FeeRepository{
+		Store:   store,
+		charges: map[string]*model.MaintenanceCharge{},
+	}
+}
+
+func chargeKey(accountNumber string, period string) string {
+	return accountNumber + "/" + period
+}
+
+func (repo *FeeRepository) HasMaintenanceCharge(ctx context.Context, accountNumber string, period string, tx repository.Tx) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	_, ok := repo.charges[chargeKey(accountNumber, period)]
+	return ok, nil
+}
+
+func (repo *FeeRepository) CreateMaintenanceCharge(ctx context.Context, charge *model.MaintenanceCharge, tx repository.Tx) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := chargeKey(charge.AccountNumber, charge.Period)
+	if _, ok := repo.charges[key]; ok {
+		return false, nil
+	}
+
+	charge.ID = repo.nextID("maintenance_charges")
+	stamp(&charge.CreatedAt, &charge.UpdatedAt)
+
+	row := *charge
+	repo.charges[key] = &row
+	onRollback(txOf(tx), func() { delete(repo.charges, key) })
+
+	return true, nil
+}
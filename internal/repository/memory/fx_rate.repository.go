@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+
+	"gorm.io/gorm"
+)
+
+type FxRateRepository struct {
+	*Store
+	rates []*model.FxRate
+}
+
+func NewFxRateRepository(store *Store) *FxRateRepository {
+	return &FxRateRepository{
+		Store: store,
+	}
+}
+
+func (repo *FxRateRepository) Upsert(ctx context.Context, rates []model.FxRate) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i := range rates {
+		rate := &rates[i]
+		if row := repo.find(rate.BaseCurrency, rate.QuoteCurrency, rate.EffectiveAt); row != nil {
+			row.Rate = rate.Rate
+			row.UpdatedAt = time.Now()
+			continue
+		}
+
+		rate.ID = repo.nextID("fx_rates")
+		stamp(&rate.CreatedAt, &rate.UpdatedAt)
+
+		row := *rate
+		repo.rates = append(repo.rates, &row)
+	}
+
+	return nil
+}
+
+// find returns the stored rate of the pair taking effect at effectiveAt. Callers hold mu.
+func (repo *FxRateRepository) find(baseCurrency string, quoteCurrency string, effectiveAt time.Time) *model.FxRate {
+	for _, row := range repo.rates {
+		if row.BaseCurrency == baseCurrency && row.QuoteCurrency == quoteCurrency && row.EffectiveAt.Equal(effectiveAt) {
+			return row
+		}
+	}
+
+	return nil
+}
+
+// list returns copies of the matching rates, latest effective first. Callers hold mu.
+func (repo *FxRateRepository) list(match func(rate *model.FxRate) bool) []model.FxRate {
+	rates := []model.FxRate{}
+	for _, row := range repo.rates {
+		if match(row) {
+			rates = append(rates, *row)
+		}
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].EffectiveAt.After(rates[j].EffectiveAt) })
+	return rates
+}
+
+func (repo *FxRateRepository) GetEffective(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (*model.FxRate, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	rates := repo.list(func(rate *model.FxRate) bool {
+		return rate.BaseCurrency == baseCurrency && rate.QuoteCurrency == quoteCurrency && !rate.EffectiveAt.After(at)
+	})
+
+	if len(rates) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &rates[0], nil
+}
+
+func (repo *FxRateRepository) List(ctx context.Context, baseCurrency string, quoteCurrency string, limit int) ([]model.FxRate, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	rates := repo.list(func(rate *model.FxRate) bool {
+		return (baseCurrency == "" || rate.BaseCurrency == baseCurrency) && (quoteCurrency == "" || rate.QuoteCurrency == quoteCurrency)
+	})
+
+	if limit > 0 && len(rates) > limit {
+		rates = rates[:limit]
+	}
+
+	return rates, nil
+}
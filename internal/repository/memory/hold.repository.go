@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type HoldRepository struct {
+	*Store
+	holds map[string]*model.Hold
+}
+
+func NewHoldRepository(store *Store) *HoldRepository {
+	return &HoldRepository{
+		Store: store,
+		holds: map[string]*model.Hold{},
+	}
+}
+
+func (repo *HoldRepository) Create(ctx context.Context, hold *model.Hold, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.holds[hold.HoldId]; ok {
+		return fmt.Errorf("failed to create hold: %w", gorm.ErrDuplicatedKey)
+	}
+
+	hold.ID = repo.nextID("holds")
+	stamp(&hold.CreatedAt, &hold.UpdatedAt)
+
+	row := *hold
+	repo.holds[hold.HoldId] = &row
+	onRollback(txOf(tx), func() { delete(repo.holds, row.HoldId) })
+
+	return nil
+}
+
+func (repo *HoldRepository) GetByHoldID(ctx context.Context, holdID string) (*model.Hold, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.holds[holdID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	hold := *row
+	return &hold, nil
+}
+
+// GetForUpdate waits for the hold's lock and holds it until tx ends
+func (repo *HoldRepository) GetForUpdate(ctx context.Context, holdID string, tx repository.Tx) (*model.Hold, error) {
+	if err := repo.lock(ctx, txOf(tx), "holds/"+holdID); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByHoldID(ctx, holdID)
+}
+
+// list returns copies of the matching holds, newest first. Callers hold mu.
+func (repo *HoldRepository) list(match func(hold *model.Hold) bool) []model.Hold {
+	holds := []model.Hold{}
+	for _, row := range repo.holds {
+		if match(row) {
+			holds = append(holds, *row)
+		}
+	}
+
+	sort.Slice(holds, func(i, j int) bool { return holds[i].ID > holds[j].ID })
+	return holds
+}
+
+func (repo *HoldRepository) ListByAccount(ctx context.Context, accountNumber string, status string) ([]model.Hold, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.list(func(hold *model.Hold) bool {
+		return hold.AccountNumber == accountNumber && (status == "" || string(hold.Status) == status)
+	}), nil
+}
+
+func (repo *HoldRepository) ListExpired(ctx context.Context, accountNumber string, now time.Time, tx repository.Tx) ([]model.Hold, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.list(func(hold *model.Hold) bool {
+		return hold.AccountNumber == accountNumber && isExpired(hold, now)
+	}), nil
+}
+
+func isExpired(hold *model.Hold, now time.Time) bool {
+	return hold.Status == model.HoldActive && !hold.ExpiresAt.After(now)
+}
+
+func (repo *HoldRepository) ListAccountsWithExpiredHolds(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	seen := map[string]bool{}
+	accountNumbers := []string{}
+	for _, hold := range repo.list(func(hold *model.Hold) bool { return isExpired(hold, now) }) {
+		if !seen[hold.AccountNumber] && len(accountNumbers) < limit {
+			seen[hold.AccountNumber] = true
+			accountNumbers = append(accountNumbers, hold.AccountNumber)
+		}
+	}
+
+	return accountNumbers, nil
+}
+
+func (repo *HoldRepository) Save(ctx context.Context, hold *model.Hold, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.holds[hold.HoldId]
+	if !ok {
+		return fmt.Errorf("failed to update hold: %w", gorm.ErrRecordNotFound)
+	}
+
+	keep(txOf(tx), row)
+	hold.UpdatedAt = time.Now()
+	*row = *hold
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"golang-exercise/internal/database/model"
+
+	"gorm.io/gorm"
+)
+
+type IdempotencyRepository struct {
+	*Store
+	keys map[string]*model.IdempotencyKey
+}
+
+func NewIdempotencyRepository(store *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		Store: store,
+		keys:  map[string]*model.IdempotencyKey{},
+	}
+}
+
+func scopedKey(idempotencyKey string, scope string) string {
+	return scope + "/" + idempotencyKey
+}
+
+func (repo *IdempotencyRepository) Reserve(ctx context.Context, key *model.IdempotencyKey) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	scoped := scopedKey(key.IdempotencyKey, key.Scope)
+	if _, ok := repo.keys[scoped]; ok {
+		return false, nil
+	}
+
+	key.ID = repo.nextID("idempotency_keys")
+	stamp(&key.CreatedAt, &key.UpdatedAt)
+
+	row := *key
+	repo.keys[scoped] = &row
+
+	return true, nil
+}
+
+func (repo *IdempotencyRepository) Get(ctx context.Context, idempotencyKey string, scope string) (*model.IdempotencyKey, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.keys[scopedKey(idempotencyKey, scope)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	key := *row
+	return &key, nil
+}
+
+// byID returns the scoped key and the stored row with the id. Callers hold mu.
+func (repo *IdempotencyRepository) byID(id uint) (string, *model.IdempotencyKey) {
+	for scoped, row := range repo.keys {
+		if row.ID == id {
+			return scoped, row
+		}
+	}
+
+	return "", nil
+}
+
+func (repo *IdempotencyRepository) TakeOver(ctx context.Context, id uint, staleBefore time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	_, row := repo.byID(id)
+	if row == nil || row.IsCompleted() || !row.UpdatedAt.Before(staleBefore) {
+		return false, nil
+	}
+
+	row.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (repo *IdempotencyRepository) SaveResponse(ctx context.Context, id uint, status int, body string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, row := repo.byID(id); row != nil {
+		row.ResponseStatus = &status
+		row.ResponseBody = &body
+		row.UpdatedAt = time.Now()
+	}
+
+	return nil
+}
+
+func (repo *IdempotencyRepository) Delete(ctx context.Context, id uint) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if scoped, row := repo.byID(id); row != nil {
+		delete(repo.keys, scoped)
+	}
+
+	return nil
+}
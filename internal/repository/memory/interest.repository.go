@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+// InterestRepository keeps the accruals itself and the accrued interest on the
+// accounts of the account repository, like the columns of the accounts table
+type InterestRepository struct {
+	*Store
+	accounts *AccountRepository
+	accruals []*model.InterestAccrual
+}
+
+func NewInterestRepository(store *Store, accounts *AccountRepository) *InterestRepository {
+	return &InterestRepository{
+		Store:    store,
+		accounts: accounts,
+	}
+}
+
+func (repo *InterestRepository) ListAccountsDue(ctx context.Context, accountType model.AccountType, through time.Time) ([]string, error) {
+	return repo.accounts.listNumbers(func(row *model.Account) bool {
+		return row.AccountType == accountType && row.AccountStatus != model.AccountClosed &&
+			(row.InterestAccruedThrough == nil || row.InterestAccruedThrough.Before(through))
+	}), nil
+}
+
+// CreateAccruals skips the days the account already accrued
+func (repo *InterestRepository) CreateAccruals(ctx context.Context, accruals []model.InterestAccrual, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i := range accruals {
+		accrual := &accruals[i]
+		if repo.accrued(accrual.AccountNumber, accrual.AccrualDate) {
+			continue
+		}
+
+		accrual.ID = repo.nextID("interest_accruals")
+		stamp(&accrual.CreatedAt, &accrual.UpdatedAt)
+
+		row := *accrual
+		repo.accruals = append(repo.accruals, &row)
+		onRollback(txOf(tx), func() {
+			for i, stored := range repo.accruals {
+				if stored.ID == row.ID {
+					repo.accruals = append(repo.accruals[:i], repo.accruals[i+1:]...)
+					return
+				}
+			}
+		})
+	}
+
+	return nil
+}
+
+// accrued reports whether the account has an accrual for the day. Callers hold mu.
+func (repo *InterestRepository) accrued(accountNumber string, day time.Time) bool {
+	for _, row := range repo.accruals {
+		if row.AccountNumber == accountNumber && row.AccrualDate.Equal(day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (repo *InterestRepository) UpdateAccrued(ctx context.Context, accountNumber string, accrued decimal.Decimal, through time.Time, tx repository.Tx) error {
+	return repo.accounts.update(accountNumber, tx, func(row *model.Account) {
+		row.AccruedInterest = accrued
+		row.InterestAccruedThrough = &through
+	})
+}
+
+func (repo *InterestRepository) ListAccruals(ctx context.Context, accountNumber string, limit int) ([]model.InterestAccrual, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	accruals := []model.InterestAccrual{}
+	for _, row := range repo.accruals {
+		if row.AccountNumber == accountNumber {
+			accruals = append(accruals, *row)
+		}
+	}
+
+	sort.SliceStable(accruals, func(i, j int) bool { return accruals[i].AccrualDate.After(accruals[j].AccrualDate) })
+	if limit > 0 && len(accruals) > limit {
+		accruals = accruals[:limit]
+	}
+
+	return accruals, nil
+}
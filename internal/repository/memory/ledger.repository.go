@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type LedgerRepository struct {
+	*Store
+	entries   []*model.JournalEntry
+	snapshots []model.BalanceSnapshot
+}
+
+func NewLedgerRepository(store *Store) *LedgerRepository {
+	return &LedgerRepository{
+		Store: store,
+	}
+}
+
+func (repo *LedgerRepository) CreateEntry(ctx context.Context, entry *model.JournalEntry, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.find(entry.TransactionId) != nil {
+		return fmt.Errorf("failed to create journal entry: %w", gorm.ErrDuplicatedKey)
+	}
+
+	entry.ID = repo.nextID("journal_entries")
+	stamp(&entry.CreatedAt, &entry.UpdatedAt)
+	for i := range entry.Postings {
+		entry.Postings[i].ID = repo.nextID("postings")
+		entry.Postings[i].JournalEntryID = entry.ID
+		stamp(&entry.Postings[i].CreatedAt, &entry.Postings[i].UpdatedAt)
+	}
+
+	row := copyEntry(entry)
+	repo.entries = append(repo.entries, row)
+	onRollback(txOf(tx), func() {
+		for i, stored := range repo.entries {
+			if stored == row {
+				repo.entries = append(repo.entries[:i], repo.entries[i+1:]...)
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func copyEntry(entry *model.JournalEntry) *model.JournalEntry {
+	copied := *entry
+	copied.Postings = append([]model.Posting{}, entry.Postings...)
+	return &copied
+}
+
+// find returns the stored entry of the transaction. Callers hold mu.
+func (repo *LedgerRepository) find(transactionID string) *model.JournalEntry {
+	for _, entry := range repo.entries {
+		if entry.TransactionId == transactionID {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// postings returns the matching postings along with their entry, oldest first. Callers hold mu.
+func (repo *LedgerRepository) postings(match func(posting *model.Posting) bool) ([]model.Posting, []*model.JournalEntry) {
+	var postings []model.Posting
+	var entries []*model.JournalEntry
+
+	for _, entry := range repo.entries {
+		for i := range entry.Postings {
+			if match(&entry.Postings[i]) {
+				postings = append(postings, entry.Postings[i])
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return postings, entries
+}
+
+func (repo *LedgerRepository) sum(match func(posting *model.Posting) bool) decimal.Decimal {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	sum := decimal.Zero
+	postings, _ := repo.postings(match)
+	for _, posting := range postings {
+		sum = sum.Add(posting.Amount)
+	}
+
+	return sum
+}
+
+func (repo *LedgerRepository) ExistsByTransactionID(ctx context.Context, transactionID string, tx repository.Tx) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.find(transactionID) != nil, nil
+}
+
+func (repo *LedgerRepository) GetEntryByTransactionID(ctx context.Context, transactionID string, tx repository.Tx) (*model.JournalEntry, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	entry := repo.find(transactionID)
+	if entry == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return copyEntry(entry), nil
+}
+
+func (repo *LedgerRepository) SumReversedAmount(ctx context.Context, transactionID string, tx repository.Tx) (decimal.Decimal, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	sum := decimal.Zero
+	for _, entry := range repo.entries {
+		if entry.ReversesTransactionId == transactionID {
+			sum = sum.Add(entry.Amount)
+		}
+	}
+
+	return sum, nil
+}
+
+func (repo *LedgerRepository) GetPostingsByAccount(ctx context.Context, accountNumber string, limit int, offset int) ([]model.Posting, int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postings, _ := repo.postings(func(posting *model.Posting) bool {
+		return posting.AccountNumber == accountNumber
+	})
+	total := int64(len(postings))
+
+	sort.Slice(postings, func(i, j int) bool { return postings[i].ID > postings[j].ID })
+	if offset >= len(postings) {
+		return []model.Posting{}, total, nil
+	}
+	postings = postings[offset:]
+	if limit < len(postings) {
+		postings = postings[:limit]
+	}
+
+	return postings, total, nil
+}
+
+func (repo *LedgerRepository) SumByAccount(ctx context.Context, accountNumber string, tx repository.Tx) (decimal.Decimal, error) {
+	return repo.sum(func(posting *model.Posting) bool {
+		return posting.AccountNumber == accountNumber
+	}), nil
+}
+
+func (repo *LedgerRepository) ListLines(ctx context.Context, accountNumber string, from time.Time, to time.Time) ([]model.StatementLine, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	postings, entries := repo.postings(func(posting *model.Posting) bool {
+		return posting.AccountNumber == accountNumber && !posting.CreatedAt.Before(from) && posting.CreatedAt.Before(to)
+	})
+
+	lines := make([]model.StatementLine, len(postings))
+	for i, posting := range postings {
+		lines[i] = model.StatementLine{
+			Date:            posting.CreatedAt,
+			TransactionId:   entries[i].TransactionId,
+			TransactionType: entries[i].TransactionType,
+			Description:     entries[i].Description,
+			Amount:          posting.Amount,
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Date.Before(lines[j].Date) })
+	return lines, nil
+}
+
+func (repo *LedgerRepository) SumByAccountBetween(ctx context.Context, accountNumber string, from time.Time, before time.Time) (decimal.Decimal, error) {
+	return repo.sum(func(posting *model.Posting) bool {
+		return posting.AccountNumber == accountNumber && !posting.CreatedAt.Before(from) && posting.CreatedAt.Before(before)
+	}), nil
+}
+
+func (repo *LedgerRepository) GetLatestSnapshot(ctx context.Context, accountNumber string, at time.Time) (*model.BalanceSnapshot, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var latest *model.BalanceSnapshot
+	for i := range repo.snapshots {
+		snapshot := &repo.snapshots[i]
+		if snapshot.AccountNumber != accountNumber || snapshot.AsOf.After(at) {
+			continue
+		}
+
+		if latest == nil || snapshot.AsOf.After(latest.AsOf) {
+			latest = snapshot
+		}
+	}
+
+	if latest == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	snapshot := *latest
+	return &snapshot, nil
+}
+
+func (repo *LedgerRepository) ListAccountsToSnapshot(ctx context.Context, before time.Time) ([]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	latest := map[string]time.Time{}
+	for _, snapshot := range repo.snapshots {
+		if snapshot.AsOf.After(latest[snapshot.AccountNumber]) {
+			latest[snapshot.AccountNumber] = snapshot.AsOf
+		}
+	}
+
+	postings, _ := repo.postings(func(posting *model.Posting) bool {
+		asOf, ok := latest[posting.AccountNumber]
+		return posting.CreatedAt.Before(before) && (!ok || !posting.CreatedAt.Before(asOf))
+	})
+
+	seen := map[string]bool{}
+	accountNumbers := []string{}
+	for _, posting := range postings {
+		if !seen[posting.AccountNumber] {
+			seen[posting.AccountNumber] = true
+			accountNumbers = append(accountNumbers, posting.AccountNumber)
+		}
+	}
+	sort.Strings(accountNumbers)
+
+	return accountNumbers, nil
+}
+
+func (repo *LedgerRepository) CreateSnapshot(ctx context.Context, snapshot *model.BalanceSnapshot) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, existing := range repo.snapshots {
+		if existing.AccountNumber == snapshot.AccountNumber && existing.AsOf.Equal(snapshot.AsOf) {
+			return nil
+		}
+	}
+
+	snapshot.ID = repo.nextID("balance_snapshots")
+	stamp(&snapshot.CreatedAt, &snapshot.UpdatedAt)
+	repo.snapshots = append(repo.snapshots, *snapshot)
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository struct {
+	*Store
+	messages []*model.OutboxMessage
+}
+
+func NewOutboxRepository(store *Store) *OutboxRepository {
+	return &OutboxRepository{
+		Store: store,
+	}
+}
+
+func (repo *OutboxRepository) Create(ctx context.Context, message *model.OutboxMessage, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, existing := range repo.messages {
+		if existing.TransactionId == message.TransactionId {
+			return fmt.Errorf("failed to write outbox message: %w", gorm.ErrDuplicatedKey)
+		}
+	}
+
+	message.ID = repo.nextID("outbox_messages")
+	stamp(&message.CreatedAt, &message.UpdatedAt)
+
+	row := *message
+	repo.messages = append(repo.messages, &row)
+	onRollback(txOf(tx), func() {
+		for i, stored := range repo.messages {
+			if stored == &row {
+				repo.messages = append(repo.messages[:i], repo.messages[i+1:]...)
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// ClaimPending leases up to limit pending messages until leaseUntil, skipping
+// the ones another relay has leased or another transaction has locked
+func (repo *OutboxRepository) ClaimPending(ctx context.Context, limit int, leaseUntil time.Time, tx repository.Tx) ([]model.OutboxMessage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	messages := []model.OutboxMessage{}
+	for _, row := range repo.messages {
+		if len(messages) == limit {
+			break
+		}
+
+		if row.Status != model.OutboxStatusPending || (row.ClaimedUntil != nil && row.ClaimedUntil.After(now)) {
+			continue
+		}
+
+		if !repo.tryLock(txOf(tx), fmt.Sprintf("outbox_messages/%d", row.ID)) {
+			continue
+		}
+
+		keep(txOf(tx), row)
+		claimedUntil := leaseUntil
+		row.ClaimedUntil = &claimedUntil
+		messages = append(messages, *row)
+	}
+
+	return messages, nil
+}
+
+func (repo *OutboxRepository) update(id uint, tx repository.Tx, apply func(row *model.OutboxMessage)) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, row := range repo.messages {
+		if row.ID == id {
+			keep(txOf(tx), row)
+			apply(row)
+			row.UpdatedAt = time.Now()
+		}
+	}
+
+	return nil
+}
+
+func (repo *OutboxRepository) MarkSent(ctx context.Context, id uint, tx repository.Tx) error {
+	return repo.update(id, tx, func(row *model.OutboxMessage) {
+		now := time.Now()
+		row.Status = model.OutboxStatusSent
+		row.SentAt = &now
+		row.ClaimedUntil = nil
+	})
+}
+
+func (repo *OutboxRepository) RecordFailure(ctx context.Context, id uint, attempts int, status model.OutboxStatus, lastError string, retryAt time.Time, tx repository.Tx) error {
+	return repo.update(id, tx, func(row *model.OutboxMessage) {
+		row.Status = status
+		row.Attempts = attempts
+		row.LastError = lastError
+		row.ClaimedUntil = &retryAt
+	})
+}
+
+func (repo *OutboxRepository) HasPending(ctx context.Context, transactionID string) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, row := range repo.messages {
+		if row.TransactionId == transactionID && row.Status == model.OutboxStatusPending {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
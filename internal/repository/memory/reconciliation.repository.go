@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+)
+
+// ReconciliationRepository reads the accounts and the ledger of its store.
+// Both are read under the store's mutex, so the read is a consistent snapshot.
+type ReconciliationRepository struct {
+	*Store
+	accounts *AccountRepository
+	ledger   *LedgerRepository
+}
+
+func NewReconciliationRepository(store *Store, accounts *AccountRepository, ledger *LedgerRepository) *ReconciliationRepository {
+	return &ReconciliationRepository{
+		Store:    store,
+		accounts: accounts,
+		ledger:   ledger,
+	}
+}
+
+func (repo *ReconciliationRepository) LedgerStartedAt(ctx context.Context) (time.Time, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if len(repo.ledger.entries) == 0 {
+		return time.Time{}, nil
+	}
+
+	return repo.ledger.entries[0].CreatedAt, nil
+}
+
+func (repo *ReconciliationRepository) ReadAccount(ctx context.Context, accountNumber string) (*model.Account, []repository.TransactionPosting, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	account, err := repo.accounts.get(accountNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postings, entries := repo.ledger.postings(func(posting *model.Posting) bool {
+		return posting.AccountNumber == accountNumber
+	})
+
+	byTransaction := map[string]*repository.TransactionPosting{}
+	for i, posting := range postings {
+		transactionID := entries[i].TransactionId
+		net, ok := byTransaction[transactionID]
+		if !ok {
+			net = &repository.TransactionPosting{TransactionId: transactionID}
+			byTransaction[transactionID] = net
+		}
+
+		net.Amount = net.Amount.Add(posting.Amount)
+		net.Migrated = net.Migrated || entries[i].Description == model.MigratedOpeningDescription
+		if posting.CreatedAt.After(net.PostedAt) {
+			net.PostedAt = posting.CreatedAt
+		}
+	}
+
+	transactionPostings := make([]repository.TransactionPosting, 0, len(byTransaction))
+	for _, net := range byTransaction {
+		transactionPostings = append(transactionPostings, *net)
+	}
+	sort.Slice(transactionPostings, func(i, j int) bool {
+		return transactionPostings[i].TransactionId < transactionPostings[j].TransactionId
+	})
+
+	return account, transactionPostings, nil
+}
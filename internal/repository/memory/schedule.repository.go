@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type ScheduleRepository struct {
+	*Store
+	schedules map[string]*model.Schedule
+	runs      []*model.ScheduleRun
+}
+
+func NewScheduleRepository(store *Store) *ScheduleRepository {
+	return &ScheduleRepository{
+		Store:     store,
+		schedules: map[string]*model.Schedule{},
+	}
+}
+
+func (repo *ScheduleRepository) Create(ctx context.Context, schedule *model.Schedule) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.schedules[schedule.ScheduleId]; ok {
+		return fmt.Errorf("failed to create schedule: %w", gorm.ErrDuplicatedKey)
+	}
+
+	schedule.ID = repo.nextID("schedules")
+	stamp(&schedule.CreatedAt, &schedule.UpdatedAt)
+
+	row := *schedule
+	repo.schedules[schedule.ScheduleId] = &row
+
+	return nil
+}
+
+func (repo *ScheduleRepository) GetByScheduleID(ctx context.Context, scheduleID string) (*model.Schedule, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.schedules[scheduleID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	schedule := *row
+	return &schedule, nil
+}
+
+// GetForUpdate waits for the schedule's lock and holds it until tx ends
+func (repo *ScheduleRepository) GetForUpdate(ctx context.Context, scheduleID string, tx repository.Tx) (*model.Schedule, error) {
+	if err := repo.lock(ctx, txOf(tx), "schedules/"+scheduleID); err != nil {
+		return nil, err
+	}
+
+	return repo.GetByScheduleID(ctx, scheduleID)
+}
+
+// list returns copies of the matching schedules, newest first. Callers hold mu.
+func (repo *ScheduleRepository) list(match func(schedule *model.Schedule) bool) []model.Schedule {
+	schedules := []model.Schedule{}
+	for _, row := range repo.schedules {
+		if match(row) {
+			schedules = append(schedules, *row)
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID > schedules[j].ID })
+	return schedules
+}
+
+func (repo *ScheduleRepository) List(ctx context.Context, accountNumber string, status string) ([]model.Schedule, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return repo.list(func(schedule *model.Schedule) bool {
+		return (accountNumber == "" || schedule.FromAccountNumber == accountNumber || schedule.ToAccountNumber == accountNumber) &&
+			(status == "" || string(schedule.Status) == status)
+	}), nil
+}
+
+func (repo *ScheduleRepository) Save(ctx context.Context, schedule *model.Schedule, tx repository.Tx) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.schedules[schedule.ScheduleId]
+	if !ok {
+		return fmt.Errorf("failed to save schedule: %w", gorm.ErrRecordNotFound)
+	}
+
+	keep(txOf(tx), row)
+	schedule.UpdatedAt = time.Now()
+	*row = *schedule
+
+	return nil
+}
+
+func isDue(schedule *model.Schedule, now time.Time) bool {
+	return schedule.Status == model.ScheduleActive && schedule.NextRunAt != nil && !schedule.NextRunAt.After(now)
+}
+
+func (repo *ScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	due := repo.list(func(schedule *model.Schedule) bool { return isDue(schedule, now) })
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextRunAt.Before(*due[j].NextRunAt) })
+
+	scheduleIDs := []string{}
+	for _, schedule := range due {
+		if len(scheduleIDs) == limit {
+			break
+		}
+		scheduleIDs = append(scheduleIDs, schedule.ScheduleId)
+	}
+
+	return scheduleIDs, nil
+}
+
+func (repo *ScheduleRepository) ClaimDue(ctx context.Context, scheduleID string, now time.Time, tx repository.Tx) (*model.Schedule, error) {
+	if !repo.tryLock(txOf(tx), "schedules/"+scheduleID) {
+		return nil, nil
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.schedules[scheduleID]
+	if !ok || !isDue(row, now) {
+		return nil, nil
+	}
+
+	schedule := *row
+	return &schedule, nil
+}
+
+func (repo *ScheduleRepository) CreateRun(ctx context.Context, run *model.ScheduleRun, tx repository.Tx) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, row := range repo.runs {
+		if row.ScheduleId == run.ScheduleId && row.ScheduledFor.Equal(run.ScheduledFor) {
+			return false, nil
+		}
+	}
+
+	run.ID = repo.nextID("schedule_runs")
+	stamp(&run.CreatedAt, &run.UpdatedAt)
+
+	row := *run
+	repo.runs = append(repo.runs, &row)
+	onRollback(txOf(tx), func() {
+		for i, stored := range repo.runs {
+			if stored.ID == row.ID {
+				repo.runs = append(repo.runs[:i], repo.runs[i+1:]...)
+				return
+			}
+		}
+	})
+
+	return true, nil
+}
+
+func (repo *ScheduleRepository) ListRuns(ctx context.Context, scheduleID string, limit int) ([]model.ScheduleRun, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	runs := []model.ScheduleRun{}
+	for _, row := range repo.runs {
+		if row.ScheduleId == scheduleID {
+			runs = append(runs, *row)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].ScheduledFor.After(runs[j].ScheduledFor) })
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	return runs, nil
+}
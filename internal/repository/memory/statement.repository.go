@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"golang-exercise/internal/database/model"
+
+	"gorm.io/gorm"
+)
+
+type StatementRepository struct {
+	*Store
+	statements map[string]*model.Statement
+}
+
+func NewStatementRepository(store *Store) *StatementRepository {
+	return &StatementRepository{
+		Store:      store,
+		statements: map[string]*model.Statement{},
+	}
+}
+
+func statementKey(accountNumber string, period string) string {
+	return accountNumber + "/" + period
+}
+
+func (repo *StatementRepository) Create(ctx context.Context, statement *model.Statement) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := statementKey(statement.AccountNumber, statement.Period)
+	if _, ok := repo.statements[key]; ok {
+		return false, nil
+	}
+
+	statement.ID = repo.nextID("statements")
+	stamp(&statement.CreatedAt, &statement.UpdatedAt)
+
+	row := *statement
+	row.Lines = append([]model.StatementLine{}, statement.Lines...)
+	repo.statements[key] = &row
+
+	return true, nil
+}
+
+func (repo *StatementRepository) GetByPeriod(ctx context.Context, accountNumber string, period string) (*model.Statement, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row, ok := repo.statements[statementKey(accountNumber, period)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	statement := *row
+	statement.Lines = append([]model.StatementLine{}, row.Lines...)
+	return &statement, nil
+}
+
+func (repo *StatementRepository) ListByAccount(ctx context.Context, accountNumber string) ([]model.Statement, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	statements := []model.Statement{}
+	for _, row := range repo.statements {
+		if row.AccountNumber == accountNumber {
+			statement := *row
+			statement.Lines = nil
+			statements = append(statements, statement)
+		}
+	}
+
+	sort.Slice(statements, func(i, j int) bool { return statements[i].Period > statements[j].Period })
+	return statements, nil
+}
@@ -0,0 +1,192 @@
+// Package memory implements the repository stores in process, so the service
+// layer and the handlers can run in tests without Postgres and Mongo.
+//
+// Transactions keep the semantics the services rely on: rows locked through a
+// transaction stay locked until it commits or rolls back, and a rollback undoes
+// every write made through it. Isolation is not modelled, uncommitted writes
+// are visible to other readers right away.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"golang-exercise/internal/repository"
+)
+
+// Store holds the rows of every repository created on it. Repositories of one
+// store share its transactions, like the Postgres ones share a database.
+type Store struct {
+	mu  sync.Mutex
+	ids map[string]uint
+
+	locksMu sync.Mutex
+	locks   map[string]*rowLock
+}
+
+type rowLock struct {
+	owner    *transaction
+	released chan struct{}
+}
+
+func NewStore() *Store {
+	return &Store{
+		ids:   map[string]uint{},
+		locks: map[string]*rowLock{},
+	}
+}
+
+type transaction struct {
+	store *Store
+	undo  []func()
+	locks []string
+	done  bool
+}
+
+func (store *Store) Begin(ctx context.Context) (repository.Tx, error) {
+	return &transaction{store: store}, nil
+}
+
+func (tx *transaction) Commit() error {
+	return tx.finish(false)
+}
+
+func (tx *transaction) Rollback() error {
+	return tx.finish(true)
+}
+
+func (tx *transaction) finish(rollback bool) error {
+	tx.store.mu.Lock()
+	if tx.done {
+		tx.store.mu.Unlock()
+		return sql.ErrTxDone
+	}
+
+	tx.done = true
+	if rollback {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+	}
+	tx.undo = nil
+	tx.store.mu.Unlock()
+
+	tx.store.unlockAll(tx)
+	return nil
+}
+
+// txOf returns the transaction behind tx, nil outside of one
+func txOf(tx repository.Tx) *transaction {
+	if tx == nil {
+		return nil
+	}
+
+	return tx.(*transaction)
+}
+
+// onRollback registers undo to run if tx rolls back. Callers hold store.mu.
+func onRollback(tx *transaction, undo func()) {
+	if tx != nil {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+// keep restores *row to its current value if tx rolls back. Callers hold store.mu.
+func keep[T any](tx *transaction, row *T) {
+	before := *row
+	onRollback(tx, func() { *row = before })
+}
+
+// nextID hands out increasing ids per table, like a serial column
+func (store *Store) nextID(table string) uint {
+	store.ids[table]++
+	return store.ids[table]
+}
+
+// lock waits until no other transaction holds key and takes it for tx. Outside
+// of a transaction nothing is locked, as the lock would end with the statement.
+func (store *Store) lock(ctx context.Context, tx *transaction, key string) error {
+	if tx == nil {
+		return nil
+	}
+
+	for {
+		store.locksMu.Lock()
+		held, ok := store.locks[key]
+		if !ok {
+			store.locks[key] = &rowLock{owner: tx, released: make(chan struct{})}
+			tx.locks = append(tx.locks, key)
+			store.locksMu.Unlock()
+			return nil
+		}
+		store.locksMu.Unlock()
+
+		if held.owner == tx {
+			return nil
+		}
+
+		select {
+		case <-held.released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// tryLock takes key for tx unless another transaction holds it (SKIP LOCKED)
+func (store *Store) tryLock(tx *transaction, key string) bool {
+	if tx == nil {
+		return true
+	}
+
+	store.locksMu.Lock()
+	defer store.locksMu.Unlock()
+
+	if held, ok := store.locks[key]; ok {
+		return held.owner == tx
+	}
+
+	store.locks[key] = &rowLock{owner: tx, released: make(chan struct{})}
+	tx.locks = append(tx.locks, key)
+	return true
+}
+
+func (store *Store) unlockAll(tx *transaction) {
+	store.locksMu.Lock()
+	defer store.locksMu.Unlock()
+
+	for _, key := range tx.locks {
+		if held, ok := store.locks[key]; ok && held.owner == tx {
+			delete(store.locks, key)
+			close(held.released)
+		}
+	}
+	tx.locks = nil
+}
+
+// stamp sets the timestamps gorm fills in on create
+func stamp(createdAt *time.Time, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	*updatedAt = now
+}
+
+var (
+	_ repository.AccountStore        = (*AccountRepository)(nil)
+	_ repository.TransactionLogStore = (*TransactionLogRepository)(nil)
+	_ repository.LedgerStore         = (*LedgerRepository)(nil)
+	_ repository.HoldStore           = (*HoldRepository)(nil)
+	_ repository.FeeStore            = (*FeeRepository)(nil)
+	_ repository.OutboxStore         = (*OutboxRepository)(nil)
+	_ repository.BatchStore          = (*BatchRepository)(nil)
+	_ repository.FxRateStore         = (*FxRateRepository)(nil)
+	_ repository.IdempotencyStore    = (*IdempotencyRepository)(nil)
+	_ repository.InterestStore       = (*InterestRepository)(nil)
+	_ repository.ScheduleStore       = (*ScheduleRepository)(nil)
+	_ repository.StatementStore      = (*StatementRepository)(nil)
+	_ repository.ReconciliationStore = (*ReconciliationRepository)(nil)
+)
@@ -0,0 +1,284 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"golang-exercise/internal/database/model"
+
+	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransactionLogRepository stands in for the Mongo collection, which takes
+// part in no transaction
+type TransactionLogRepository struct {
+	*Store
+	logs []*model.TransactionLog
+}
+
+func NewTransactionLogRepository(store *Store) *TransactionLogRepository {
+	return &TransactionLogRepository{
+		Store: store,
+	}
+}
+
+func (repo *TransactionLogRepository) Create(ctx context.Context, txLog *model.TransactionLog) error {
+	txLog.ID = primitive.NewObjectID()
+	txLog.Timestamp = time.Now()
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := *txLog
+	repo.logs = append(repo.logs, &row)
+	return nil
+}
+
+func (repo *TransactionLogRepository) CreateIfNotExists(ctx context.Context, txLog *model.TransactionLog) error {
+	if txLog.ID.IsZero() {
+		txLog.ID = primitive.NewObjectID()
+	}
+
+	if txLog.Timestamp.IsZero() {
+		txLog.Timestamp = time.Now()
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.find(txLog.TransactionId) != nil {
+		return nil
+	}
+
+	row := *txLog
+	repo.logs = append(repo.logs, &row)
+	return nil
+}
+
+// find returns the stored log of the transaction. Callers hold mu.
+func (repo *TransactionLogRepository) find(transactionID string) *model.TransactionLog {
+	for _, row := range repo.logs {
+		if row.TransactionId == transactionID {
+			return row
+		}
+	}
+
+	return nil
+}
+
+// list returns copies of the matching logs, newest first. Callers hold mu.
+func (repo *TransactionLogRepository) list(match func(txLog *model.TransactionLog) bool) []model.TransactionLog {
+	logs := []model.TransactionLog{}
+	for _, row := range repo.logs {
+		if match(row) {
+			logs = append(logs, *row)
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].Timestamp.After(logs[j].Timestamp) })
+	return logs
+}
+
+// page applies skip and limit the way Mongo does, a limit of zero means all
+func page(logs []model.TransactionLog, limit int64, offset int64) []model.TransactionLog {
+	if offset >= int64(len(logs)) {
+		return []model.TransactionLog{}
+	}
+	logs = logs[offset:]
+
+	if limit > 0 && limit < int64(len(logs)) {
+		logs = logs[:limit]
+	}
+
+	return logs
+}
+
+func involves(accountID uint) func(txLog *model.TransactionLog) bool {
+	return func(txLog *model.TransactionLog) bool {
+		return txLog.FromAccountId == accountID || txLog.ToAccountId == accountID
+	}
+}
+
+func (repo *TransactionLogRepository) GetByAccountID(ctx context.Context, accountID uint, limit int64) ([]model.TransactionLog, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return page(repo.list(involves(accountID)), limit, 0), nil
+}
+
+func (repo *TransactionLogRepository) GetByTransactionID(ctx context.Context, transactionID string) (*model.TransactionLog, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.find(transactionID)
+	if row == nil {
+		return nil, nil
+	}
+
+	txLog := *row
+	return &txLog, nil
+}
+
+func (repo *TransactionLogRepository) GetByTransactionIDs(ctx context.Context, transactionIDs []string) ([]model.TransactionLog, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	logs := []model.TransactionLog{}
+	for _, transactionID := range transactionIDs {
+		if row := repo.find(transactionID); row != nil {
+			logs = append(logs, *row)
+		}
+	}
+
+	return logs, nil
+}
+
+func (repo *TransactionLogRepository) update(transactionID string, apply func(row *model.TransactionLog)) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// Like UpdateOne, nothing happens when there is no such log
+	if row := repo.find(transactionID); row != nil {
+		apply(row)
+	}
+
+	return nil
+}
+
+func (repo *TransactionLogRepository) UpdateStatus(ctx context.Context, transactionID string, status model.TransactionStatus) error {
+	return repo.update(transactionID, func(row *model.TransactionLog) {
+		now := time.Now()
+		row.Status = status
+		row.ProcessedAt = &now
+
+		// The reason of an earlier failed attempt no longer applies
+		if status == model.TransactionStatusCompleted {
+			row.FailureReason = ""
+		}
+	})
+}
+
+func (repo *TransactionLogRepository) MarkFailed(ctx context.Context, transactionID string, reason string) error {
+	return repo.update(transactionID, func(row *model.TransactionLog) {
+		now := time.Now()
+		row.Status = model.TransactionStatusFailed
+		row.FailureReason = reason
+		row.ProcessedAt = &now
+	})
+}
+
+func (repo *TransactionLogRepository) RecordAttempt(ctx context.Context, transactionID string, retryCount int, status model.TransactionStatus, reason string) error {
+	return repo.update(transactionID, func(row *model.TransactionLog) {
+		row.Status = status
+		row.RetryCount = retryCount
+		row.FailureReason = reason
+	})
+}
+
+func (repo *TransactionLogRepository) LinkFee(ctx context.Context, transactionID string, feeTransactionID string, feeAmount decimal.Decimal) error {
+	return repo.update(transactionID, func(row *model.TransactionLog) {
+		row.FeeTransactionId = feeTransactionID
+		row.FeeAmount = &feeAmount
+	})
+}
+
+func (repo *TransactionLogRepository) AddReversal(ctx context.Context, transactionID string, reversalID string, reversedAmount decimal.Decimal) error {
+	return repo.update(transactionID, func(row *model.TransactionLog) {
+		for _, id := range row.ReversalIds {
+			if id == reversalID {
+				row.ReversedAmount = reversedAmount
+				return
+			}
+		}
+
+		row.ReversalIds = append(append([]string{}, row.ReversalIds...), reversalID)
+		row.ReversedAmount = reversedAmount
+	})
+}
+
+func (repo *TransactionLogRepository) GetByStatus(ctx context.Context, status string, limit int64) ([]model.TransactionLog, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return page(repo.list(func(txLog *model.TransactionLog) bool {
+		return string(txLog.Status) == status
+	}), limit, 0), nil
+}
+
+func (repo *TransactionLogRepository) GetAll(ctx context.Context, limit int64, offset int64) ([]model.TransactionLog, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	return page(repo.list(func(*model.TransactionLog) bool { return true }), limit, offset), nil
+}
+
+func (repo *TransactionLogRepository) GetTransactionHistory(ctx context.Context, accountID uint, limit int, offset int, startDate, endDate, status string) ([]model.TransactionLog, int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	logs := repo.list(historyFilter(accountID, startDate, endDate, status))
+
+	return page(logs, int64(limit), int64(offset)), len(logs), nil
+}
+
+// StreamTransactionHistory calls fn without holding the lock, so fn may use the store
+func (repo *TransactionLogRepository) StreamTransactionHistory(ctx context.Context, accountID uint, startDate, endDate, status string, fn func(txLog *model.TransactionLog) error) error {
+	repo.mu.Lock()
+	logs := repo.list(historyFilter(accountID, startDate, endDate, status))
+	repo.mu.Unlock()
+
+	for i := range logs {
+		if err := fn(&logs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// historyFilter matches the logs of the account the history filters select
+func historyFilter(accountID uint, startDate, endDate, status string) func(txLog *model.TransactionLog) bool {
+	var from, to *time.Time
+	if startTime, err := time.Parse("2006-01-02", startDate); startDate != "" && err == nil {
+		from = &startTime
+	}
+	if endTime, err := time.Parse("2006-01-02", endDate); endDate != "" && err == nil {
+		endOfDay := endTime.Add(24 * time.Hour)
+		to = &endOfDay
+	}
+
+	isInvolved := involves(accountID)
+
+	return func(txLog *model.TransactionLog) bool {
+		switch {
+		case !isInvolved(txLog):
+			return false
+		case from != nil && txLog.Timestamp.Before(*from):
+			return false
+		case to != nil && txLog.Timestamp.After(*to):
+			return false
+		case status != "" && string(txLog.Status) != status:
+			return false
+		}
+
+		return true
+	}
+}
+
+// StreamByAccountID calls fn without holding the lock, so fn may use the store
+func (repo *TransactionLogRepository) StreamByAccountID(ctx context.Context, accountID uint, fn func(txLog *model.TransactionLog) error) error {
+	repo.mu.Lock()
+	logs := repo.list(involves(accountID))
+	repo.mu.Unlock()
+
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].Timestamp.Before(logs[j].Timestamp) })
+	for i := range logs {
+		if err := fn(&logs[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
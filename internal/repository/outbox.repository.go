@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewOutboxRepositoryWithDB(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+func (repo *OutboxRepository) GetDB() *gorm.DB {
+	return repo.db
+}
+
+func (repo *OutboxRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *OutboxRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+func (repo *OutboxRepository) Create(ctx context.Context, message *model.OutboxMessage, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).Create(message)
+	if result.Error != nil {
+		return fmt.Errorf("failed to write outbox message: %w", result.Error)
+	}
+
+	return nil
+}
+
+// ClaimPending leases up to limit pending messages until leaseUntil. Rows
+// leased or locked by another relay are skipped so several relays can run side
+// by side, and a lease left by a relay that died expires on its own.
+func (repo *OutboxRepository) ClaimPending(ctx context.Context, limit int, leaseUntil time.Time, tx Tx) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND (claimed_until IS NULL OR claimed_until <= ?)", model.OutboxStatusPending, time.Now()).
+		Order("id").
+		Limit(limit).
+		Find(&messages)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", result.Error)
+	}
+
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	ids := make([]uint, 0, len(messages))
+	for i := range messages {
+		ids = append(ids, messages[i].ID)
+		messages[i].ClaimedUntil = &leaseUntil
+	}
+
+	result = repo.conn(tx).WithContext(ctx).
+		Model(&model.OutboxMessage{}).
+		Where("id IN ?", ids).
+		Update("claimed_until", leaseUntil)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", result.Error)
+	}
+
+	return messages, nil
+}
+
+func (repo *OutboxRepository) MarkSent(ctx context.Context, id uint, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).
+		Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":        model.OutboxStatusSent,
+			"sent_at":       time.Now(),
+			"claimed_until": nil,
+		})
+
+	return result.Error
+}
+
+func (repo *OutboxRepository) RecordFailure(ctx context.Context, id uint, attempts int, status model.OutboxStatus, lastError string, retryAt time.Time, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).
+		Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":        status,
+			"attempts":      attempts,
+			"last_error":    lastError,
+			"claimed_until": retryAt,
+		})
+
+	return result.Error
+}
+
+// HasPending reports whether the transaction still waits in the outbox to be published
+func (repo *OutboxRepository) HasPending(ctx context.Context, transactionID string) (bool, error) {
+	var count int64
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.OutboxMessage{}).
+		Where("transaction_id = ? AND status = ?", transactionID, model.OutboxStatusPending).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
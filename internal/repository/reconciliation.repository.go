@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReconciliationRepository reads what the reconciliation compares against the
+// transaction logs
+type ReconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository() *ReconciliationRepository {
+	return &ReconciliationRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewReconciliationRepositoryWithDB(db *gorm.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{
+		db: db,
+	}
+}
+
+func (repo *ReconciliationRepository) LedgerStartedAt(ctx context.Context) (time.Time, error) {
+	var startedAt []time.Time
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.JournalEntry{}).
+		Order("id").
+		Limit(1).
+		Pluck("created_at", &startedAt)
+
+	if result.Error != nil {
+		return time.Time{}, fmt.Errorf("failed to read the first journal entry: %w", result.Error)
+	}
+
+	if len(startedAt) == 0 {
+		return time.Time{}, nil
+	}
+
+	return startedAt[0], nil
+}
+
+// ReadAccount reads the balance and the postings in one read-only repeatable
+// read transaction, so a concurrent commit can't split them
+func (repo *ReconciliationRepository) ReadAccount(ctx context.Context, accountNumber string) (*model.Account, []TransactionPosting, error) {
+	var account *model.Account
+	var postings []TransactionPosting
+
+	err := repo.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		account, err = NewAccountRepositoryWithDB(tx).GetByAccountNumber(ctx, accountNumber)
+		if err != nil {
+			return err
+		}
+
+		postings, err = NewLedgerRepositoryWithDB(tx).ListTransactionPostings(ctx, accountNumber, nil)
+		return err
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return account, postings, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository() *ScheduleRepository {
+	return &ScheduleRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewScheduleRepositoryWithDB(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{
+		db: db,
+	}
+}
+
+func (repo *ScheduleRepository) GetDB() *gorm.DB {
+	return repo.db
+}
+
+func (repo *ScheduleRepository) Begin(ctx context.Context) (Tx, error) {
+	return gormTransactor{db: repo.db}.Begin(ctx)
+}
+
+func (repo *ScheduleRepository) conn(tx Tx) *gorm.DB {
+	return gormConn(tx, repo.db)
+}
+
+func (repo *ScheduleRepository) Create(ctx context.Context, schedule *model.Schedule) error {
+	result := repo.db.WithContext(ctx).Create(schedule)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create schedule: %w", result.Error)
+	}
+
+	return nil
+}
+
+func (repo *ScheduleRepository) GetByScheduleID(ctx context.Context, scheduleID string) (*model.Schedule, error) {
+	schedule := &model.Schedule{}
+	result := repo.db.WithContext(ctx).First(schedule, "schedule_id = ?", scheduleID)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return schedule, nil
+}
+
+// GetForUpdate loads the schedule inside tx and holds a row lock on it
+func (repo *ScheduleRepository) GetForUpdate(ctx context.Context, scheduleID string, tx Tx) (*model.Schedule, error) {
+	schedule := &model.Schedule{}
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(schedule, "schedule_id = ?", scheduleID)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return schedule, nil
+}
+
+func (repo *ScheduleRepository) List(ctx context.Context, accountNumber string, status string) ([]model.Schedule, error) {
+	query := repo.db.WithContext(ctx)
+	if accountNumber != "" {
+		query = query.Where("from_account_number = ? OR to_account_number = ?", accountNumber, accountNumber)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var schedules []model.Schedule
+	result := query.Order("id DESC").Find(&schedules)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return schedules, nil
+}
+
+func (repo *ScheduleRepository) Save(ctx context.Context, schedule *model.Schedule, tx Tx) error {
+	result := repo.conn(tx).WithContext(ctx).Save(schedule)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save schedule: %w", result.Error)
+	}
+
+	return nil
+}
+
+// ListDue returns the ids of up to limit active schedules due at now
+func (repo *ScheduleRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var scheduleIDs []string
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Schedule{}).
+		Where("status = ? AND next_run_at <= ?", model.ScheduleActive, now).
+		Order("next_run_at").
+		Limit(limit).
+		Pluck("schedule_id", &scheduleIDs)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list due schedules: %w", result.Error)
+	}
+
+	return scheduleIDs, nil
+}
+
+// ClaimDue locks the schedule inside tx if it is still due at now. A schedule
+// locked by another scheduler is skipped.
+func (repo *ScheduleRepository) ClaimDue(ctx context.Context, scheduleID string, now time.Time, tx Tx) (*model.Schedule, error) {
+	var schedules []model.Schedule
+
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("schedule_id = ? AND status = ? AND next_run_at <= ?", scheduleID, model.ScheduleActive, now).
+		Limit(1).
+		Find(&schedules)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim schedule: %w", result.Error)
+	}
+
+	if len(schedules) == 0 {
+		return nil, nil
+	}
+
+	return &schedules[0], nil
+}
+
+// CreateRun records an occurrence and reports false when it was already recorded
+func (repo *ScheduleRepository) CreateRun(ctx context.Context, run *model.ScheduleRun, tx Tx) (bool, error) {
+	result := repo.conn(tx).WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "schedule_id"}, {Name: "scheduled_for"}},
+			DoNothing: true,
+		}).
+		Create(run)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record schedule run: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *ScheduleRepository) ListRuns(ctx context.Context, scheduleID string, limit int) ([]model.ScheduleRun, error) {
+	var runs []model.ScheduleRun
+
+	result := repo.db.WithContext(ctx).
+		Where("schedule_id = ?", scheduleID).
+		Order("scheduled_for DESC").
+		Limit(limit).
+		Find(&runs)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"golang-exercise/internal/database"
+	"golang-exercise/internal/database/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StatementRepository only ever inserts and reads, statements are immutable
+type StatementRepository struct {
+	db *gorm.DB
+}
+
+func NewStatementRepository() *StatementRepository {
+	return &StatementRepository{
+		db: database.GetPostgresDB(),
+	}
+}
+
+func NewStatementRepositoryWithDB(db *gorm.DB) *StatementRepository {
+	return &StatementRepository{
+		db: db,
+	}
+}
+
+// Create stores the statement and reports false when the account already has
+// one for the period
+func (repo *StatementRepository) Create(ctx context.Context, statement *model.Statement) (bool, error) {
+	result := repo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "account_number"}, {Name: "period"}},
+			DoNothing: true,
+		}).
+		Create(statement)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to store statement: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *StatementRepository) GetByPeriod(ctx context.Context, accountNumber string, period string) (*model.Statement, error) {
+	statement := &model.Statement{}
+	result := repo.db.WithContext(ctx).First(statement, "account_number = ? AND period = ?", accountNumber, period)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return statement, nil
+}
+
+// ListByAccount returns the statements of the account, newest first, without their lines
+func (repo *StatementRepository) ListByAccount(ctx context.Context, accountNumber string) ([]model.Statement, error) {
+	var statements []model.Statement
+
+	result := repo.db.WithContext(ctx).
+		Omit("lines").
+		Where("account_number = ?", accountNumber).
+		Order("period DESC").
+		Find(&statements)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return statements, nil
+}
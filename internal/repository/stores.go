@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"golang-exercise/internal/database/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// The stores are what the services depend on. The repositories in this package
+// implement them on Postgres and Mongo, package memory implements them in
+// process for tests. Lookups of missing rows fail with gorm.ErrRecordNotFound
+// on both.
+
+type AccountStore interface {
+	Transactor
+
+	Create(ctx context.Context, account *model.Account, tx Tx) error
+	GetByAccountNumber(ctx context.Context, accountNumber string) (*model.Account, error)
+	GetByID(ctx context.Context, id uint) (*model.Account, error)
+	// GetForUpdate locks the account until tx ends
+	GetForUpdate(ctx context.Context, accountNumber string, tx Tx) (*model.Account, error)
+	Count(ctx context.Context, accountNumber string) (int64, error)
+	// NextAccountNumber draws from a sequence, no value is handed out twice
+	NextAccountNumber(ctx context.Context) (int64, error)
+	Update(ctx context.Context, accountNumber string, account *model.Account, tx Tx) error
+	UpdateBalance(ctx context.Context, accountNumber string, balance decimal.Decimal, tx Tx) error
+	UpdateHeldBalance(ctx context.Context, accountNumber string, heldBalance decimal.Decimal, tx Tx) error
+	UpdateBalancePolicy(ctx context.Context, accountNumber string, minimumBalance *decimal.Decimal, overdraftLimit *decimal.Decimal) error
+	ListOpenAccountNumbers(ctx context.Context, accountType model.AccountType) ([]string, error)
+	// ListAccountNumbersOpenDuring returns the accounts opened before end and not closed before start
+	ListAccountNumbersOpenDuring(ctx context.Context, start time.Time, end time.Time) ([]string, error)
+	ListAfterID(ctx context.Context, afterID uint, limit int) ([]model.Account, error)
+}
+
+type TransactionLogStore interface {
+	Create(ctx context.Context, txLog *model.TransactionLog) error
+	CreateIfNotExists(ctx context.Context, txLog *model.TransactionLog) error
+	GetByAccountID(ctx context.Context, accountID uint, limit int64) ([]model.TransactionLog, error)
+	// GetByTransactionID returns nil without an error when there is no such log
+	GetByTransactionID(ctx context.Context, transactionID string) (*model.TransactionLog, error)
+	// GetByTransactionIDs skips the ids without a log
+	GetByTransactionIDs(ctx context.Context, transactionIDs []string) ([]model.TransactionLog, error)
+	UpdateStatus(ctx context.Context, transactionID string, status model.TransactionStatus) error
+	MarkFailed(ctx context.Context, transactionID string, reason string) error
+	RecordAttempt(ctx context.Context, transactionID string, retryCount int, status model.TransactionStatus, reason string) error
+	LinkFee(ctx context.Context, transactionID string, feeTransactionID string, feeAmount decimal.Decimal) error
+	AddReversal(ctx context.Context, transactionID string, reversalID string, reversedAmount decimal.Decimal) error
+	GetByStatus(ctx context.Context, status string, limit int64) ([]model.TransactionLog, error)
+	GetAll(ctx context.Context, limit int64, offset int64) ([]model.TransactionLog, error)
+	GetTransactionHistory(ctx context.Context, accountID uint, limit int, offset int, startDate, endDate, status string) ([]model.TransactionLog, int, error)
+	// StreamTransactionHistory hands every log matching the history filters to fn, newest first
+	StreamTransactionHistory(ctx context.Context, accountID uint, startDate, endDate, status string, fn func(txLog *model.TransactionLog) error) error
+	// StreamByAccountID hands every log the account takes part in to fn, oldest first
+	StreamByAccountID(ctx context.Context, accountID uint, fn func(txLog *model.TransactionLog) error) error
+}
+
+type LedgerStore interface {
+	CreateEntry(ctx context.Context, entry *model.JournalEntry, tx Tx) error
+	ExistsByTransactionID(ctx context.Context, transactionID string, tx Tx) (bool, error)
+	GetEntryByTransactionID(ctx context.Context, transactionID string, tx Tx) (*model.JournalEntry, error)
+	SumReversedAmount(ctx context.Context, transactionID string, tx Tx) (decimal.Decimal, error)
+	GetPostingsByAccount(ctx context.Context, accountNumber string, limit int, offset int) ([]model.Posting, int64, error)
+	SumByAccount(ctx context.Context, accountNumber string, tx Tx) (decimal.Decimal, error)
+	ListLines(ctx context.Context, accountNumber string, from time.Time, to time.Time) ([]model.StatementLine, error)
+	SumByAccountBetween(ctx context.Context, accountNumber string, from time.Time, before time.Time) (decimal.Decimal, error)
+	GetLatestSnapshot(ctx context.Context, accountNumber string, at time.Time) (*model.BalanceSnapshot, error)
+	ListAccountsToSnapshot(ctx context.Context, before time.Time) ([]string, error)
+	CreateSnapshot(ctx context.Context, snapshot *model.BalanceSnapshot) error
+}
+
+type HoldStore interface {
+	Transactor
+
+	Create(ctx context.Context, hold *model.Hold, tx Tx) error
+	GetByHoldID(ctx context.Context, holdID string) (*model.Hold, error)
+	// GetForUpdate locks the hold until tx ends
+	GetForUpdate(ctx context.Context, holdID string, tx Tx) (*model.Hold, error)
+	ListByAccount(ctx context.Context, accountNumber string, status string) ([]model.Hold, error)
+	ListExpired(ctx context.Context, accountNumber string, now time.Time, tx Tx) ([]model.Hold, error)
+	ListAccountsWithExpiredHolds(ctx context.Context, now time.Time, limit int) ([]string, error)
+	Save(ctx context.Context, hold *model.Hold, tx Tx) error
+}
+
+type FeeStore interface {
+	Transactor
+
+	HasMaintenanceCharge(ctx context.Context, accountNumber string, period string, tx Tx) (bool, error)
+	CreateMaintenanceCharge(ctx context.Context, charge *model.MaintenanceCharge, tx Tx) (bool, error)
+}
+
+type OutboxStore interface {
+	Transactor
+
+	Create(ctx context.Context, message *model.OutboxMessage, tx Tx) error
+	// ClaimPending leases pending messages until leaseUntil, skipping ones leased or locked elsewhere
+	ClaimPending(ctx context.Context, limit int, leaseUntil time.Time, tx Tx) ([]model.OutboxMessage, error)
+	MarkSent(ctx context.Context, id uint, tx Tx) error
+	// RecordFailure counts a failed publish and leaves the message alone until retryAt
+	RecordFailure(ctx context.Context, id uint, attempts int, status model.OutboxStatus, lastError string, retryAt time.Time, tx Tx) error
+	HasPending(ctx context.Context, transactionID string) (bool, error)
+}
+
+type BatchStore interface {
+	Transactor
+
+	Create(ctx context.Context, batch *model.TransactionBatch, items []model.TransactionBatchItem, tx Tx) error
+	GetByBatchID(ctx context.Context, batchID string) (*model.TransactionBatch, error)
+	// ListItems returns the items of the batch in submission order
+	ListItems(ctx context.Context, batchID string) ([]model.TransactionBatchItem, error)
+}
+
+type FxRateStore interface {
+	// Upsert replaces an existing rate for the same pair and effective time
+	Upsert(ctx context.Context, rates []model.FxRate) error
+	// GetEffective returns the latest rate for the pair that took effect at or before at
+	GetEffective(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (*model.FxRate, error)
+	List(ctx context.Context, baseCurrency string, quoteCurrency string, limit int) ([]model.FxRate, error)
+}
+
+type IdempotencyStore interface {
+	// Reserve reports false when the scope already has the key
+	Reserve(ctx context.Context, key *model.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, idempotencyKey string, scope string) (*model.IdempotencyKey, error)
+	// TakeOver refreshes an unfinished reservation last touched before
+	// staleBefore, reporting false when it finished or another retry took it
+	TakeOver(ctx context.Context, id uint, staleBefore time.Time) (bool, error)
+	SaveResponse(ctx context.Context, id uint, status int, body string) error
+	Delete(ctx context.Context, id uint) error
+}
+
+type InterestStore interface {
+	Transactor
+
+	ListAccountsDue(ctx context.Context, accountType model.AccountType, through time.Time) ([]string, error)
+	CreateAccruals(ctx context.Context, accruals []model.InterestAccrual, tx Tx) error
+	UpdateAccrued(ctx context.Context, accountNumber string, accrued decimal.Decimal, through time.Time, tx Tx) error
+	ListAccruals(ctx context.Context, accountNumber string, limit int) ([]model.InterestAccrual, error)
+}
+
+type ScheduleStore interface {
+	Transactor
+
+	Create(ctx context.Context, schedule *model.Schedule) error
+	GetByScheduleID(ctx context.Context, scheduleID string) (*model.Schedule, error)
+	// GetForUpdate locks the schedule until tx ends
+	GetForUpdate(ctx context.Context, scheduleID string, tx Tx) (*model.Schedule, error)
+	List(ctx context.Context, accountNumber string, status string) ([]model.Schedule, error)
+	Save(ctx context.Context, schedule *model.Schedule, tx Tx) error
+	// ListDue returns the ids of active schedules due at now, the earliest first
+	ListDue(ctx context.Context, now time.Time, limit int) ([]string, error)
+	// ClaimDue locks the schedule until tx ends if it is still due. It returns
+	// nil when it is not, or when it is locked elsewhere.
+	ClaimDue(ctx context.Context, scheduleID string, now time.Time, tx Tx) (*model.Schedule, error)
+	// CreateRun reports false when the occurrence was already recorded
+	CreateRun(ctx context.Context, run *model.ScheduleRun, tx Tx) (bool, error)
+	ListRuns(ctx context.Context, scheduleID string, limit int) ([]model.ScheduleRun, error)
+}
+
+type StatementStore interface {
+	// Create reports false when the account already has a statement for the period
+	Create(ctx context.Context, statement *model.Statement) (bool, error)
+	GetByPeriod(ctx context.Context, accountNumber string, period string) (*model.Statement, error)
+	// ListByAccount returns the statements newest first, without their lines
+	ListByAccount(ctx context.Context, accountNumber string) ([]model.Statement, error)
+}
+
+type ReconciliationStore interface {
+	// ReadAccount returns the account and the net amount every transaction
+	// posted to it, read from one snapshot
+	ReadAccount(ctx context.Context, accountNumber string) (*model.Account, []TransactionPosting, error)
+	// LedgerStartedAt returns when the first journal entry was posted, zero
+	// while there is none
+	LedgerStartedAt(ctx context.Context) (time.Time, error)
+}
+
+var (
+	_ AccountStore        = (*AccountRepository)(nil)
+	_ TransactionLogStore = (*TransactionLogRepository)(nil)
+	_ LedgerStore         = (*LedgerRepository)(nil)
+	_ HoldStore           = (*HoldRepository)(nil)
+	_ FeeStore            = (*FeeRepository)(nil)
+	_ OutboxStore         = (*OutboxRepository)(nil)
+	_ BatchStore          = (*BatchRepository)(nil)
+	_ FxRateStore         = (*FxRateRepository)(nil)
+	_ IdempotencyStore    = (*IdempotencyRepository)(nil)
+	_ InterestStore       = (*InterestRepository)(nil)
+	_ ScheduleStore       = (*ScheduleRepository)(nil)
+	_ StatementStore      = (*StatementRepository)(nil)
+	_ ReconciliationStore = (*ReconciliationRepository)(nil)
+)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -32,6 +33,23 @@ func (repo *TransactionLogRepository) Create(ctx context.Context, txLog *model.T
 	return err
 }
 
+// CreateIfNotExists inserts the log unless one already exists for its transaction id
+func (repo *TransactionLogRepository) CreateIfNotExists(ctx context.Context, txLog *model.TransactionLog) error {
+	if txLog.ID.IsZero() {
+		txLog.ID = primitive.NewObjectID()
+	}
+
+	if txLog.Timestamp.IsZero() {
+		txLog.Timestamp = time.Now()
+	}
+
+	filter := bson.M{"transaction_id": txLog.TransactionId}
+	update := bson.M{"$setOnInsert": txLog}
+
+	_, err := repo.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
 func (repo *TransactionLogRepository) GetByAccountID(ctx context.Context, accountID uint, limit int64) ([]model.TransactionLog, error) {
 	filter := bson.M{
 		"$or": []bson.M{
@@ -70,6 +88,23 @@ func (repo *TransactionLogRepository) GetByTransactionID(ctx context.Context, tr
 	return &txLog, nil
 }
 
+func (repo *TransactionLogRepository) GetByTransactionIDs(ctx context.Context, transactionIDs []string) ([]model.TransactionLog, error) {
+	filter := bson.M{"transaction_id": bson.M{"$in": transactionIDs}}
+
+	cursor, err := repo.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var logs []model.TransactionLog
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
 func (repo *TransactionLogRepository) UpdateStatus(ctx context.Context, transactionID string, status model.TransactionStatus) error {
 	filter := bson.M{"transaction_id": transactionID}
 	update := bson.M{
@@ -79,6 +114,67 @@ func (repo *TransactionLogRepository) UpdateStatus(ctx context.Context, transact
 		},
 	}
 
+	// The reason of an earlier failed attempt no longer applies
+	if status == model.TransactionStatusCompleted {
+		update["$unset"] = bson.M{"failure_reason": ""}
+	}
+
+	_, err := repo.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// MarkFailed fails the transaction and records why
+func (repo *TransactionLogRepository) MarkFailed(ctx context.Context, transactionID string, reason string) error {
+	filter := bson.M{"transaction_id": transactionID}
+	update := bson.M{
+		"$set": bson.M{
+			"status":         model.TransactionStatusFailed,
+			"failure_reason": reason,
+			"processed_at":   time.Now(),
+		},
+	}
+
+	_, err := repo.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RecordAttempt stores how often the transaction was retried along with its current status and last error
+func (repo *TransactionLogRepository) RecordAttempt(ctx context.Context, transactionID string, retryCount int, status model.TransactionStatus, reason string) error {
+	filter := bson.M{"transaction_id": transactionID}
+	update := bson.M{
+		"$set": bson.M{
+			"status":         status,
+			"retry_count":    retryCount,
+			"failure_reason": reason,
+		},
+	}
+
+	_, err := repo.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// LinkFee records on the transaction the fee that was charged for it
+func (repo *TransactionLogRepository) LinkFee(ctx context.Context, transactionID string, feeTransactionID string, feeAmount decimal.Decimal) error {
+	filter := bson.M{"transaction_id": transactionID}
+	update := bson.M{
+		"$set": bson.M{
+			"fee_transaction_id": feeTransactionID,
+			"fee_amount":         feeAmount,
+		},
+	}
+
+	_, err := repo.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// AddReversal links a completed reversal to the original transaction
+func (repo *TransactionLogRepository) AddReversal(ctx context.Context, transactionID string, reversalID string, reversedAmount decimal.Decimal) error {
+	filter := bson.M{"transaction_id": transactionID}
+	update := bson.M{
+		"$addToSet": bson.M{"reversal_ids": reversalID},
+		"$set":      bson.M{"reversed_amount": reversedAmount},
+	}
+
 	_, err := repo.collection.UpdateOne(ctx, filter, update)
 	return err
 }
@@ -121,7 +217,8 @@ func (repo *TransactionLogRepository) GetAll(ctx context.Context, limit int64, o
 	return logs, nil
 }
 
-func (repo *TransactionLogRepository) GetTransactionHistory(ctx context.Context, accountID uint, limit int, offset int, startDate, endDate, status string) ([]model.TransactionLog, int, error) {
+// historyFilter selects the logs of the account matching the history filters
+func historyFilter(accountID uint, startDate, endDate, status string) bson.M {
 	filter := bson.M{
 		"$or": []bson.M{
 			{"from_account_id": accountID},
@@ -152,6 +249,12 @@ func (repo *TransactionLogRepository) GetTransactionHistory(ctx context.Context,
 		filter["status"] = status
 	}
 
+	return filter
+}
+
+func (repo *TransactionLogRepository) GetTransactionHistory(ctx context.Context, accountID uint, limit int, offset int, startDate, endDate, status string) ([]model.TransactionLog, int, error) {
+	filter := historyFilter(accountID, startDate, endDate, status)
+
 	// Get total count
 	totalCount, err := repo.collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -176,3 +279,45 @@ func (repo *TransactionLogRepository) GetTransactionHistory(ctx context.Context,
 
 	return logs, int(totalCount), nil
 }
+
+// StreamTransactionHistory decodes the logs matching the history filters one at
+// a time from a single cursor, newest first, so any range can be exported
+func (repo *TransactionLogRepository) StreamTransactionHistory(ctx context.Context, accountID uint, startDate, endDate, status string, fn func(txLog *model.TransactionLog) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	return repo.stream(ctx, historyFilter(accountID, startDate, endDate, status), opts, fn)
+}
+
+// StreamByAccountID decodes the logs of the account one at a time from a single
+// cursor, so accounts with a long history are not loaded at once
+func (repo *TransactionLogRepository) StreamByAccountID(ctx context.Context, accountID uint, fn func(txLog *model.TransactionLog) error) error {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"from_account_id": accountID},
+			{"to_account_id": accountID},
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	return repo.stream(ctx, filter, opts, fn)
+}
+
+func (repo *TransactionLogRepository) stream(ctx context.Context, filter bson.M, opts *options.FindOptions, fn func(txLog *model.TransactionLog) error) error {
+	cursor, err := repo.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var txLog model.TransactionLog
+		if err := cursor.Decode(&txLog); err != nil {
+			return err
+		}
+
+		if err := fn(&txLog); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Tx is a database transaction spanning several repository calls. Writes made
+// through it commit or roll back together, and rows locked through it stay
+// locked until it ends. Repository methods taking a nil Tx run on their own.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Transactor starts transactions shared by every repository of the same backend
+type Transactor interface {
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// Transaction runs fn inside a transaction, committing when it returns nil and
+// rolling back when it returns an error or panics
+func Transaction(ctx context.Context, transactor Transactor, fn func(tx Tx) error) (err error) {
+	tx, err := transactor.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type gormTx struct {
+	db *gorm.DB
+}
+
+func (tx gormTx) Commit() error {
+	return tx.db.Commit().Error
+}
+
+func (tx gormTx) Rollback() error {
+	return tx.db.Rollback().Error
+}
+
+// GormTx hands a transaction started by gorm to repositories taking a Tx
+func GormTx(db *gorm.DB) Tx {
+	if db == nil {
+		return nil
+	}
+
+	return gormTx{db: db}
+}
+
+// gormTransactor starts transactions on db
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func (transactor gormTransactor) Begin(ctx context.Context) (Tx, error) {
+	tx := transactor.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	}
+
+	return gormTx{db: tx}, nil
+}
+
+// gormConn returns the gorm transaction behind tx, or db outside of one
+func gormConn(tx Tx, db *gorm.DB) *gorm.DB {
+	if tx == nil {
+		return db
+	}
+
+	return tx.(gormTx).db
+}
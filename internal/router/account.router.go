@@ -6,15 +6,26 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupAccountRoutes(router *gin.RouterGroup, accountHandler *handler.AccountHandler) {
+func SetupAccountRoutes(router *gin.RouterGroup, accountHandler *handler.AccountHandler, idempotency gin.HandlerFunc) {
 	accounts := router.Group("/accounts")
 	{
 		accounts.POST("/", accountHandler.CreateAccount)
+
+		// Bulk account opening from a CSV file
+		accounts.POST("/import", idempotency, accountHandler.ImportAccounts)
 		accounts.GET("/:account_number", accountHandler.GetAccount)
+		accounts.PATCH("/:account_number/status", accountHandler.UpdateAccountStatus)
 
 		// Direct funds processing endpoint
-		accounts.POST("/funds", accountHandler.ProcessFunds)
+		accounts.POST("/funds", idempotency, accountHandler.ProcessFunds)
 
 		accounts.GET("/:account_number/balance", accountHandler.GetAccountBalance)
+
+		// Ledger postings backing the account balance
+		accounts.GET("/:account_number/postings", accountHandler.GetAccountPostings)
+
+		// Overdraft limit and minimum balance enforced on debits
+		accounts.GET("/:account_number/balance-policy", accountHandler.GetBalancePolicy)
+		accounts.PUT("/:account_number/balance-policy", accountHandler.UpdateBalancePolicy)
 	}
 }
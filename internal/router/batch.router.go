@@ -0,0 +1,15 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupBatchRoutes(router *gin.RouterGroup, batchHandler *handler.BatchHandler, idempotency gin.HandlerFunc) {
+	// Submit many deposits and withdrawals at once
+	router.POST("/transactions/batch", idempotency, batchHandler.CreateBatch)
+
+	// Aggregated progress and per-item outcomes of a batch
+	router.GET("/batches/:batch_id", batchHandler.GetBatch)
+}
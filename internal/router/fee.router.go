@@ -0,0 +1,15 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFeeRoutes(router *gin.RouterGroup, feeHandler *handler.FeeHandler) {
+	fees := router.Group("/fees")
+	{
+		fees.GET("/schedule", feeHandler.GetSchedule)
+		fees.POST("/quote", feeHandler.QuoteFee)
+	}
+}
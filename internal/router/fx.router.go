@@ -0,0 +1,16 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupFxRoutes(router *gin.RouterGroup, fxHandler *handler.FxHandler) {
+	router.GET("/fx-rates", fxHandler.ListRates)
+
+	admin := router.Group("/admin")
+	{
+		admin.POST("/fx-rates", fxHandler.LoadRates)
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupHoldRoutes(router *gin.RouterGroup, holdHandler *handler.HoldHandler, idempotency gin.HandlerFunc) {
+	holds := router.Group("/accounts/:account_number/holds")
+	{
+		holds.POST("", idempotency, holdHandler.CreateHold)
+		holds.GET("", holdHandler.ListHolds)
+
+		holds.POST("/:hold_id/capture", idempotency, holdHandler.CaptureHold)
+		holds.POST("/:hold_id/void", holdHandler.VoidHold)
+	}
+}
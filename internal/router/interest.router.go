@@ -0,0 +1,11 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupInterestRoutes(router *gin.RouterGroup, interestHandler *handler.InterestHandler) {
+	router.GET("/accounts/:account_number/interest", interestHandler.GetAccountInterest)
+}
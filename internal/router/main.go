@@ -2,14 +2,26 @@ package router
 
 import (
 	"golang-exercise/internal/handler"
+	"golang-exercise/internal/middleware"
+	"golang-exercise/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(router *gin.Engine) {
+func SetupRouter(router *gin.Engine, idempotencyService *service.IdempotencyService) {
+	idempotency := middleware.Idempotency(idempotencyService)
+
 	v1 := router.Group("/api/v1")
 	{
-		SetupAccountRoutes(v1, &handler.AccountHandler{})
-		SetupTransactionRoutes(v1, &handler.TransactionHandler{})
+		SetupAccountRoutes(v1, &handler.AccountHandler{}, idempotency)
+		SetupTransactionRoutes(v1, &handler.TransactionHandler{}, idempotency)
+		SetupTransferRoutes(v1, &handler.TransferHandler{}, idempotency)
+		SetupHoldRoutes(v1, &handler.HoldHandler{}, idempotency)
+		SetupFxRoutes(v1, &handler.FxHandler{})
+		SetupScheduleRoutes(v1, &handler.ScheduleHandler{}, idempotency)
+		SetupInterestRoutes(v1, &handler.InterestHandler{})
+		SetupFeeRoutes(v1, &handler.FeeHandler{})
+		SetupStatementRoutes(v1, &handler.StatementHandler{})
+		SetupBatchRoutes(v1, &handler.BatchHandler{}, idempotency)
 	}
 }
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupScheduleRoutes(router *gin.RouterGroup, scheduleHandler *handler.ScheduleHandler, idempotency gin.HandlerFunc) {
+	schedules := router.Group("/schedules")
+	{
+		schedules.POST("", idempotency, scheduleHandler.CreateSchedule)
+		schedules.GET("", scheduleHandler.ListSchedules)
+		schedules.GET("/:schedule_id", scheduleHandler.GetSchedule)
+		schedules.PATCH("/:schedule_id", scheduleHandler.UpdateSchedule)
+		schedules.DELETE("/:schedule_id", scheduleHandler.CancelSchedule)
+		schedules.GET("/:schedule_id/runs", scheduleHandler.ListRuns)
+	}
+}
@@ -0,0 +1,13 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupStatementRoutes(router *gin.RouterGroup, statementHandler *handler.StatementHandler) {
+	router.GET("/accounts/:account_number/statements", statementHandler.ListStatements)
+	router.POST("/accounts/:account_number/statements", statementHandler.GenerateStatement)
+	router.GET("/accounts/:account_number/statements/:period", statementHandler.GetStatement)
+}
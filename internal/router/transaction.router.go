@@ -6,7 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupTransactionRoutes(router *gin.RouterGroup, transactionHandler *handler.TransactionHandler) {
+func SetupTransactionRoutes(router *gin.RouterGroup, transactionHandler *handler.TransactionHandler, idempotency gin.HandlerFunc) {
 	transactions := router.Group("/transactions")
 	{
 		// Get transaction history for a specific account
@@ -14,5 +14,8 @@ func SetupTransactionRoutes(router *gin.RouterGroup, transactionHandler *handler
 
 		// Get transaction status by transaction ID
 		transactions.GET("/:transaction_id/status", transactionHandler.GetTransactionStatus)
+
+		// Reverse (fully or partially) a completed transaction
+		transactions.POST("/:transaction_id/reverse", idempotency, transactionHandler.ReverseTransaction)
 	}
 }
@@ -0,0 +1,14 @@
+package router
+
+import (
+	"golang-exercise/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupTransferRoutes(router *gin.RouterGroup, transferHandler *handler.TransferHandler, idempotency gin.HandlerFunc) {
+	transfers := router.Group("/transfers")
+	{
+		transfers.POST("", idempotency, transferHandler.CreateTransfer)
+	}
+}
@@ -2,53 +2,44 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"time"
 
+	"golang-exercise/config"
 	model "golang-exercise/internal/database/model"
 	requestdto "golang-exercise/internal/dto/request"
 	"golang-exercise/internal/repository"
 
 	"github.com/shopspring/decimal"
-	"gorm.io/gorm"
 )
 
 type AccountService struct {
-	accRepo *repository.AccountRepository
+	accRepo       repository.AccountStore
+	ledgerService *LedgerService
+	txLogService  *TransactionLogService
 }
 
-func NewAccountService(accRepo *repository.AccountRepository) *AccountService {
+func NewAccountService(accRepo repository.AccountStore, ledgerService *LedgerService, txLogService *TransactionLogService) *AccountService {
 	return &AccountService{
-		accRepo: accRepo,
+		accRepo:       accRepo,
+		ledgerService: ledgerService,
+		txLogService:  txLogService,
 	}
 }
 
-const MAX_ATTEMPTS_TO_GENERATE_ACCOUNT = 5
+var ErrInsufficientFunds = errors.New("insufficient balance")
 
-func (accService *AccountService) generateAccountNumber(ctx context.Context, accountType model.AccountType) (string, error) {
-	maxRetries := MAX_ATTEMPTS_TO_GENERATE_ACCOUNT
-
-	for maxRetries > 0 {
-		accountNumber := accService.generateUniqueAccountNumber(accountType)
-
-		count, err := accService.accRepo.Count(ctx, accountNumber)
-		if err != nil {
-			return "", fmt.Errorf("failed to check uniqueness: %w", err)
-		}
-
-		if count <= 0 {
-			return accountNumber, nil
-		}
-
-		fmt.Printf("Failed to generate unique account id on attempt %d", MAX_ATTEMPTS_TO_GENERATE_ACCOUNT-maxRetries+1)
-		maxRetries--
-	}
-
-	return "", fmt.Errorf("failed to generate unique account id after %d attempts", MAX_ATTEMPTS_TO_GENERATE_ACCOUNT)
-}
+var (
+	ErrAccountFrozen           = errors.New("account is frozen")
+	ErrAccountClosed           = errors.New("account is closed")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+)
 
-func (accService *AccountService) generateUniqueAccountNumber(accountType model.AccountType) string {
+// generateAccountNumber numbers the account from a database sequence, so
+// accounts opened together, as an import does, never share a number
+func (accService *AccountService) generateAccountNumber(ctx context.Context, accountType model.AccountType) (string, error) {
 	prefix := ""
 	switch accountType {
 	case model.AccountTypeChecking:
@@ -57,22 +48,42 @@ func (accService *AccountService) generateUniqueAccountNumber(accountType model.
 		prefix = "SAV"
 	}
 
-	// Use current timestamp + random for uniqueness
-	timestamp := time.Now().Unix()
-	random := rand.Intn(9999)
-
-	accountNumber := fmt.Sprintf("%s%d%04d", prefix, timestamp, random)
+	next, err := accService.accRepo.NextAccountNumber(ctx)
+	if err != nil {
+		return "", err
+	}
 
-	return accountNumber
+	return fmt.Sprintf("%s%014d", prefix, next), nil
 }
 
 func (accService *AccountService) CreateAccount(ctx context.Context, req *requestdto.CreateAccount) (*model.Account, error) {
+	account, err := accService.newAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repository.Transaction(ctx, accService.accRepo, func(tx repository.Tx) error {
+		return accService.openAccount(ctx, account, tx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the account")
+	}
+
+	if !account.Balance.IsZero() {
+		accService.logOpeningBalance(ctx, openingTransactionID(account), account)
+	}
+
+	return account, nil
+}
+
+// newAccount builds the account to open with a fresh account number
+func (accService *AccountService) newAccount(ctx context.Context, req *requestdto.CreateAccount) (*model.Account, error) {
 	accountNumber, err := accService.generateAccountNumber(ctx, req.AccountType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate unique account number: %w", err)
 	}
 
-	account := &model.Account{
+	return &model.Account{
 		AccountNumber: accountNumber,
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
@@ -80,14 +91,51 @@ func (accService *AccountService) CreateAccount(ctx context.Context, req *reques
 		Currency:      req.Currency,
 		AccountStatus: model.AccountActive,
 		AccountType:   req.AccountType,
+	}, nil
+}
+
+func openingTransactionID(account *model.Account) string {
+	return fmt.Sprintf("OPEN_%s", account.AccountNumber)
+}
+
+// openAccount creates the account and books its initial balance within tx
+func (accService *AccountService) openAccount(ctx context.Context, account *model.Account, tx repository.Tx) error {
+	if err := accService.accRepo.Create(ctx, account, tx); err != nil {
+		return err
 	}
 
-	err = accService.accRepo.Create(ctx, account)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create the account")
+	if account.Balance.IsZero() {
+		return nil
 	}
 
-	return account, nil
+	// Book the initial balance so the account reconciles with its postings
+	entry := NewJournalEntry(openingTransactionID(account), model.TransactionTypeOpeningBalance, account.Balance, account.Currency, "Opening balance",
+		Leg(account.AccountNumber, account.Balance, account.Currency),
+		Leg(SystemCashAccount(account.Currency), account.Balance.Neg(), account.Currency),
+	)
+
+	return accService.ledgerService.PostEntry(ctx, entry, tx)
+}
+
+func (accService *AccountService) logOpeningBalance(ctx context.Context, transactionID string, account *model.Account) {
+	processedAt := time.Now()
+	txLog := &model.TransactionLog{
+		TransactionId: transactionID,
+		FromAccountId: account.ID,
+		ToAccountId:   account.ID,
+		Amount:        account.Balance,
+		Currency:      account.Currency,
+		Type:          model.TransactionTypeOpeningBalance,
+		Status:        model.TransactionStatusCompleted,
+		Memo:          "Opening balance",
+		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
+		ProcessedAt:   &processedAt,
+	}
+
+	// The account is already committed, so a failed log write must not fail the request
+	if err := accService.txLogService.LogTransaction(ctx, txLog); err != nil {
+		log.Printf("Failed to log opening balance for account %s: %v", account.AccountNumber, err)
+	}
 }
 
 func (accService *AccountService) GetAccount(ctx context.Context, req *requestdto.GetAccount) (*model.Account, error) {
@@ -99,26 +147,165 @@ func (accService *AccountService) GetAccount(ctx context.Context, req *requestdt
 	return account, nil
 }
 
-func (accService *AccountService) UpdateBalance(ctx context.Context, accountNumber string, newBalance decimal.Decimal, tx *gorm.DB) error {
-	account := &model.Account{
-		Balance: newBalance,
+func (accService *AccountService) GetAccountByID(ctx context.Context, id uint) (*model.Account, error) {
+	account, err := accService.accRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find account with id %d: %w", id, err)
 	}
 
-	var db *gorm.DB
-	if tx != nil {
-		db = tx
-	} else {
-		db = accService.accRepo.GetDB()
+	return account, nil
+}
+
+func (accService *AccountService) ListOpenAccountNumbers(ctx context.Context, accountType model.AccountType) ([]string, error) {
+	return accService.accRepo.ListOpenAccountNumbers(ctx, accountType)
+}
+
+func (accService *AccountService) ListAccountNumbersOpenDuring(ctx context.Context, start time.Time, end time.Time) ([]string, error) {
+	return accService.accRepo.ListAccountNumbersOpenDuring(ctx, start, end)
+}
+
+// Begin starts a transaction in which accounts can be locked and updated
+func (accService *AccountService) Begin(ctx context.Context) (repository.Tx, error) {
+	return accService.accRepo.Begin(ctx)
+}
+
+// LockAccount loads the account and holds a row lock on it until tx ends
+func (accService *AccountService) LockAccount(ctx context.Context, accountNumber string, tx repository.Tx) (*model.Account, error) {
+	return accService.accRepo.GetForUpdate(ctx, accountNumber, tx)
+}
+
+// CheckAccountActivity refuses debits on frozen accounts and any movement on
+// closed ones. Callers moving money must hold the account's row lock.
+func CheckAccountActivity(account *model.Account, debit bool) error {
+	switch account.AccountStatus {
+	case model.AccountClosed:
+		return fmt.Errorf("%w: %s accepts no further transactions", ErrAccountClosed, account.AccountNumber)
+	case model.AccountFrozen:
+		if debit {
+			return fmt.Errorf("%w: %s cannot be debited", ErrAccountFrozen, account.AccountNumber)
+		}
+	}
+
+	return nil
+}
+
+// UpdateStatus moves the account through its lifecycle. The account is locked
+// so closing cannot race with money still arriving.
+func (accService *AccountService) UpdateStatus(ctx context.Context, accountNumber string, status model.AccountStatus, reason string) (*model.Account, error) {
+	var account *model.Account
+
+	err := repository.Transaction(ctx, accService.accRepo, func(tx repository.Tx) error {
+		var err error
+		account, err = accService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return fmt.Errorf("failed to find account with such account number: %w", err)
+		}
+
+		if !account.AccountStatus.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, account.AccountStatus, status)
+		}
+
+		if status == model.AccountClosed && (!account.Balance.IsZero() || !account.HeldBalance.IsZero()) {
+			return fmt.Errorf("%w: balance must be zero with no active holds to close the account", ErrInvalidStatusTransition)
+		}
+
+		now := time.Now()
+		account.AccountStatus = status
+		account.StatusReason = reason
+		account.StatusChangedAt = &now
+
+		return accService.accRepo.Update(ctx, accountNumber, &model.Account{
+			AccountStatus:   status,
+			StatusReason:    reason,
+			StatusChangedAt: &now,
+		}, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// DefaultBalancePolicy is the configured policy for accounts of the given type
+func DefaultBalancePolicy(accountType model.AccountType) model.BalancePolicy {
+	var policy config.BalancePolicy
+
+	switch accountType {
+	case model.AccountTypeChecking:
+		policy = config.GetConfig().AccountPolicies.Checking
+	case model.AccountTypeSaving:
+		policy = config.GetConfig().AccountPolicies.Savings
 	}
 
-	result := db.WithContext(ctx).Model(&model.Account{}).Where("account_number = ?", accountNumber).Updates(account)
-	if result.Error != nil {
-		return fmt.Errorf("failed to update account balance: %w", result.Error)
+	return model.BalancePolicy{
+		MinimumBalance: configDecimal("minimum_balance", policy.MinimumBalance),
+		OverdraftLimit: configDecimal("overdraft_limit", policy.OverdraftLimit),
+	}
+}
+
+// configDecimal parses an amount from the config. Unset amounts are zero, and
+// so are invalid ones after a warning.
+func configDecimal(name string, value string) decimal.Decimal {
+	if value == "" {
+		return decimal.Zero
+	}
+
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q in config, using 0", name, value)
+		return decimal.Zero
+	}
+
+	return amount
+}
+
+// BalancePolicy resolves the policy in effect for the account, its own
+// overrides take precedence over the default of its account type
+func (accService *AccountService) BalancePolicy(account *model.Account) model.BalancePolicy {
+	policy := DefaultBalancePolicy(account.AccountType)
+
+	if account.MinimumBalance != nil {
+		policy.MinimumBalance = *account.MinimumBalance
+	}
+
+	if account.OverdraftLimit != nil {
+		policy.OverdraftLimit = *account.OverdraftLimit
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("failed to find account with number %s for balance update", accountNumber)
+	return policy
+}
+
+// CheckDebit verifies that taking amount from the account keeps it within its
+// balance policy. Callers moving money must hold the account's row lock.
+func (accService *AccountService) CheckDebit(account *model.Account, amount decimal.Decimal) error {
+	floor := accService.BalancePolicy(account).Floor()
+
+	if account.AvailableBalance().Sub(amount).LessThan(floor) {
+		return fmt.Errorf("%w: available %s, may not go below %s", ErrInsufficientFunds, account.AvailableBalance().String(), floor.String())
 	}
 
 	return nil
 }
+
+func (accService *AccountService) UpdateBalancePolicy(ctx context.Context, accountNumber string, minimumBalance *decimal.Decimal, overdraftLimit *decimal.Decimal) (*model.Account, error) {
+	if overdraftLimit != nil && overdraftLimit.IsNegative() {
+		return nil, errors.New("overdraft_limit must not be negative")
+	}
+
+	if err := accService.accRepo.UpdateBalancePolicy(ctx, accountNumber, minimumBalance, overdraftLimit); err != nil {
+		return nil, err
+	}
+
+	return accService.accRepo.GetByAccountNumber(ctx, accountNumber)
+}
+
+// UpdateHeldBalance sets the total amount reserved by active holds on the account
+func (accService *AccountService) UpdateHeldBalance(ctx context.Context, accountNumber string, heldBalance decimal.Decimal, tx repository.Tx) error {
+	return accService.accRepo.UpdateHeldBalance(ctx, accountNumber, heldBalance, tx)
+}
+
+func (accService *AccountService) UpdateBalance(ctx context.Context, accountNumber string, newBalance decimal.Decimal, tx repository.Tx) error {
+	return accService.accRepo.UpdateBalance(ctx, accountNumber, newBalance, tx)
+}
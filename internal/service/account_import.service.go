@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"golang-exercise/internal/accountimport"
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+)
+
+const DEFAULT_IMPORT_CHUNK_SIZE = 100
+
+type ImportOptions struct {
+	// DryRun validates every row without creating anything
+	DryRun    bool
+	ChunkSize int
+}
+
+type ImportRowError struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+type ImportedAccount struct {
+	Line          int    `json:"line"`
+	AccountNumber string `json:"account_number"`
+}
+
+type ImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Rows     int               `json:"rows"`
+	Valid    int               `json:"valid"`
+	Created  int               `json:"created"`
+	Failed   int               `json:"failed"`
+	Accounts []ImportedAccount `json:"accounts"`
+	Errors   []ImportRowError  `json:"errors"`
+}
+
+func (report *ImportReport) fail(line int, errs ...string) {
+	report.Failed++
+	report.Errors = append(report.Errors, ImportRowError{Line: line, Errors: errs})
+}
+
+type importRow struct {
+	line    int
+	account *model.Account
+}
+
+// ImportAccounts opens an account for every valid row of reader. Invalid rows
+// are reported and skipped, the valid ones are created a chunk per database
+// transaction. A chunk that fails is retried row by row, so one bad row only
+// fails itself. A malformed file stops the import with an error, the report
+// then covers the rows before it.
+func (accService *AccountService) ImportAccounts(ctx context.Context, reader *accountimport.Reader, opts ImportOptions) (*ImportReport, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DEFAULT_IMPORT_CHUNK_SIZE
+	}
+
+	report := &ImportReport{
+		DryRun:   opts.DryRun,
+		Accounts: []ImportedAccount{},
+		Errors:   []ImportRowError{},
+	}
+	chunk := make([]importRow, 0, chunkSize)
+
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to read the file: %w", err)
+		}
+
+		report.Rows++
+
+		if row.Valid() {
+			row.Errors = validateImportedAccount(row)
+		}
+
+		if !row.Valid() {
+			report.fail(row.Line, row.Errors...)
+			continue
+		}
+
+		report.Valid++
+		if opts.DryRun {
+			continue
+		}
+
+		account, err := accService.newAccount(ctx, row.Account)
+		if err != nil {
+			report.fail(row.Line, err.Error())
+			continue
+		}
+
+		chunk = append(chunk, importRow{line: row.Line, account: account})
+		if len(chunk) == chunkSize {
+			accService.importChunk(ctx, chunk, report)
+			chunk = chunk[:0]
+		}
+	}
+
+	if len(chunk) > 0 {
+		accService.importChunk(ctx, chunk, report)
+	}
+
+	return report, nil
+}
+
+// validateImportedAccount applies the rules the account endpoint enforces
+func validateImportedAccount(row *accountimport.Row) []string {
+	errs := []string{}
+
+	if row.Account.AccountType != model.AccountTypeChecking && row.Account.AccountType != model.AccountTypeSaving {
+		errs = append(errs, fmt.Sprintf("account_type must be %s or %s", model.AccountTypeChecking, model.AccountTypeSaving))
+	}
+
+	if !IsValidCurrency(row.Account.Currency) {
+		errs = append(errs, "currency must be a three letter code")
+	}
+
+	if row.Account.InitialBalance.IsNegative() {
+		errs = append(errs, "initial_balance must not be negative")
+	}
+
+	return errs
+}
+
+func (accService *AccountService) importChunk(ctx context.Context, chunk []importRow, report *ImportReport) {
+	err := repository.Transaction(ctx, accService.accRepo, func(tx repository.Tx) error {
+		for _, row := range chunk {
+			if err := accService.openAccount(ctx, row.account, tx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		for _, row := range chunk {
+			accService.imported(ctx, row, report)
+		}
+		return
+	}
+
+	log.Printf("Failed to import a chunk of %d accounts, retrying them one by one: %v", len(chunk), err)
+
+	for _, row := range chunk {
+		// Set by the rolled back insert
+		row.account.ID = 0
+
+		err := repository.Transaction(ctx, accService.accRepo, func(tx repository.Tx) error {
+			return accService.openAccount(ctx, row.account, tx)
+		})
+		if err != nil {
+			report.fail(row.line, fmt.Sprintf("failed to create the account: %v", err))
+			continue
+		}
+
+		accService.imported(ctx, row, report)
+	}
+}
+
+// imported logs the opening balance of a committed account and reports it
+func (accService *AccountService) imported(ctx context.Context, row importRow, report *ImportReport) {
+	if !row.account.Balance.IsZero() {
+		accService.logOpeningBalance(ctx, openingTransactionID(row.account), row.account)
+	}
+
+	report.Created++
+	report.Accounts = append(report.Accounts, ImportedAccount{Line: row.line, AccountNumber: row.account.AccountNumber})
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBatchNotFound = errors.New("batch not found")
+	ErrInvalidBatch  = errors.New("invalid batch")
+)
+
+const MAX_BATCH_ITEMS = 1000
+
+type BatchStatus string
+
+const (
+	BatchStatusInProgress            BatchStatus = "IN_PROGRESS"
+	BatchStatusCompleted             BatchStatus = "COMPLETED"
+	BatchStatusCompletedWithFailures BatchStatus = "COMPLETED_WITH_FAILURES"
+	BatchStatusFailed                BatchStatus = "FAILED"
+)
+
+// BatchItemError is why one item of a batch was rejected
+type BatchItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BatchValidationError lists every rejected item. None of the batch was queued.
+type BatchValidationError struct {
+	Items []BatchItemError
+}
+
+func (err *BatchValidationError) Error() string {
+	return fmt.Sprintf("%d of the batch items are invalid", len(err.Items))
+}
+
+type BatchItemProgress struct {
+	Item          model.TransactionBatchItem
+	Status        model.TransactionStatus
+	FailureReason string
+	ProcessedAt   *time.Time
+}
+
+// BatchProgress is a batch with the current outcome of each of its items
+type BatchProgress struct {
+	Batch     *model.TransactionBatch
+	Status    BatchStatus
+	Pending   int
+	Completed int
+	Failed    int
+	Items     []BatchItemProgress
+}
+
+type BatchService struct {
+	batchRepo      repository.BatchStore
+	accountService *AccountService
+	fxService      *FxService
+	feeService     *FeeService
+	outboxService  *OutboxService
+	txLogService   *TransactionLogService
+}
+
+func NewBatchService(batchRepo repository.BatchStore, accountService *AccountService, fxService *FxService, feeService *FeeService, outboxService *OutboxService, txLogService *TransactionLogService) *BatchService {
+	return &BatchService{
+		batchRepo:      batchRepo,
+		accountService: accountService,
+		fxService:      fxService,
+		feeService:     feeService,
+		outboxService:  outboxService,
+		txLogService:   txLogService,
+	}
+}
+
+type queuedItem struct {
+	item  model.TransactionBatchItem
+	txLog *model.TransactionLog
+	txMsg *dto.TransactionMessage
+}
+
+// CreateBatch validates every item first and fails with a BatchValidationError
+// listing all rejected items. A valid batch is written together with the outbox
+// message of every item, so either all of them are queued or none.
+func (s *BatchService) CreateBatch(ctx context.Context, req *requestdto.CreateBatch) (*model.TransactionBatch, []model.TransactionBatchItem, error) {
+	if len(req.Items) == 0 {
+		return nil, nil, fmt.Errorf("%w: batch has no items", ErrInvalidBatch)
+	}
+
+	if len(req.Items) > MAX_BATCH_ITEMS {
+		return nil, nil, fmt.Errorf("%w: batch has %d items, at most %d are allowed", ErrInvalidBatch, len(req.Items), MAX_BATCH_ITEMS)
+	}
+
+	now := time.Now()
+	batchID := "BATCH_" + uuid.New().String()
+
+	queued := make([]queuedItem, 0, len(req.Items))
+	invalid := []BatchItemError{}
+	accounts := map[string]*model.Account{}
+
+	// Withdrawals are checked against the balance left by the earlier items of
+	// the same account, which the worker processes first
+	debits := map[string]decimal.Decimal{}
+
+	for i, itemReq := range req.Items {
+		item, err := s.prepareItem(ctx, accounts, debits, &itemReq)
+		if err != nil {
+			invalid = append(invalid, BatchItemError{Index: i, Error: err.Error()})
+			continue
+		}
+
+		item.item.BatchId = batchID
+		item.item.ItemIndex = i
+		item.item.TransactionId = fmt.Sprintf("TXN_%d_%d", now.UnixNano(), i)
+		item.txLog.TransactionId = item.item.TransactionId
+		item.txMsg.ID = item.item.TransactionId
+		queued = append(queued, *item)
+	}
+
+	if len(invalid) > 0 {
+		return nil, nil, &BatchValidationError{Items: invalid}
+	}
+
+	batch := &model.TransactionBatch{
+		BatchId:   batchID,
+		Reference: req.Reference,
+		ItemCount: len(queued),
+	}
+
+	items := make([]model.TransactionBatchItem, len(queued))
+	for i := range queued {
+		items[i] = queued[i].item
+	}
+
+	err := repository.Transaction(ctx, s.batchRepo, func(tx repository.Tx) error {
+		if err := s.batchRepo.Create(ctx, batch, items, tx); err != nil {
+			return err
+		}
+
+		// The relay logs and publishes each item once the batch is committed
+		for _, item := range queued {
+			if err := s.outboxService.EnqueueTransaction(ctx, item.txLog, item.txMsg, tx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to queue batch: %w", err)
+	}
+
+	return batch, items, nil
+}
+
+// prepareItem validates a single deposit or withdrawal like ProcessFunds does
+// and builds its transaction log and message
+func (s *BatchService) prepareItem(ctx context.Context, accounts map[string]*model.Account, debits map[string]decimal.Decimal, req *requestdto.MoveMoneyFromAccount) (*queuedItem, error) {
+	if req.Type != model.TransactionTypeDeposit && req.Type != model.TransactionTypeWithdrawal {
+		return nil, errors.New("type must be DEPOSIT or WITHDRAWAL")
+	}
+
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	if req.Currency != "" && !IsValidCurrency(req.Currency) {
+		return nil, errors.New("currency must be a three letter code")
+	}
+
+	account, ok := accounts[req.AccountNumber]
+	if !ok {
+		var err error
+		account, err = s.accountService.GetAccount(ctx, &requestdto.GetAccount{AccountNumber: req.AccountNumber})
+		if err != nil {
+			return nil, fmt.Errorf("account %s not found", req.AccountNumber)
+		}
+		accounts[req.AccountNumber] = account
+	}
+
+	if err := CheckAccountActivity(account, req.Type == model.TransactionTypeWithdrawal); err != nil {
+		return nil, err
+	}
+
+	// Money in another currency is converted into the account currency up front
+	amount := req.Amount
+	var conversion *model.FxConversion
+	if req.Currency != "" && req.Currency != account.Currency {
+		var err error
+		conversion, err = s.fxService.Convert(ctx, req.Amount, req.Currency, account.Currency, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		amount = conversion.TargetAmount
+	}
+
+	// The fee is taken from the same funds, a deposit fee above the deposit
+	// takes the difference from the balance
+	fee := s.feeService.Quote(account.AccountType, req.Type, amount)
+	debit := debits[account.AccountNumber].Add(fee)
+	if req.Type == model.TransactionTypeWithdrawal {
+		debit = debit.Add(amount)
+	} else {
+		debit = debit.Sub(amount)
+	}
+
+	if req.Type == model.TransactionTypeWithdrawal || fee.GreaterThan(amount) {
+		if err := s.accountService.CheckDebit(account, debit); err != nil {
+			return nil, err
+		}
+	}
+	debits[account.AccountNumber] = debit
+
+	txLog := &model.TransactionLog{
+		FromAccountId: account.ID,
+		ToAccountId:   account.ID,
+		Amount:        amount,
+		Currency:      account.Currency,
+		Type:          req.Type,
+		Status:        model.TransactionStatusInprogress,
+		Memo:          req.Memo,
+		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:     time.Now(),
+	}
+	txLog.SetFxConversion(conversion)
+
+	return &queuedItem{
+		item: model.TransactionBatchItem{
+			AccountNumber: account.AccountNumber,
+			Type:          req.Type,
+			Amount:        amount,
+			Currency:      account.Currency,
+			Memo:          req.Memo,
+		},
+		txLog: txLog,
+		txMsg: &dto.TransactionMessage{
+			Type:          req.Type,
+			AccountNumber: account.AccountNumber,
+			Amount:        amount,
+			Currency:      account.Currency,
+			Description:   req.Memo,
+			Fx:            conversion,
+			CreatedAt:     time.Now(),
+		},
+	}, nil
+}
+
+// GetBatchProgress reads the outcome of every item from its transaction log.
+// Items the relay has not logged yet count as pending.
+func (s *BatchService) GetBatchProgress(ctx context.Context, batchID string) (*BatchProgress, error) {
+	batch, err := s.batchRepo.GetByBatchID(ctx, batchID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.batchRepo.ListItems(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactionIDs := make([]string, len(items))
+	for i, item := range items {
+		transactionIDs[i] = item.TransactionId
+	}
+
+	logs, err := s.txLogService.GetTransactionsByIDs(ctx, transactionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch transactions: %w", err)
+	}
+
+	byID := make(map[string]model.TransactionLog, len(logs))
+	for _, txLog := range logs {
+		byID[txLog.TransactionId] = txLog
+	}
+
+	progress := &BatchProgress{
+		Batch: batch,
+		Items: make([]BatchItemProgress, len(items)),
+	}
+
+	for i, item := range items {
+		itemProgress := BatchItemProgress{Item: item, Status: model.TransactionStatusPending}
+		if txLog, ok := byID[item.TransactionId]; ok {
+			itemProgress.Status = txLog.Status
+			itemProgress.FailureReason = txLog.FailureReason
+			itemProgress.ProcessedAt = txLog.ProcessedAt
+		}
+
+		switch itemProgress.Status {
+		case model.TransactionStatusCompleted:
+			progress.Completed++
+		case model.TransactionStatusFailed:
+			progress.Failed++
+		default:
+			progress.Pending++
+		}
+
+		progress.Items[i] = itemProgress
+	}
+
+	switch {
+	case progress.Pending > 0:
+		progress.Status = BatchStatusInProgress
+	case progress.Failed == 0:
+		progress.Status = BatchStatusCompleted
+	case progress.Completed == 0:
+		progress.Status = BatchStatusFailed
+	default:
+		progress.Status = BatchStatusCompletedWithFailures
+	}
+
+	return progress, nil
+}
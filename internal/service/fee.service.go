@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-exercise/config"
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/fee"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+type FeeService struct {
+	feeRepo        repository.FeeStore
+	schedule       fee.Schedule
+	accountService *AccountService
+	ledgerService  *LedgerService
+	txLogService   *TransactionLogService
+}
+
+func NewFeeService(feeRepo repository.FeeStore, accountService *AccountService, ledgerService *LedgerService, txLogService *TransactionLogService, settings config.Fees) *FeeService {
+	var schedule fee.Schedule
+
+	for _, rule := range settings.TransactionFees {
+		schedule.TransactionFees = append(schedule.TransactionFees, fee.TransactionFee{
+			AccountType:     model.AccountType(rule.AccountType),
+			TransactionType: model.TransactionType(rule.TransactionType),
+			Flat:            configDecimal("transaction fee flat", rule.Flat),
+			Percentage:      configDecimal("transaction fee percentage", rule.Percentage),
+			Min:             configDecimal("transaction fee min", rule.Min),
+			Max:             configDecimal("transaction fee max", rule.Max),
+		})
+	}
+
+	for _, rule := range settings.MaintenanceFees {
+		schedule.MaintenanceFees = append(schedule.MaintenanceFees, fee.MaintenanceFee{
+			AccountType: model.AccountType(rule.AccountType),
+			Amount:      configDecimal("maintenance fee amount", rule.Amount),
+			WaiveAbove:  configDecimal("maintenance fee waive_above", rule.WaiveAbove),
+		})
+	}
+
+	return &FeeService{
+		feeRepo:        feeRepo,
+		schedule:       schedule,
+		accountService: accountService,
+		ledgerService:  ledgerService,
+		txLogService:   txLogService,
+	}
+}
+
+// SystemFeeAccount is the income account fees charged to customers are booked against
+func SystemFeeAccount(currency string) string {
+	return fmt.Sprintf("%sFEES_%s", model.SystemAccountPrefix, currency)
+}
+
+// FeeTransactionID derives the id of the fee charged for a transaction, so a
+// replayed message can never charge it twice
+func FeeTransactionID(transactionID string) string {
+	return fmt.Sprintf("FEE_%s", transactionID)
+}
+
+func (s *FeeService) Schedule() *fee.Schedule {
+	return &s.schedule
+}
+
+// Quote prices the fee of a transaction without charging it
+func (s *FeeService) Quote(accountType model.AccountType, transactionType model.TransactionType, amount decimal.Decimal) decimal.Decimal {
+	return s.schedule.Quote(accountType, transactionType, amount)
+}
+
+// ChargeFee debits amount from the locked account as its own journal entry
+// inside tx. account.Balance must be current and is updated. The returned log
+// is written with RecordFee once tx commits.
+func (s *FeeService) ChargeFee(ctx context.Context, account *model.Account, transactionID string, amount decimal.Decimal, memo string, metadata map[string]any, tx repository.Tx) (*model.TransactionLog, error) {
+	newBalance := account.Balance.Sub(amount)
+	if err := s.accountService.UpdateBalance(ctx, account.AccountNumber, newBalance, tx); err != nil {
+		return nil, err
+	}
+
+	entry := NewJournalEntry(transactionID, model.TransactionTypeFee, amount, account.Currency, memo,
+		Leg(account.AccountNumber, amount.Neg(), account.Currency),
+		Leg(SystemFeeAccount(account.Currency), amount, account.Currency),
+	)
+
+	if err := s.ledgerService.PostEntry(ctx, entry, tx); err != nil {
+		return nil, err
+	}
+
+	account.Balance = newBalance
+
+	processedAt := time.Now()
+	return &model.TransactionLog{
+		TransactionId: transactionID,
+		FromAccountId: account.ID,
+		ToAccountId:   account.ID,
+		Amount:        amount,
+		Currency:      account.Currency,
+		Type:          model.TransactionTypeFee,
+		Status:        model.TransactionStatusCompleted,
+		Memo:          memo,
+		Metadata:      metadata,
+		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:     processedAt,
+		ProcessedAt:   &processedAt,
+	}, nil
+}
+
+// ChargeTransactionFee charges the scheduled fee for the transaction, if any
+func (s *FeeService) ChargeTransactionFee(ctx context.Context, account *model.Account, transactionID string, transactionType model.TransactionType, amount decimal.Decimal, tx repository.Tx) (*model.TransactionLog, error) {
+	charge := s.Quote(account.AccountType, transactionType, amount)
+	if !charge.IsPositive() {
+		return nil, nil
+	}
+
+	feeLog, err := s.ChargeFee(ctx, account, FeeTransactionID(transactionID), charge,
+		fmt.Sprintf("%s fee", transactionType), map[string]any{"transaction_type": transactionType}, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	feeLog.ParentTransactionId = transactionID
+	return feeLog, nil
+}
+
+// RecordFee logs a committed fee and links it to the transaction it was charged for
+func (s *FeeService) RecordFee(ctx context.Context, feeLog *model.TransactionLog) {
+	if feeLog == nil {
+		return
+	}
+
+	// The fee is committed, so a failed log write must not fail the transaction
+	if err := s.txLogService.EnsureLogged(ctx, feeLog); err != nil {
+		log.Printf("Failed to log fee %s: %v", feeLog.TransactionId, err)
+	}
+
+	if feeLog.ParentTransactionId == "" {
+		return
+	}
+
+	if err := s.txLogService.LinkFee(ctx, feeLog.ParentTransactionId, feeLog.TransactionId, feeLog.Amount); err != nil {
+		log.Printf("Failed to link fee %s to transaction %s: %v", feeLog.TransactionId, feeLog.ParentTransactionId, err)
+	}
+}
+
+// ChargeMaintenanceDue decides the monthly maintenance fee of the current month
+// for every open account that has no decision yet. The waiver is judged on the
+// balance at that moment and recorded, so the fee is charged at most once.
+// Frozen accounts are charged once they are active again within the month.
+func (s *FeeService) ChargeMaintenanceDue(ctx context.Context) error {
+	period := time.Now().UTC().Format("200601")
+	failed := 0
+
+	for index := range s.schedule.MaintenanceFees {
+		rule := &s.schedule.MaintenanceFees[index]
+
+		accountNumbers, err := s.accountService.ListOpenAccountNumbers(ctx, rule.AccountType)
+		if err != nil {
+			return err
+		}
+
+		for _, accountNumber := range accountNumbers {
+			if err := s.chargeMaintenance(ctx, accountNumber, rule, period); err != nil {
+				log.Printf("Failed to charge maintenance fee to account %s: %v", accountNumber, err)
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("maintenance fee failed for %d accounts", failed)
+	}
+
+	return nil
+}
+
+func (s *FeeService) chargeMaintenance(ctx context.Context, accountNumber string, rule *fee.MaintenanceFee, period string) error {
+	if charged, err := s.feeRepo.HasMaintenanceCharge(ctx, accountNumber, period, nil); charged || err != nil {
+		return err
+	}
+
+	var feeLog *model.TransactionLog
+
+	err := repository.Transaction(ctx, s.feeRepo, func(tx repository.Tx) error {
+		account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return err
+		}
+
+		if CheckAccountActivity(account, true) != nil {
+			return nil
+		}
+
+		charge := &model.MaintenanceCharge{
+			AccountNumber: account.AccountNumber,
+			Period:        period,
+			Amount:        rule.Charge(account.Balance),
+		}
+		charge.Waived = !charge.Amount.IsPositive()
+		if !charge.Waived {
+			charge.TransactionId = fmt.Sprintf("MAINT_%s_%s", account.AccountNumber, period)
+		}
+
+		// Another worker may have decided the period meanwhile
+		created, err := s.feeRepo.CreateMaintenanceCharge(ctx, charge, tx)
+		if err != nil || !created || charge.Waived {
+			return err
+		}
+
+		feeLog, err = s.ChargeFee(ctx, account, charge.TransactionId, charge.Amount,
+			fmt.Sprintf("Monthly maintenance fee %s", period), map[string]any{"period": period}, tx)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.RecordFee(ctx, feeLog)
+	return nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var ErrNoFxRate = errors.New("no fx rate in effect for currency pair")
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Converted amounts are rounded to the minor unit (cents)
+const FX_AMOUNT_PLACES = 2
+
+type FxService struct {
+	fxRateRepo repository.FxRateStore
+}
+
+func NewFxService(fxRateRepo repository.FxRateStore) *FxService {
+	return &FxService{
+		fxRateRepo: fxRateRepo,
+	}
+}
+
+// IsValidCurrency checks for an ISO 4217 style three letter upper case code
+func IsValidCurrency(currency string) bool {
+	return currencyPattern.MatchString(currency)
+}
+
+// SystemFxAccount is the position account the bank uses to exchange currency
+func SystemFxAccount(currency string) string {
+	return fmt.Sprintf("%sFX_%s", model.SystemAccountPrefix, currency)
+}
+
+// FxLegs books the exchange of conversion through the fx position accounts so
+// each currency balances on its own
+func FxLegs(conversion *model.FxConversion) []model.Posting {
+	return []model.Posting{
+		Leg(SystemFxAccount(conversion.SourceCurrency), conversion.SourceAmount, conversion.SourceCurrency),
+		Leg(SystemFxAccount(conversion.TargetCurrency), conversion.TargetAmount.Neg(), conversion.TargetCurrency),
+	}
+}
+
+func (s *FxService) LoadRates(ctx context.Context, rates []model.FxRate) error {
+	if len(rates) == 0 {
+		return errors.New("no rates given")
+	}
+
+	for index, rate := range rates {
+		if !IsValidCurrency(rate.BaseCurrency) || !IsValidCurrency(rate.QuoteCurrency) {
+			return fmt.Errorf("rate %d has an invalid currency", index)
+		}
+
+		if rate.BaseCurrency == rate.QuoteCurrency {
+			return fmt.Errorf("rate %d converts %s into itself", index, rate.BaseCurrency)
+		}
+
+		if !rate.Rate.IsPositive() {
+			return fmt.Errorf("rate %d must be greater than zero", index)
+		}
+
+		if rate.EffectiveAt.IsZero() {
+			rates[index].EffectiveAt = time.Now()
+		}
+	}
+
+	return s.fxRateRepo.Upsert(ctx, rates)
+}
+
+func (s *FxService) ListRates(ctx context.Context, baseCurrency string, quoteCurrency string, limit int) ([]model.FxRate, error) {
+	return s.fxRateRepo.List(ctx, baseCurrency, quoteCurrency, limit)
+}
+
+// GetRate returns the rate in effect at the given time. When only the opposite
+// pair is loaded its inverse is used.
+func (s *FxService) GetRate(ctx context.Context, sourceCurrency string, targetCurrency string, at time.Time) (decimal.Decimal, error) {
+	if sourceCurrency == targetCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rate, err := s.fxRateRepo.GetEffective(ctx, sourceCurrency, targetCurrency, at)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Zero, fmt.Errorf("failed to look up fx rate %s/%s: %w", sourceCurrency, targetCurrency, err)
+	}
+
+	// Only a missing pair falls back to its inverse, a failed lookup is not a missing rate
+	inverse, err := s.fxRateRepo.GetEffective(ctx, targetCurrency, sourceCurrency, at)
+	if err == nil {
+		return decimal.NewFromInt(1).DivRound(inverse.Rate, 10), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Zero, fmt.Errorf("failed to look up fx rate %s/%s: %w", targetCurrency, sourceCurrency, err)
+	}
+
+	return decimal.Zero, fmt.Errorf("%w: %s/%s", ErrNoFxRate, sourceCurrency, targetCurrency)
+}
+
+// Convert prices amount of sourceCurrency in targetCurrency at the rate in effect
+// at the given time. It returns nil when no conversion is needed.
+func (s *FxService) Convert(ctx context.Context, amount decimal.Decimal, sourceCurrency string, targetCurrency string, at time.Time) (*model.FxConversion, error) {
+	if sourceCurrency == targetCurrency {
+		return nil, nil
+	}
+
+	rate, err := s.GetRate(ctx, sourceCurrency, targetCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.FxConversion{
+		SourceAmount:   amount,
+		SourceCurrency: sourceCurrency,
+		TargetAmount:   amount.Mul(rate).Round(FX_AMOUNT_PLACES),
+		TargetCurrency: targetCurrency,
+		Rate:           rate,
+	}, nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrHoldNotActive = errors.New("hold is no longer active")
+	ErrHoldNotFound  = errors.New("hold not found")
+)
+
+type HoldService struct {
+	holdRepo       repository.HoldStore
+	accountService *AccountService
+}
+
+const DEFAULT_HOLD_DURATION = 7 * 24 * time.Hour
+
+func NewHoldService(holdRepo repository.HoldStore, accountService *AccountService) *HoldService {
+	return &HoldService{
+		holdRepo:       holdRepo,
+		accountService: accountService,
+	}
+}
+
+// ReleaseExpired expires the overdue holds of a locked account and returns the
+// reserved amount to its available balance. Callers must hold the account lock.
+func (s *HoldService) ReleaseExpired(ctx context.Context, account *model.Account, tx repository.Tx) error {
+	now := time.Now()
+
+	holds, err := s.holdRepo.ListExpired(ctx, account.AccountNumber, now, tx)
+	if err != nil {
+		return fmt.Errorf("failed to load expired holds: %w", err)
+	}
+
+	if len(holds) == 0 {
+		return nil
+	}
+
+	heldBalance := account.HeldBalance
+	for _, hold := range holds {
+		hold.Status = model.HoldExpired
+		hold.ReleasedAt = &now
+
+		if err := s.holdRepo.Save(ctx, &hold, tx); err != nil {
+			return err
+		}
+
+		heldBalance = heldBalance.Sub(hold.Amount)
+	}
+
+	if err := s.accountService.UpdateHeldBalance(ctx, account.AccountNumber, heldBalance, tx); err != nil {
+		return err
+	}
+
+	account.HeldBalance = heldBalance
+	return nil
+}
+
+// PlaceHold reserves amount on the account if enough funds are available
+func (s *HoldService) PlaceHold(ctx context.Context, accountNumber string, amount decimal.Decimal, expiresAt time.Time, memo string) (*model.Hold, error) {
+	var hold *model.Hold
+
+	err := repository.Transaction(ctx, s.holdRepo, func(tx repository.Tx) error {
+		account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return fmt.Errorf("account not found or could not be locked: %w", err)
+		}
+
+		if account.AccountStatus != model.AccountActive {
+			return fmt.Errorf("account is %s", account.AccountStatus)
+		}
+
+		if err := s.ReleaseExpired(ctx, account, tx); err != nil {
+			return err
+		}
+
+		if err := s.accountService.CheckDebit(account, amount); err != nil {
+			return err
+		}
+
+		hold = &model.Hold{
+			HoldId:        "HOLD_" + uuid.New().String(),
+			AccountNumber: account.AccountNumber,
+			Amount:        amount,
+			Currency:      account.Currency,
+			Status:        model.HoldActive,
+			Memo:          memo,
+			ExpiresAt:     expiresAt,
+		}
+
+		if err := s.holdRepo.Create(ctx, hold, tx); err != nil {
+			return err
+		}
+
+		return s.accountService.UpdateHeldBalance(ctx, account.AccountNumber, account.HeldBalance.Add(amount), tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// VoidHold releases an active hold without moving any money
+func (s *HoldService) VoidHold(ctx context.Context, accountNumber string, holdID string) (*model.Hold, error) {
+	var hold *model.Hold
+
+	err := repository.Transaction(ctx, s.holdRepo, func(tx repository.Tx) error {
+		account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return fmt.Errorf("account not found or could not be locked: %w", err)
+		}
+
+		if err := s.ReleaseExpired(ctx, account, tx); err != nil {
+			return err
+		}
+
+		hold, err = s.LockHold(ctx, account, holdID, tx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		hold.Status = model.HoldVoided
+		hold.ReleasedAt = &now
+
+		if err := s.holdRepo.Save(ctx, hold, tx); err != nil {
+			return err
+		}
+
+		return s.accountService.UpdateHeldBalance(ctx, account.AccountNumber, account.HeldBalance.Sub(hold.Amount), tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// LockHold locks an active hold of the locked account
+func (s *HoldService) LockHold(ctx context.Context, account *model.Account, holdID string, tx repository.Tx) (*model.Hold, error) {
+	hold, err := s.holdRepo.GetForUpdate(ctx, holdID, tx)
+	if err != nil || hold.AccountNumber != account.AccountNumber {
+		return nil, ErrHoldNotFound
+	}
+
+	if hold.Status != model.HoldActive {
+		return nil, ErrHoldNotActive
+	}
+
+	return hold, nil
+}
+
+// SaveCapture marks the locked hold as captured by the given transaction
+func (s *HoldService) SaveCapture(ctx context.Context, hold *model.Hold, amount decimal.Decimal, transactionID string, tx repository.Tx) error {
+	now := time.Now()
+	hold.Status = model.HoldCaptured
+	hold.CapturedAmount = amount
+	hold.CaptureTransactionId = transactionID
+	hold.ReleasedAt = &now
+
+	return s.holdRepo.Save(ctx, hold, tx)
+}
+
+func (s *HoldService) GetHold(ctx context.Context, accountNumber string, holdID string) (*model.Hold, error) {
+	hold, err := s.holdRepo.GetByHoldID(ctx, holdID)
+	if err != nil || hold.AccountNumber != accountNumber {
+		return nil, ErrHoldNotFound
+	}
+
+	return hold, nil
+}
+
+func (s *HoldService) ListHolds(ctx context.Context, accountNumber string, status string) ([]model.Hold, error) {
+	return s.holdRepo.ListByAccount(ctx, accountNumber, status)
+}
+
+// ExpireDueHolds sweeps accounts with overdue holds so their available balance
+// is correct even when nothing else touches the account
+func (s *HoldService) ExpireDueHolds(ctx context.Context) error {
+	accountNumbers, err := s.holdRepo.ListAccountsWithExpiredHolds(ctx, time.Now(), 100)
+	if err != nil {
+		return err
+	}
+
+	for _, accountNumber := range accountNumbers {
+		err := repository.Transaction(ctx, s.holdRepo, func(tx repository.Tx) error {
+			account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+			if err != nil {
+				return err
+			}
+
+			return s.ReleaseExpired(ctx, account, tx)
+		})
+
+		if err != nil {
+			log.Printf("Failed to expire holds of account %s: %v", accountNumber, err)
+		}
+	}
+
+	return nil
+}
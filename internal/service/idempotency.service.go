@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+type IdempotencyService struct {
+	idempotencyRepo repository.IdempotencyStore
+	reservationTTL  time.Duration
+}
+
+// NewIdempotencyService creates the service. A reservation whose request has
+// not finished within reservationTTL is considered abandoned.
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyStore, reservationTTL time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		reservationTTL:  reservationTTL,
+	}
+}
+
+// Fingerprint hashes everything that identifies a request for replay purposes
+func Fingerprint(method string, path string, body []byte) string {
+	hash := NewFingerprintHash(method, path)
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// NewFingerprintHash starts the fingerprint of a request whose body is then
+// written to it, so large bodies can be hashed as they stream
+func NewFingerprintHash(method string, path string) hash.Hash {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+
+	return hash
+}
+
+// Begin claims the key for a new request. When the key was seen before it
+// returns the stored record so the original response can be replayed, or an
+// error if the key is reused with another request or is still in flight. A
+// reservation left behind by a request that never finished is taken over once
+// it is older than the reservation TTL.
+func (s *IdempotencyService) Begin(ctx context.Context, idempotencyKey string, scope string, fingerprint string) (*model.IdempotencyKey, bool, error) {
+	key := &model.IdempotencyKey{
+		IdempotencyKey: idempotencyKey,
+		Scope:          scope,
+		Fingerprint:    fingerprint,
+	}
+
+	created, err := s.idempotencyRepo.Reserve(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		return key, false, nil
+	}
+
+	existing, err := s.idempotencyRepo.Get(ctx, idempotencyKey, scope)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, false, ErrIdempotencyKeyReused
+	}
+
+	if !existing.IsCompleted() {
+		tookOver, err := s.idempotencyRepo.TakeOver(ctx, existing.ID, time.Now().Add(-s.reservationTTL))
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !tookOver {
+			return nil, false, ErrIdempotencyKeyInProgress
+		}
+
+		return existing, false, nil
+	}
+
+	return existing, true, nil
+}
+
+// Complete stores the response so retries with the same key receive it again
+func (s *IdempotencyService) Complete(ctx context.Context, key *model.IdempotencyKey, status int, body []byte) error {
+	return s.idempotencyRepo.SaveResponse(ctx, key.ID, status, string(body))
+}
+
+// Release forgets the key so the client may retry, used when the request failed
+// before anything was committed
+func (s *IdempotencyService) Release(ctx context.Context, key *model.IdempotencyKey) error {
+	return s.idempotencyRepo.Delete(ctx, key.ID)
+}
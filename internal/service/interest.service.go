@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-exercise/config"
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/interest"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+const DEFAULT_INTEREST_ACCRUALS_LIMIT = 31
+
+type InterestService struct {
+	interestRepo   repository.InterestStore
+	accountService *AccountService
+	ledgerService  *LedgerService
+	txLogService   *TransactionLogService
+	dayCount       interest.DayCount
+	tiers          []interest.Tier
+}
+
+func NewInterestService(interestRepo repository.InterestStore, accountService *AccountService, ledgerService *LedgerService, txLogService *TransactionLogService, settings config.Interest) *InterestService {
+	dayCount, err := interest.ParseDayCount(settings.DayCount)
+	if err != nil {
+		log.Printf("Warning: %v, using %s", err, interest.Actual365Fixed)
+		dayCount = interest.Actual365Fixed
+	}
+
+	tiers := make([]interest.Tier, 0, len(settings.SavingsTiers))
+	for _, tier := range settings.SavingsTiers {
+		tiers = append(tiers, interest.Tier{
+			MinBalance: configDecimal("interest tier min_balance", tier.MinBalance),
+			AnnualRate: configDecimal("interest tier annual_rate", tier.AnnualRate),
+		})
+	}
+
+	return &InterestService{
+		interestRepo:   interestRepo,
+		accountService: accountService,
+		ledgerService:  ledgerService,
+		txLogService:   txLogService,
+		dayCount:       dayCount,
+		tiers:          tiers,
+	}
+}
+
+// SystemInterestAccount is the expense account interest paid to customers is booked against
+func SystemInterestAccount(currency string) string {
+	return fmt.Sprintf("%sINTEREST_%s", model.SystemAccountPrefix, currency)
+}
+
+func (s *InterestService) RateFor(balance decimal.Decimal) decimal.Decimal {
+	return interest.RateFor(s.tiers, balance)
+}
+
+func (s *InterestService) ListAccruals(ctx context.Context, accountNumber string, limit int) ([]model.InterestAccrual, error) {
+	if limit <= 0 {
+		limit = DEFAULT_INTEREST_ACCRUALS_LIMIT
+	}
+
+	return s.interestRepo.ListAccruals(ctx, accountNumber, limit)
+}
+
+// AccrueDue accrues interest on every savings account through yesterday and
+// pays it out at each month end. Days missed while the worker was down are
+// caught up, an account never accrues the same day twice.
+func (s *InterestService) AccrueDue(ctx context.Context) error {
+	through := interest.Date(time.Now()).AddDate(0, 0, -1)
+
+	accountNumbers, err := s.interestRepo.ListAccountsDue(ctx, model.AccountTypeSaving, through)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, accountNumber := range accountNumbers {
+		if err := s.accrueAccount(ctx, accountNumber, through); err != nil {
+			log.Printf("Failed to accrue interest for account %s: %v", accountNumber, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("interest accrual failed for %d of %d accounts", failed, len(accountNumbers))
+	}
+
+	return nil
+}
+
+func (s *InterestService) accrueAccount(ctx context.Context, accountNumber string, through time.Time) error {
+	var payments []*model.TransactionLog
+
+	err := repository.Transaction(ctx, s.interestRepo, func(tx repository.Tx) error {
+		account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return err
+		}
+
+		start := interest.Date(account.CreatedAt)
+		if account.InterestAccruedThrough != nil {
+			start = interest.Date(*account.InterestAccruedThrough).AddDate(0, 0, 1)
+		}
+
+		if start.After(through) || account.AccountStatus == model.AccountClosed {
+			return nil
+		}
+
+		var accruals []model.InterestAccrual
+		accrued := account.AccruedInterest
+		newBalance := account.Balance
+		paidInRun := decimal.Zero
+
+		for day := start; !day.After(through); day = day.AddDate(0, 0, 1) {
+			// Each day accrues on its own end-of-day balance from the ledger. Interest
+			// paid earlier in this run is posted now, after the day, so it is added.
+			balance, err := s.ledgerService.GetBalanceAt(ctx, account.AccountNumber, day.AddDate(0, 0, 1))
+			if err != nil {
+				return err
+			}
+			balance = balance.Add(paidInRun)
+
+			rate := s.RateFor(balance)
+
+			if amount := interest.DailyAmount(balance, rate, day, s.dayCount); amount.IsPositive() {
+				accruals = append(accruals, model.InterestAccrual{
+					AccountNumber: account.AccountNumber,
+					AccrualDate:   day,
+					Balance:       balance,
+					AnnualRate:    rate,
+					Amount:        amount,
+				})
+				accrued = accrued.Add(amount)
+			}
+
+			if !interest.IsMonthEnd(day) {
+				continue
+			}
+
+			// Interest is paid on the last day of the month, fractions of a cent wait for the next one
+			paid, remainder := interest.Payable(accrued)
+			if paid.IsZero() {
+				continue
+			}
+
+			newBalance = newBalance.Add(paid)
+			payment, err := s.postInterest(ctx, account, day, paid, newBalance, tx)
+			if err != nil {
+				return err
+			}
+
+			payments = append(payments, payment)
+			accrued = remainder
+			paidInRun = paidInRun.Add(paid)
+		}
+
+		if err := s.interestRepo.CreateAccruals(ctx, accruals, tx); err != nil {
+			return err
+		}
+
+		return s.interestRepo.UpdateAccrued(ctx, account.AccountNumber, accrued, through, tx)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// The payments are committed, so a failed log write must not undo them
+	for _, payment := range payments {
+		if err := s.txLogService.EnsureLogged(ctx, payment); err != nil {
+			log.Printf("Failed to log interest payment %s: %v", payment.TransactionId, err)
+		}
+	}
+
+	return nil
+}
+
+// postInterest credits paid to the account for the month ending on monthEnd
+func (s *InterestService) postInterest(ctx context.Context, account *model.Account, monthEnd time.Time, paid decimal.Decimal, newBalance decimal.Decimal, tx repository.Tx) (*model.TransactionLog, error) {
+	period := monthEnd.Format("2006-01")
+	transactionID := fmt.Sprintf("INT_%s_%s", account.AccountNumber, monthEnd.Format("200601"))
+	memo := fmt.Sprintf("Interest for %s", period)
+
+	if err := s.accountService.UpdateBalance(ctx, account.AccountNumber, newBalance, tx); err != nil {
+		return nil, err
+	}
+
+	entry := NewJournalEntry(transactionID, model.TransactionTypeInterest, paid, account.Currency, memo,
+		Leg(account.AccountNumber, paid, account.Currency),
+		Leg(SystemInterestAccount(account.Currency), paid.Neg(), account.Currency),
+	)
+
+	if err := s.ledgerService.PostEntry(ctx, entry, tx); err != nil {
+		return nil, err
+	}
+
+	processedAt := time.Now()
+	return &model.TransactionLog{
+		TransactionId: transactionID,
+		FromAccountId: account.ID,
+		ToAccountId:   account.ID,
+		Amount:        paid,
+		Currency:      account.Currency,
+		Type:          model.TransactionTypeInterest,
+		Status:        model.TransactionStatusCompleted,
+		Memo:          memo,
+		Metadata:      map[string]any{"period": period},
+		InitiatedBy:   1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:     processedAt,
+		ProcessedAt:   &processedAt,
+	}, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const BALANCE_SNAPSHOT_SETTLE_DELAY = time.Hour
+
+// Amounts reversed on accounts are rounded to the minor unit (cents)
+const REVERSAL_AMOUNT_PLACES = 2
+
+type LedgerService struct {
+	ledgerRepo repository.LedgerStore
+}
+
+func NewLedgerService(ledgerRepo repository.LedgerStore) *LedgerService {
+	return &LedgerService{
+		ledgerRepo: ledgerRepo,
+	}
+}
+
+// SystemCashAccount is the counter-account for money entering or leaving the
+// bank through deposits and withdrawals.
+func SystemCashAccount(currency string) string {
+	return fmt.Sprintf("%sCASH_%s", model.SystemAccountPrefix, currency)
+}
+
+func IsSystemAccount(accountNumber string) bool {
+	return strings.HasPrefix(accountNumber, model.SystemAccountPrefix)
+}
+
+// NewJournalEntry builds an entry whose postings are the given legs
+func NewJournalEntry(transactionID string, transactionType model.TransactionType, amount decimal.Decimal, currency string, description string, legs ...model.Posting) *model.JournalEntry {
+	return &model.JournalEntry{
+		TransactionId:   transactionID,
+		TransactionType: transactionType,
+		Amount:          amount,
+		Currency:        currency,
+		Description:     description,
+		Postings:        legs,
+	}
+}
+
+// Leg is a shorthand for a single posting of an entry
+func Leg(accountNumber string, amount decimal.Decimal, currency string) model.Posting {
+	return model.Posting{
+		AccountNumber: accountNumber,
+		Amount:        amount,
+		Currency:      currency,
+	}
+}
+
+func (s *LedgerService) validateEntry(entry *model.JournalEntry) error {
+	if entry.TransactionId == "" {
+		return errors.New("journal entry requires a transaction id")
+	}
+
+	if len(entry.Postings) < 2 {
+		return errors.New("journal entry requires at least two postings")
+	}
+
+	totals := map[string]decimal.Decimal{}
+	for _, posting := range entry.Postings {
+		if posting.Amount.IsZero() {
+			return fmt.Errorf("posting against %s has zero amount", posting.AccountNumber)
+		}
+
+		totals[posting.Currency] = totals[posting.Currency].Add(posting.Amount)
+	}
+
+	for currency, total := range totals {
+		if !total.IsZero() {
+			return fmt.Errorf("journal entry %s is unbalanced by %s %s", entry.TransactionId, total.String(), currency)
+		}
+	}
+
+	return nil
+}
+
+// PostEntry validates that the entry balances and records it. It is meant to run
+// inside the same database transaction that mutates the account balances.
+func (s *LedgerService) PostEntry(ctx context.Context, entry *model.JournalEntry, tx repository.Tx) error {
+	if err := s.validateEntry(entry); err != nil {
+		return err
+	}
+
+	return s.ledgerRepo.CreateEntry(ctx, entry, tx)
+}
+
+// IsPosted reports whether the transaction already has a journal entry, which
+// makes replays of an already applied message detectable
+func (s *LedgerService) IsPosted(ctx context.Context, transactionID string, tx repository.Tx) (bool, error) {
+	return s.ledgerRepo.ExistsByTransactionID(ctx, transactionID, tx)
+}
+
+func (s *LedgerService) GetEntry(ctx context.Context, transactionID string, tx repository.Tx) (*model.JournalEntry, error) {
+	return s.ledgerRepo.GetEntryByTransactionID(ctx, transactionID, tx)
+}
+
+func (s *LedgerService) GetReversedAmount(ctx context.Context, transactionID string, tx repository.Tx) (decimal.Decimal, error) {
+	return s.ledgerRepo.SumReversedAmount(ctx, transactionID, tx)
+}
+
+// ReversalLegs mirrors the postings of the original entry scaled down to amount,
+// rounded to cents. Within each currency the rounding difference is booked on
+// the last system leg, or on the cash account when there is none, so customer
+// accounts never carry fractions of a cent. A reversal too small to give every
+// leg at least a cent is refused.
+func ReversalLegs(original *model.JournalEntry, amount decimal.Decimal) ([]model.Posting, error) {
+	ratio := amount.Div(original.Amount)
+
+	legs := make([]model.Posting, 0, len(original.Postings)+1)
+	systemLeg := map[string]int{}
+	totals := map[string]decimal.Decimal{}
+	var currencies []string
+
+	for _, posting := range original.Postings {
+		reversed := posting.Amount.Mul(ratio).Round(REVERSAL_AMOUNT_PLACES).Neg()
+		if reversed.IsZero() {
+			return nil, fmt.Errorf("%w: %s rounds to zero on %s", ErrReversalTooSmall, amount.String(), posting.AccountNumber)
+		}
+
+		if _, seen := totals[posting.Currency]; !seen {
+			currencies = append(currencies, posting.Currency)
+		}
+
+		legs = append(legs, Leg(posting.AccountNumber, reversed, posting.Currency))
+		totals[posting.Currency] = totals[posting.Currency].Add(reversed)
+		if IsSystemAccount(posting.AccountNumber) {
+			systemLeg[posting.Currency] = len(legs) - 1
+		}
+	}
+
+	for _, currency := range currencies {
+		remainder := totals[currency]
+		if remainder.IsZero() {
+			continue
+		}
+
+		index, ok := systemLeg[currency]
+		if !ok {
+			legs = append(legs, Leg(SystemCashAccount(currency), remainder.Neg(), currency))
+			continue
+		}
+
+		legs[index].Amount = legs[index].Amount.Sub(remainder)
+		if legs[index].Amount.IsZero() {
+			return nil, fmt.Errorf("%w: %s rounds to zero on %s", ErrReversalTooSmall, amount.String(), legs[index].AccountNumber)
+		}
+	}
+
+	return legs, nil
+}
+
+func (s *LedgerService) GetPostings(ctx context.Context, accountNumber string, limit int, offset int) ([]model.Posting, int64, error) {
+	return s.ledgerRepo.GetPostingsByAccount(ctx, accountNumber, limit, offset)
+}
+
+// GetPostedBalance derives the account balance from its postings
+func (s *LedgerService) GetPostedBalance(ctx context.Context, accountNumber string) (decimal.Decimal, error) {
+	return s.ledgerRepo.SumByAccount(ctx, accountNumber, nil)
+}
+
+// GetBalanceAt derives the account balance from its postings made before the
+// given time, starting from the latest snapshot taken by then
+func (s *LedgerService) GetBalanceAt(ctx context.Context, accountNumber string, at time.Time) (decimal.Decimal, error) {
+	from := time.Time{}
+	balance := decimal.Zero
+
+	snapshot, err := s.ledgerRepo.GetLatestSnapshot(ctx, accountNumber, at)
+	if err == nil {
+		from = snapshot.AsOf
+		balance = snapshot.Balance
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Zero, err
+	}
+
+	sum, err := s.ledgerRepo.SumByAccountBetween(ctx, accountNumber, from, at)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return balance.Add(sum), nil
+}
+
+// GetBalanceAsOf returns the balance including postings made exactly at asOf.
+// Postgres keeps microseconds, so the next microsecond is the exclusive bound.
+func (s *LedgerService) GetBalanceAsOf(ctx context.Context, accountNumber string, asOf time.Time) (decimal.Decimal, error) {
+	return s.GetBalanceAt(ctx, accountNumber, asOf.Truncate(time.Microsecond).Add(time.Microsecond))
+}
+
+// SnapshotBalances records the balance at midnight UTC of every ledger account
+// that has moved since its last snapshot. Midnight is only snapshotted once
+// BALANCE_SNAPSHOT_SETTLE_DELAY has passed, so transactions committing late
+// with an earlier timestamp are not missed.
+func (s *LedgerService) SnapshotBalances(ctx context.Context) error {
+	now := time.Now().UTC()
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if now.Sub(asOf) < BALANCE_SNAPSHOT_SETTLE_DELAY {
+		asOf = asOf.AddDate(0, 0, -1)
+	}
+
+	accountNumbers, err := s.ledgerRepo.ListAccountsToSnapshot(ctx, asOf)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, accountNumber := range accountNumbers {
+		balance, err := s.GetBalanceAt(ctx, accountNumber, asOf)
+		if err == nil {
+			err = s.ledgerRepo.CreateSnapshot(ctx, &model.BalanceSnapshot{
+				AccountNumber: accountNumber,
+				AsOf:          asOf,
+				Balance:       balance,
+			})
+		}
+
+		if err != nil {
+			log.Printf("Failed to snapshot balance of account %s: %v", accountNumber, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("balance snapshot failed for %d of %d accounts", failed, len(accountNumbers))
+	}
+
+	return nil
+}
+
+// GetLines returns the postings of the account in [from, to) with their entries
+func (s *LedgerService) GetLines(ctx context.Context, accountNumber string, from time.Time, to time.Time) ([]model.StatementLine, error) {
+	return s.ledgerRepo.ListLines(ctx, accountNumber, from, to)
+}
+
+// VerifyBalance checks the stored balance of the account against its postings
+func (s *LedgerService) VerifyBalance(ctx context.Context, account *model.Account) (decimal.Decimal, bool, error) {
+	posted, err := s.GetPostedBalance(ctx, account.AccountNumber)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+
+	return posted, posted.Equal(account.Balance), nil
+}
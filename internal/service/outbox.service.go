@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	"golang-exercise/internal/repository"
+)
+
+type OutboxService struct {
+	outboxRepo   repository.OutboxStore
+	txLogService *TransactionLogService
+	maxAttempts  int
+}
+
+const (
+	DEFAULT_OUTBOX_MAX_ATTEMPTS = 10
+
+	// OUTBOX_CLAIM_LEASE is how long other relays leave claimed messages alone.
+	// A relay that dies mid-batch has its messages picked up again afterwards.
+	OUTBOX_CLAIM_LEASE = 5 * time.Minute
+
+	// A message that failed to publish is left alone for OUTBOX_RETRY_BASE_DELAY,
+	// doubling with every further failure up to OUTBOX_RETRY_MAX_DELAY, so a
+	// broker outage does not use up its attempts within a few polls.
+	OUTBOX_RETRY_BASE_DELAY = 5 * time.Second
+	OUTBOX_RETRY_MAX_DELAY  = 10 * time.Minute
+)
+
+func NewOutboxService(outboxRepo repository.OutboxStore, txLogService *TransactionLogService, maxAttempts int) *OutboxService {
+	if maxAttempts <= 0 {
+		maxAttempts = DEFAULT_OUTBOX_MAX_ATTEMPTS
+	}
+
+	return &OutboxService{
+		outboxRepo:   outboxRepo,
+		txLogService: txLogService,
+		maxAttempts:  maxAttempts,
+	}
+}
+
+// EnqueueTransaction records the transaction in the outbox. Pass tx to make the
+// write part of a larger database transaction; the Mongo log is then left to the
+// relay. Without tx the log is also written right away so the status endpoint
+// sees the transaction immediately.
+func (s *OutboxService) EnqueueTransaction(ctx context.Context, txLog *model.TransactionLog, txMsg *dto.TransactionMessage, tx repository.Tx) error {
+	payload, err := json.Marshal(txMsg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize transaction message: %w", err)
+	}
+
+	logPayload, err := json.Marshal(txLog)
+	if err != nil {
+		return fmt.Errorf("failed to serialize transaction log: %w", err)
+	}
+
+	message := &model.OutboxMessage{
+		TransactionId:  txMsg.ID,
+		Payload:        string(payload),
+		TransactionLog: string(logPayload),
+		Status:         model.OutboxStatusPending,
+	}
+
+	if err := s.outboxRepo.Create(ctx, message, tx); err != nil {
+		return err
+	}
+
+	if tx == nil {
+		// The relay writes the log before publishing, so a failure here is recovered
+		if err := s.txLogService.EnsureLogged(ctx, txLog); err != nil {
+			log.Printf("Failed to log transaction %s, relay will retry: %v", txMsg.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ProcessPending publishes up to limit pending messages through publish and
+// returns how many were sent. The messages are leased in a short transaction
+// and published outside it, so no row stays locked while the broker confirms.
+// Messages are only marked sent after publish succeeds, so delivery is
+// at-least-once.
+func (s *OutboxService) ProcessPending(ctx context.Context, limit int, publish func(*dto.TransactionMessage) error) (int, error) {
+	var messages []model.OutboxMessage
+
+	err := repository.Transaction(ctx, s.outboxRepo, func(tx repository.Tx) error {
+		claimed, err := s.outboxRepo.ClaimPending(ctx, limit, time.Now().Add(OUTBOX_CLAIM_LEASE), tx)
+		messages = claimed
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, message := range messages {
+		if err := s.relay(ctx, &message, publish); err != nil {
+			if err := s.recordFailure(ctx, &message, err, nil); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err := s.outboxRepo.MarkSent(ctx, message.ID, nil); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+func (s *OutboxService) relay(ctx context.Context, message *model.OutboxMessage, publish func(*dto.TransactionMessage) error) error {
+	var txLog model.TransactionLog
+	if err := json.Unmarshal([]byte(message.TransactionLog), &txLog); err != nil {
+		return fmt.Errorf("failed to decode transaction log: %w", err)
+	}
+
+	var txMsg dto.TransactionMessage
+	if err := json.Unmarshal([]byte(message.Payload), &txMsg); err != nil {
+		return fmt.Errorf("failed to decode transaction message: %w", err)
+	}
+
+	// The log must exist before the worker can update its status
+	if err := s.txLogService.EnsureLogged(ctx, &txLog); err != nil {
+		return fmt.Errorf("failed to log transaction: %w", err)
+	}
+
+	return publish(&txMsg)
+}
+
+// OutboxRetryDelay is how long a message waits after its given number of
+// failed publishes, counting from 1
+func OutboxRetryDelay(attempts int) time.Duration {
+	delay := OUTBOX_RETRY_BASE_DELAY
+	for i := 1; i < attempts && delay < OUTBOX_RETRY_MAX_DELAY; i++ {
+		delay *= 2
+	}
+
+	return min(delay, OUTBOX_RETRY_MAX_DELAY)
+}
+
+func (s *OutboxService) recordFailure(ctx context.Context, message *model.OutboxMessage, cause error, tx repository.Tx) error {
+	attempts := message.Attempts + 1
+	status := model.OutboxStatusPending
+
+	if attempts >= s.maxAttempts {
+		status = model.OutboxStatusFailed
+		log.Printf("Giving up on outbox message %s after %d attempts: %v", message.TransactionId, attempts, cause)
+
+		reason := fmt.Sprintf("not published after %d attempts: %v", attempts, cause)
+		if err := s.txLogService.MarkFailed(ctx, message.TransactionId, reason); err != nil {
+			log.Printf("Failed to mark transaction %s as failed: %v", message.TransactionId, err)
+		}
+	}
+
+	retryAt := time.Now().Add(OutboxRetryDelay(attempts))
+	return s.outboxRepo.RecordFailure(ctx, message.ID, attempts, status, cause.Error(), retryAt, tx)
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golang-exercise/config"
+	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/reconcile"
+	"golang-exercise/internal/repository"
+)
+
+const (
+	RECONCILIATION_BATCH_SIZE    = 100
+	DEFAULT_RECONCILIATION_GRACE = time.Hour
+)
+
+type ReconciliationService struct {
+	accountRepo        repository.AccountStore
+	ledgerRepo         repository.LedgerStore
+	outboxRepo         repository.OutboxStore
+	reconciliationRepo repository.ReconciliationStore
+	txLogService       *TransactionLogService
+	grace              time.Duration
+	repair             bool
+}
+
+func NewReconciliationService(accountRepo repository.AccountStore, ledgerRepo repository.LedgerStore, outboxRepo repository.OutboxStore, reconciliationRepo repository.ReconciliationStore, txLogService *TransactionLogService, settings config.Reconciliation) *ReconciliationService {
+	grace := time.Duration(settings.GraceMinutes) * time.Minute
+	if grace <= 0 {
+		grace = DEFAULT_RECONCILIATION_GRACE
+	}
+
+	return &ReconciliationService{
+		accountRepo:        accountRepo,
+		ledgerRepo:         ledgerRepo,
+		outboxRepo:         outboxRepo,
+		reconciliationRepo: reconciliationRepo,
+		txLogService:       txLogService,
+		grace:              grace,
+		repair:             settings.Repair,
+	}
+}
+
+// Run reconciles every account, or only accountNumber when given. With repair
+// set, log statuses that are provably wrong are fixed.
+func (s *ReconciliationService) Run(ctx context.Context, accountNumber string, repair bool) (*reconcile.Report, error) {
+	report := reconcile.NewReport(time.Now(), repair)
+
+	ledgerStartedAt, err := s.reconciliationRepo.LedgerStartedAt(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if accountNumber != "" {
+		account, err := s.accountRepo.GetByAccountNumber(ctx, accountNumber)
+		if err != nil {
+			return nil, fmt.Errorf("account %s not found: %w", accountNumber, err)
+		}
+
+		if err := s.reconcileAccount(ctx, account, ledgerStartedAt, repair, report); err != nil {
+			return nil, err
+		}
+
+		report.FinishedAt = time.Now()
+		return report, nil
+	}
+
+	var afterID uint
+	for {
+		accounts, err := s.accountRepo.ListAfterID(ctx, afterID, RECONCILIATION_BATCH_SIZE)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(accounts) == 0 {
+			break
+		}
+
+		for i := range accounts {
+			if err := s.reconcileAccount(ctx, &accounts[i], ledgerStartedAt, repair, report); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", accounts[i].AccountNumber, err))
+			}
+		}
+
+		afterID = accounts[len(accounts)-1].ID
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// RunScheduled is the worker job, it logs a summary and every account with discrepancies
+func (s *ReconciliationService) RunScheduled(ctx context.Context) error {
+	report, err := s.Run(ctx, "", s.repair)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Reconciliation checked %d accounts, %d with discrepancies %v", report.AccountsChecked, report.AccountsWithIssues, report.Discrepancies)
+
+	for _, account := range report.Accounts {
+		for _, discrepancy := range account.Discrepancies {
+			log.Printf("Reconciliation %s account=%s transaction=%s repaired=%t: %s",
+				discrepancy.Kind, account.AccountNumber, discrepancy.TransactionId, discrepancy.Repaired, discrepancy.Detail)
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("reconciliation failed for %d accounts", len(report.Errors))
+	}
+
+	return nil
+}
+
+func (s *ReconciliationService) reconcileAccount(ctx context.Context, account *model.Account, ledgerStartedAt time.Time, repair bool, report *reconcile.Report) error {
+	// Balance and postings come from the same snapshot so a concurrent commit can't split them
+	current, postings, err := s.reconciliationRepo.ReadAccount(ctx, account.AccountNumber)
+	if err != nil {
+		return err
+	}
+
+	posted := make(map[string]reconcile.Posting, len(postings))
+	for _, posting := range postings {
+		posted[posting.TransactionId] = reconcile.Posting{Amount: posting.Amount, PostedAt: posting.PostedAt, Migrated: posting.Migrated}
+	}
+
+	reconciler := reconcile.NewReconciler(reconcile.Account{
+		ID:              current.ID,
+		AccountNumber:   current.AccountNumber,
+		Balance:         current.Balance,
+		LedgerStartedAt: ledgerStartedAt,
+	}, posted, time.Now(), s.grace)
+
+	err = s.txLogService.StreamTransactionsByAccount(ctx, account.ID, func(txLog *model.TransactionLog) error {
+		// A log completed since the snapshot was posted after it, its amount is
+		// not in the balance read above
+		if _, ok := posted[txLog.TransactionId]; !ok && txLog.Status == model.TransactionStatusCompleted {
+			postedSince, err := s.ledgerRepo.ExistsByTransactionID(ctx, txLog.TransactionId, nil)
+			if err != nil || postedSince {
+				return err
+			}
+		}
+
+		reconciler.Add(txLog)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load transaction logs: %w", err)
+	}
+
+	accountReport := reconciler.Finish()
+
+	if repair {
+		for i := range accountReport.Discrepancies {
+			discrepancy := &accountReport.Discrepancies[i]
+			if discrepancy.RepairTo == "" {
+				continue
+			}
+
+			repaired, err := s.repairStatus(ctx, discrepancy)
+			if err != nil {
+				log.Printf("Failed to repair transaction %s: %v", discrepancy.TransactionId, err)
+			}
+			discrepancy.Repaired = repaired
+		}
+	}
+
+	report.Add(accountReport)
+	return nil
+}
+
+// repairStatus sets the log to the status the discrepancy calls for. A log is
+// only failed once it is certain the transaction will never be posted.
+func (s *ReconciliationService) repairStatus(ctx context.Context, discrepancy *reconcile.Discrepancy) (bool, error) {
+	if discrepancy.RepairTo == model.TransactionStatusCompleted {
+		err := s.txLogService.UpdateTransactionStatus(ctx, discrepancy.TransactionId, model.TransactionStatusCompleted)
+		return err == nil, err
+	}
+
+	pending, err := s.outboxRepo.HasPending(ctx, discrepancy.TransactionId)
+	if err != nil || pending {
+		return false, err
+	}
+
+	posted, err := s.ledgerRepo.ExistsByTransactionID(ctx, discrepancy.TransactionId, nil)
+	if err != nil || posted {
+		return false, err
+	}
+
+	err = s.txLogService.MarkFailed(ctx, discrepancy.TransactionId, "reconciliation: never posted")
+	return err == nil, err
+}
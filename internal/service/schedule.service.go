@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/recurrence"
+	"golang-exercise/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrScheduleNotFound    = errors.New("schedule not found")
+	ErrScheduleNotEditable = errors.New("schedule has already ended")
+)
+
+// How often the worker looks for due schedules
+const SCHEDULER_POLL_INTERVAL = 15 * time.Second
+
+const DEFAULT_SCHEDULE_BATCH_SIZE = 50
+
+// A run that starts later than this after its occurrence counts as missed
+const MISSED_RUN_GRACE = 5 * time.Minute
+
+const DEFAULT_SCHEDULE_RUNS_LIMIT = 20
+
+type ScheduleService struct {
+	scheduleRepo   repository.ScheduleStore
+	accountService *AccountService
+	fxService      *FxService
+	outboxService  *OutboxService
+}
+
+func NewScheduleService(scheduleRepo repository.ScheduleStore, accountService *AccountService, fxService *FxService, outboxService *OutboxService) *ScheduleService {
+	return &ScheduleService{
+		scheduleRepo:   scheduleRepo,
+		accountService: accountService,
+		fxService:      fxService,
+		outboxService:  outboxService,
+	}
+}
+
+// nextRun returns the first occurrence of the schedule strictly after the given
+// time, or nil once the schedule has no more occurrences
+func nextRun(schedule *model.Schedule, after time.Time) (*time.Time, error) {
+	if schedule.Recurrence == "" {
+		if after.Before(schedule.StartAt) {
+			return &schedule.StartAt, nil
+		}
+
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(schedule.Recurrence, schedule.StartAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Occurrences before the start of the schedule don't count
+	if after.Before(schedule.StartAt) {
+		after = schedule.StartAt.Add(-time.Nanosecond)
+	}
+
+	next := rule.Next(after)
+	if next.IsZero() || (schedule.EndAt != nil && next.After(*schedule.EndAt)) {
+		return nil, nil
+	}
+
+	return &next, nil
+}
+
+func (s *ScheduleService) CreateSchedule(ctx context.Context, req *requestdto.CreateSchedule) (*model.Schedule, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	if req.FromAccountNumber == req.ToAccountNumber {
+		return nil, errors.New("cannot transfer to the same account")
+	}
+
+	for _, accountNumber := range []string{req.FromAccountNumber, req.ToAccountNumber} {
+		if _, err := s.accountService.GetAccount(ctx, &requestdto.GetAccount{AccountNumber: accountNumber}); err != nil {
+			return nil, fmt.Errorf("account %s not found", accountNumber)
+		}
+	}
+
+	missedRuns := req.MissedRuns
+	if missedRuns == "" {
+		missedRuns = model.MissedRunOnce
+	}
+
+	if missedRuns != model.MissedRunOnce && missedRuns != model.MissedRunSkip {
+		return nil, errors.New("missed_runs must be RUN_ONCE or SKIP")
+	}
+
+	now := time.Now()
+	startAt := now
+	if req.StartAt != nil {
+		startAt = *req.StartAt
+	}
+
+	if req.EndAt != nil && !req.EndAt.After(startAt) {
+		return nil, errors.New("end_at must be after start_at")
+	}
+
+	schedule := &model.Schedule{
+		ScheduleId:        "SCH_" + uuid.New().String(),
+		FromAccountNumber: req.FromAccountNumber,
+		ToAccountNumber:   req.ToAccountNumber,
+		Amount:            req.Amount,
+		Memo:              req.Memo,
+		Recurrence:        req.Recurrence,
+		MissedRuns:        missedRuns,
+		Status:            model.ScheduleActive,
+		StartAt:           startAt,
+		EndAt:             req.EndAt,
+	}
+
+	next, err := nextRun(schedule, startAt.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence: %w", err)
+	}
+
+	if next == nil {
+		return nil, errors.New("schedule has no runs between start_at and end_at")
+	}
+
+	schedule.NextRunAt = next
+
+	if err := s.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *ScheduleService) GetSchedule(ctx context.Context, scheduleID string) (*model.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetByScheduleID(ctx, scheduleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+
+	return schedule, err
+}
+
+func (s *ScheduleService) ListSchedules(ctx context.Context, accountNumber string, status string) ([]model.Schedule, error) {
+	return s.scheduleRepo.List(ctx, accountNumber, status)
+}
+
+func (s *ScheduleService) ListRuns(ctx context.Context, scheduleID string, limit int) ([]model.ScheduleRun, error) {
+	if limit <= 0 {
+		limit = DEFAULT_SCHEDULE_RUNS_LIMIT
+	}
+
+	return s.scheduleRepo.ListRuns(ctx, scheduleID, limit)
+}
+
+// lockSchedule loads the schedule under a row lock and refuses ended schedules
+func (s *ScheduleService) lockSchedule(ctx context.Context, scheduleID string, tx repository.Tx) (*model.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetForUpdate(ctx, scheduleID, tx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.Status == model.ScheduleCompleted || schedule.Status == model.ScheduleCancelled {
+		return nil, fmt.Errorf("%w: schedule is %s", ErrScheduleNotEditable, schedule.Status)
+	}
+
+	return schedule, nil
+}
+
+// UpdateSchedule changes the schedule. Changing the recurrence or resuming a
+// paused schedule plans the next run from now, occurrences that passed while
+// paused are not caught up.
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, scheduleID string, req *requestdto.UpdateSchedule) (*model.Schedule, error) {
+	var schedule *model.Schedule
+
+	err := repository.Transaction(ctx, s.scheduleRepo, func(tx repository.Tx) error {
+		var err error
+		schedule, err = s.lockSchedule(ctx, scheduleID, tx)
+		if err != nil {
+			return err
+		}
+
+		replan := false
+
+		if req.Amount != nil {
+			if !req.Amount.IsPositive() {
+				return errors.New("amount must be greater than zero")
+			}
+			schedule.Amount = *req.Amount
+		}
+
+		if req.Memo != nil {
+			schedule.Memo = *req.Memo
+		}
+
+		if req.Recurrence != nil {
+			schedule.Recurrence = *req.Recurrence
+			replan = true
+		}
+
+		if req.EndAt != nil && req.ClearEndAt {
+			return errors.New("end_at and clear_end_at cannot be combined")
+		}
+
+		if req.EndAt != nil {
+			schedule.EndAt = req.EndAt
+			replan = true
+		}
+
+		if req.ClearEndAt {
+			schedule.EndAt = nil
+			replan = true
+		}
+
+		switch req.Status {
+		case "":
+		case model.SchedulePaused:
+			schedule.Status = model.SchedulePaused
+		case model.ScheduleActive:
+			replan = replan || schedule.Status == model.SchedulePaused
+			schedule.Status = model.ScheduleActive
+		default:
+			return errors.New("status must be ACTIVE or PAUSED")
+		}
+
+		if replan {
+			next, err := nextRun(schedule, time.Now())
+			if err != nil {
+				return fmt.Errorf("invalid recurrence: %w", err)
+			}
+
+			schedule.NextRunAt = next
+			if next == nil {
+				schedule.Status = model.ScheduleCompleted
+			}
+		}
+
+		return s.scheduleRepo.Save(ctx, schedule, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *ScheduleService) CancelSchedule(ctx context.Context, scheduleID string) (*model.Schedule, error) {
+	var schedule *model.Schedule
+
+	err := repository.Transaction(ctx, s.scheduleRepo, func(tx repository.Tx) error {
+		var err error
+		schedule, err = s.lockSchedule(ctx, scheduleID, tx)
+		if err != nil {
+			return err
+		}
+
+		schedule.Status = model.ScheduleCancelled
+		schedule.NextRunAt = nil
+
+		return s.scheduleRepo.Save(ctx, schedule, tx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// RunDue enqueues a transfer for every schedule that is due. Each schedule gets
+// its own database transaction, in which claiming it, recording the run,
+// writing the outbox message and planning the next run happen together. An
+// occurrence is so enqueued exactly once even with several workers or after a
+// crash, and a schedule that fails does not hold up the others.
+func (s *ScheduleService) RunDue(ctx context.Context) error {
+	now := time.Now()
+
+	scheduleIDs, err := s.scheduleRepo.ListDue(ctx, now, DEFAULT_SCHEDULE_BATCH_SIZE)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, scheduleID := range scheduleIDs {
+		err := repository.Transaction(ctx, s.scheduleRepo, func(tx repository.Tx) error {
+			// Another worker may have run it since it was listed
+			schedule, err := s.scheduleRepo.ClaimDue(ctx, scheduleID, now, tx)
+			if err != nil || schedule == nil {
+				return err
+			}
+
+			return s.runSchedule(ctx, schedule, now, tx)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", scheduleID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *ScheduleService) runSchedule(ctx context.Context, schedule *model.Schedule, now time.Time, tx repository.Tx) error {
+	due := *schedule.NextRunAt
+
+	// Occurrences that also passed while the scheduler was down are folded into
+	// this run instead of being executed one by one
+	next, err := nextRun(schedule, due)
+	if err != nil {
+		return err
+	}
+
+	missed := 0
+	for next != nil && !next.After(now) {
+		missed++
+		if next, err = nextRun(schedule, *next); err != nil {
+			return err
+		}
+	}
+
+	run := &model.ScheduleRun{
+		ScheduleId:        schedule.ScheduleId,
+		ScheduledFor:      due,
+		MissedOccurrences: missed,
+	}
+
+	var txLog *model.TransactionLog
+	var txMsg *dto.TransactionMessage
+
+	if schedule.MissedRuns == model.MissedRunSkip && now.Sub(due) > MISSED_RUN_GRACE {
+		run.Status = model.ScheduleRunSkipped
+		run.Error = "missed while the scheduler was not running"
+	} else if txLog, txMsg, err = s.buildTransfer(ctx, schedule, due); err != nil {
+		run.Status = model.ScheduleRunFailed
+		run.Error = err.Error()
+	} else {
+		run.Status = model.ScheduleRunEnqueued
+		run.TransactionId = txMsg.ID
+	}
+
+	created, err := s.scheduleRepo.CreateRun(ctx, run, tx)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		log.Printf("Schedule %s already ran for %s, skipping", schedule.ScheduleId, due.Format(time.RFC3339))
+	} else {
+		if run.Status == model.ScheduleRunEnqueued {
+			if err := s.outboxService.EnqueueTransaction(ctx, txLog, txMsg, tx); err != nil {
+				return err
+			}
+		}
+
+		schedule.LastRunAt = &due
+	}
+
+	schedule.NextRunAt = next
+	if next == nil {
+		schedule.Status = model.ScheduleCompleted
+	}
+
+	return s.scheduleRepo.Save(ctx, schedule, tx)
+}
+
+// buildTransfer prepares the transfer of one occurrence. Accounts that can no
+// longer take part fail the run right away, the worker enforces balances.
+func (s *ScheduleService) buildTransfer(ctx context.Context, schedule *model.Schedule, due time.Time) (*model.TransactionLog, *dto.TransactionMessage, error) {
+	from, err := s.accountService.GetAccount(ctx, &requestdto.GetAccount{AccountNumber: schedule.FromAccountNumber})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	to, err := s.accountService.GetAccount(ctx, &requestdto.GetAccount{AccountNumber: schedule.ToAccountNumber})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := CheckAccountActivity(from, true); err != nil {
+		return nil, nil, err
+	}
+
+	if err := CheckAccountActivity(to, false); err != nil {
+		return nil, nil, err
+	}
+
+	conversion, err := s.fxService.Convert(ctx, schedule.Amount, from.Currency, to.Currency, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Runs of several schedules are built within the same instant
+	transactionID := "TXN_" + uuid.New().String()
+
+	txLog := &model.TransactionLog{
+		TransactionId: transactionID,
+		FromAccountId: from.ID,
+		ToAccountId:   to.ID,
+		Amount:        schedule.Amount,
+		Currency:      from.Currency,
+		Type:          model.TransactionTypeTransfer,
+		Status:        model.TransactionStatusInprogress,
+		Memo:          schedule.Memo,
+		Metadata: map[string]any{
+			"schedule_id":   schedule.ScheduleId,
+			"scheduled_for": due,
+		},
+		InitiatedBy: 1, // TODO: Using the userid from jwt when auth is enabled
+		Timestamp:   time.Now(),
+	}
+	txLog.SetFxConversion(conversion)
+
+	txMsg := &dto.TransactionMessage{
+		ID:              transactionID,
+		Type:            model.TransactionTypeTransfer,
+		AccountNumber:   from.AccountNumber,
+		ToAccountNumber: to.AccountNumber,
+		Amount:          schedule.Amount,
+		Currency:        from.Currency,
+		Description:     schedule.Memo,
+		Fx:              conversion,
+		CreatedAt:       time.Now(),
+	}
+
+	return txLog, txMsg, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	model "golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const STATEMENT_PERIOD_LAYOUT = "2006-01"
+
+var ErrStatementPeriodOpen = errors.New("statement period has not ended yet")
+
+type StatementService struct {
+	statementRepo  repository.StatementStore
+	accountService *AccountService
+	ledgerService  *LedgerService
+}
+
+func NewStatementService(statementRepo repository.StatementStore, accountService *AccountService, ledgerService *LedgerService) *StatementService {
+	return &StatementService{
+		statementRepo:  statementRepo,
+		accountService: accountService,
+		ledgerService:  ledgerService,
+	}
+}
+
+// ParseStatementPeriod turns a YYYY-MM period into the first instant of that month in UTC
+func ParseStatementPeriod(period string) (time.Time, error) {
+	start, err := time.ParseInLocation(STATEMENT_PERIOD_LAYOUT, period, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid statement period %q, expected YYYY-MM", period)
+	}
+
+	return start, nil
+}
+
+func StatementID(accountNumber string, start time.Time) string {
+	return fmt.Sprintf("STMT_%s_%s", accountNumber, start.Format("200601"))
+}
+
+func (s *StatementService) ListStatements(ctx context.Context, accountNumber string) ([]model.Statement, error) {
+	return s.statementRepo.ListByAccount(ctx, accountNumber)
+}
+
+func (s *StatementService) GetStatement(ctx context.Context, accountNumber string, period string) (*model.Statement, error) {
+	return s.statementRepo.GetByPeriod(ctx, accountNumber, period)
+}
+
+// GenerateStatement builds the statement of a completed month from the ledger
+// and stores it. A statement that already exists is returned unchanged.
+func (s *StatementService) GenerateStatement(ctx context.Context, accountNumber string, period string) (*model.Statement, error) {
+	start, err := ParseStatementPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	end := start.AddDate(0, 1, 0)
+	if end.After(time.Now()) {
+		return nil, ErrStatementPeriodOpen
+	}
+
+	existing, err := s.statementRepo.GetByPeriod(ctx, accountNumber, period)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	account, err := s.accountService.GetAccount(ctx, &requestdto.GetAccount{AccountNumber: accountNumber})
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := s.ledgerService.GetBalanceAt(ctx, accountNumber, start)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := s.ledgerService.GetLines(ctx, accountNumber, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &model.Statement{
+		StatementId:    StatementID(accountNumber, start),
+		AccountNumber:  accountNumber,
+		Period:         start.Format(STATEMENT_PERIOD_LAYOUT),
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Currency:       account.Currency,
+		OpeningBalance: opening,
+		TotalCredits:   decimal.Zero,
+		TotalDebits:    decimal.Zero,
+		Lines:          make([]model.StatementLine, 0, len(lines)),
+	}
+
+	balance := opening
+	for _, line := range lines {
+		balance = balance.Add(line.Amount)
+		line.Balance = balance
+
+		// Postings are signed, credits are positive
+		if line.Amount.IsPositive() {
+			statement.TotalCredits = statement.TotalCredits.Add(line.Amount)
+		} else {
+			statement.TotalDebits = statement.TotalDebits.Add(line.Amount.Neg())
+		}
+
+		statement.Lines = append(statement.Lines, line)
+	}
+
+	statement.ClosingBalance = balance
+	statement.TransactionCount = len(statement.Lines)
+
+	created, err := s.statementRepo.Create(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	// Someone else generated it first, theirs is the one that counts
+	if !created {
+		return s.statementRepo.GetByPeriod(ctx, accountNumber, period)
+	}
+
+	return statement, nil
+}
+
+// GenerateDue issues last month's statement for every account that was open
+// during it. Accounts opened since or closed before get none.
+func (s *StatementService) GenerateDue(ctx context.Context) error {
+	now := time.Now().UTC()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, -1, 0)
+	period := start.Format(STATEMENT_PERIOD_LAYOUT)
+
+	accountNumbers, err := s.accountService.ListAccountNumbersOpenDuring(ctx, start, end)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, accountNumber := range accountNumbers {
+		if _, err := s.GenerateStatement(ctx, accountNumber, period); err != nil {
+			log.Printf("Failed to generate %s statement for account %s: %v", period, accountNumber, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("statement generation failed for %d of %d accounts", failed, len(accountNumbers))
+	}
+
+	return nil
+}
+
+// WriteStatementCSV writes the statement lines framed by the opening and closing balances
+func WriteStatementCSV(w io.Writer, statement *model.Statement) error {
+	writer := csv.NewWriter(w)
+
+	records := [][]string{
+		{"date", "transaction_id", "type", "description", "amount", "balance"},
+		{statement.PeriodStart.Format(time.RFC3339), "", "", "Opening balance", "", statement.OpeningBalance.StringFixed(2)},
+	}
+
+	for _, line := range statement.Lines {
+		records = append(records, []string{
+			line.Date.UTC().Format(time.RFC3339),
+			line.TransactionId,
+			string(line.TransactionType),
+			line.Description,
+			line.Amount.StringFixed(2),
+			line.Balance.StringFixed(2),
+		})
+	}
+
+	records = append(records, []string{statement.PeriodEnd.Format(time.RFC3339), "", "", "Closing balance", "", statement.ClosingBalance.StringFixed(2)})
+
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+
+	return nil
+}
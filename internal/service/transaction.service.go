@@ -4,15 +4,60 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"golang-exercise/internal/database"
 	model "golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository"
+	"log"
+	"sort"
 
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
+// Transactions failing with these errors are rejected for good, processing them
+// again can only fail the same way
+var (
+	ErrFxMismatch              = errors.New("currency conversion does not match the accounts")
+	ErrInvalidTransactionType  = errors.New("invalid transaction type")
+	ErrSameAccountTransfer     = errors.New("cannot transfer to the same account")
+	ErrReverseReversal         = errors.New("cannot reverse a reversal")
+	ErrReversalExceedsOriginal = errors.New("reversal exceeds the remaining amount of the original transaction")
+	ErrReversalTooSmall        = errors.New("reversal is too small to split across the original postings")
+	ErrCaptureExceedsHold      = errors.New("capture amount exceeds the held amount")
+)
+
+// IsPermanent reports whether a failed transaction should not be retried
+func IsPermanent(err error) bool {
+	permanent := []error{
+		ErrInsufficientFunds,
+		ErrAccountFrozen,
+		ErrAccountClosed,
+		ErrHoldNotActive,
+		ErrHoldNotFound,
+		ErrFxMismatch,
+		ErrInvalidTransactionType,
+		ErrSameAccountTransfer,
+		ErrReverseReversal,
+		ErrReversalExceedsOriginal,
+		ErrReversalTooSmall,
+		ErrCaptureExceedsHold,
+		gorm.ErrRecordNotFound,
+	}
+
+	for _, target := range permanent {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type TransactionService struct {
 	accountService *AccountService
 	txLogService   *TransactionLogService
+	ledgerService  *LedgerService
+	holdService    *HoldService
+	feeService     *FeeService
 }
 
 type TransactionRequest struct {
@@ -24,79 +69,453 @@ type TransactionRequest struct {
 	InitiatedBy   uint            `json:"initiated_by"`
 }
 
-func NewTransactionService(accountService *AccountService, txLogService *TransactionLogService) *TransactionService {
+func NewTransactionService(accountService *AccountService, txLogService *TransactionLogService, ledgerService *LedgerService, holdService *HoldService, feeService *FeeService) *TransactionService {
 	return &TransactionService{
 		accountService: accountService,
 		txLogService:   txLogService,
+		ledgerService:  ledgerService,
+		holdService:    holdService,
+		feeService:     feeService,
 	}
 }
 
-func (s *TransactionService) ProcessTransaction(ctx context.Context, transactionID string, accountID string, amount decimal.Decimal, transactionType model.TransactionType) error {
+// alreadyApplied checks for a journal entry of the transaction while its accounts
+// are locked. When found it rolls back tx and completes the log again.
+func (s *TransactionService) alreadyApplied(ctx context.Context, transactionID string, tx repository.Tx) (bool, error) {
+	posted, err := s.ledgerService.IsPosted(ctx, transactionID, tx)
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to check journal for transaction: %w", err)
+	}
+
+	if !posted {
+		return false, nil
+	}
+
+	tx.Rollback()
+	log.Printf("Transaction %s was already applied, skipping", transactionID)
+
+	return true, s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusCompleted)
+}
+
+// reject rolls back tx and fails the transaction with err as the reason
+func (s *TransactionService) reject(ctx context.Context, tx repository.Tx, transactionID string, err error) error {
+	tx.Rollback()
+	s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+	return err
+}
+
+// externalLegs books money entering the bank into the account. Foreign currency
+// arrives as cash in its own currency and is exchanged through the fx accounts.
+func externalLegs(account *model.Account, amount decimal.Decimal, fx *model.FxConversion) []model.Posting {
+	legs := []model.Posting{Leg(account.AccountNumber, amount, account.Currency)}
+
+	if fx == nil {
+		return append(legs, Leg(SystemCashAccount(account.Currency), amount.Neg(), account.Currency))
+	}
+
+	legs = append(legs, FxLegs(fx)...)
+	return append(legs, Leg(SystemCashAccount(fx.SourceCurrency), fx.SourceAmount.Neg(), fx.SourceCurrency))
+}
+
+func negateLegs(legs []model.Posting) []model.Posting {
+	for index := range legs {
+		legs[index].Amount = legs[index].Amount.Neg()
+	}
+
+	return legs
+}
+
+// ProcessTransaction applies a deposit or withdrawal. When fx is set the money
+// was requested in another currency and amount is the converted amount.
+func (s *TransactionService) ProcessTransaction(ctx context.Context, transactionID string, accountID string, amount decimal.Decimal, transactionType model.TransactionType, fx *model.FxConversion) error {
 
 	// Start database transaction with pessimistic locking
-	tx := database.GetPostgresDB().Begin()
-	if tx.Error != nil {
-		s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
-		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	tx, err := s.accountService.Begin(ctx)
+	if err != nil {
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
 	}
 
 	defer func() {
 		if r := recover(); r != nil {
-			tx.Rollback()
-			s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
+			s.reject(ctx, tx, transactionID, fmt.Errorf("panic: %v", r))
 		}
 	}()
 
 	// Lock account and get current balance (SELECT FOR UPDATE)
-	var account model.Account
-	result := tx.WithContext(ctx).
-		Where("account_number = ?", accountID).
-		Set("gorm:query_option", "FOR UPDATE").
-		First(&account)
+	account, err := s.accountService.LockAccount(ctx, accountID, tx)
+	if err != nil {
+		return s.reject(ctx, tx, transactionID, fmt.Errorf("account not found or could not be locked: %w", err))
+	}
 
-	if result.Error != nil {
-		tx.Rollback()
-		s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
-		return fmt.Errorf("account not found or could not be locked: %w", result.Error)
+	// Messages are delivered at least once, so skip ones that were already applied
+	if applied, err := s.alreadyApplied(ctx, transactionID, tx); applied || err != nil {
+		return err
+	}
+
+	if err := CheckAccountActivity(account, transactionType == model.TransactionTypeWithdrawal); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if fx != nil && (fx.TargetCurrency != account.Currency || !fx.TargetAmount.Equal(amount)) {
+		return s.reject(ctx, tx, transactionID, ErrFxMismatch)
 	}
 
 	var newBalance decimal.Decimal
+	legs := externalLegs(account, amount, fx)
 
 	// Handle different transaction types with locked balance
 	switch transactionType {
 
 	case model.TransactionTypeWithdrawal:
-		// Check sufficient balance for withdrawal
-		if account.Balance.LessThan(amount) {
+		if err := s.holdService.ReleaseExpired(ctx, account, tx); err != nil {
 			tx.Rollback()
-			s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
-			return errors.New("insufficient balance")
+			return err
+		}
+
+		// Enforce the balance policy under the row lock, funds reserved by holds are not available.
+		// The fee is taken from the same funds.
+		fee := s.feeService.Quote(account.AccountType, transactionType, amount)
+		if err := s.accountService.CheckDebit(account, amount.Add(fee)); err != nil {
+			return s.reject(ctx, tx, transactionID, err)
 		}
 
 		newBalance = account.Balance.Sub(amount)
+		legs = negateLegs(legs)
 
 	case model.TransactionTypeDeposit:
+		// A fee above the deposit takes the difference from the account's funds
+		fee := s.feeService.Quote(account.AccountType, transactionType, amount)
+		if fee.GreaterThan(amount) {
+			if err := s.accountService.CheckDebit(account, fee.Sub(amount)); err != nil {
+				return s.reject(ctx, tx, transactionID, err)
+			}
+		}
+
 		newBalance = account.Balance.Add(amount)
 
 	default:
-		tx.Rollback()
-		s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
-		return errors.New("invalid transaction type")
+		return s.reject(ctx, tx, transactionID, ErrInvalidTransactionType)
 	}
 
 	// Update account balance within the locked transaction
 	if err := s.accountService.UpdateBalance(ctx, accountID, newBalance, tx); err != nil {
-		tx.Rollback()
-		s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
-		return err
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	// Record the movement against the cash counter-account
+	entry := NewJournalEntry(transactionID, transactionType, amount, account.Currency, "", legs...)
+
+	if err := s.ledgerService.PostEntry(ctx, entry, tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	// The fee is a separate entry committed together with the transaction
+	account.Balance = newBalance
+	feeLog, err := s.feeService.ChargeTransactionFee(ctx, account, transactionID, transactionType, amount, tx)
+	if err != nil {
+		return s.reject(ctx, tx, transactionID, err)
 	}
 
 	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusFailed)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
 	}
 
 	// Update transaction status to completed in MongoDB (eventual consistency)
+	if err := s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusCompleted); err != nil {
+		return err
+	}
+
+	s.feeService.RecordFee(ctx, feeLog)
+	return nil
+}
+
+// ProcessTransfer moves amount, in the source account's currency, between two
+// accounts. Accounts in different currencies require fx to credit the destination.
+func (s *TransactionService) ProcessTransfer(ctx context.Context, transactionID string, fromAccountNumber string, toAccountNumber string, amount decimal.Decimal, fx *model.FxConversion) error {
+	if fromAccountNumber == toAccountNumber {
+		s.txLogService.MarkFailed(ctx, transactionID, ErrSameAccountTransfer.Error())
+		return ErrSameAccountTransfer
+	}
+
+	tx, err := s.accountService.Begin(ctx)
+	if err != nil {
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.reject(ctx, tx, transactionID, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	// Always lock in account number order so two opposite transfers can't deadlock
+	lockOrder := []string{fromAccountNumber, toAccountNumber}
+	sort.Strings(lockOrder)
+
+	locked := map[string]*model.Account{}
+	for _, accountNumber := range lockOrder {
+		account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return s.reject(ctx, tx, transactionID, fmt.Errorf("account %s not found or could not be locked: %w", accountNumber, err))
+		}
+
+		locked[accountNumber] = account
+	}
+
+	if applied, err := s.alreadyApplied(ctx, transactionID, tx); applied || err != nil {
+		return err
+	}
+
+	from := locked[fromAccountNumber]
+	to := locked[toAccountNumber]
+
+	if err := CheckAccountActivity(from, true); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := CheckAccountActivity(to, false); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	credit := amount
+	if from.Currency != to.Currency {
+		if fx == nil || fx.SourceCurrency != from.Currency || fx.TargetCurrency != to.Currency || !fx.SourceAmount.Equal(amount) {
+			return s.reject(ctx, tx, transactionID, ErrFxMismatch)
+		}
+
+		credit = fx.TargetAmount
+	}
+
+	if err := s.holdService.ReleaseExpired(ctx, from, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := s.accountService.CheckDebit(from, amount); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := s.accountService.UpdateBalance(ctx, from.AccountNumber, from.Balance.Sub(amount), tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := s.accountService.UpdateBalance(ctx, to.AccountNumber, to.Balance.Add(credit), tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	legs := []model.Posting{
+		Leg(from.AccountNumber, amount.Neg(), from.Currency),
+		Leg(to.AccountNumber, credit, to.Currency),
+	}
+	if from.Currency != to.Currency {
+		legs = append(legs, FxLegs(fx)...)
+	}
+
+	entry := NewJournalEntry(transactionID, model.TransactionTypeTransfer, amount, from.Currency, "", legs...)
+
+	if err := s.ledgerService.PostEntry(ctx, entry, tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
+	}
+
+	return s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusCompleted)
+}
+
+// ProcessReversal posts a compensating entry for up to the remaining amount of
+// a completed transaction. The original journal entry decides which accounts
+// are touched, so deposits, withdrawals and transfers are all reversed the same way.
+func (s *TransactionService) ProcessReversal(ctx context.Context, transactionID string, originalTransactionID string, amount decimal.Decimal) error {
+	tx, err := s.accountService.Begin(ctx)
+	if err != nil {
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.reject(ctx, tx, transactionID, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	original, err := s.ledgerService.GetEntry(ctx, originalTransactionID, tx)
+	if err != nil {
+		return s.reject(ctx, tx, transactionID, fmt.Errorf("original transaction %s has not been posted: %w", originalTransactionID, err))
+	}
+
+	if original.ReversesTransactionId != "" || original.TransactionType == model.TransactionTypeReversal {
+		return s.reject(ctx, tx, transactionID, ErrReverseReversal)
+	}
+
+	// Lock every customer account of the original entry in a deterministic order.
+	// Concurrent reversals of the same transaction serialize on these locks.
+	var lockOrder []string
+	for _, posting := range original.Postings {
+		if !IsSystemAccount(posting.AccountNumber) {
+			lockOrder = append(lockOrder, posting.AccountNumber)
+		}
+	}
+	sort.Strings(lockOrder)
+
+	locked := map[string]*model.Account{}
+	for _, accountNumber := range lockOrder {
+		account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+		if err != nil {
+			return s.reject(ctx, tx, transactionID, fmt.Errorf("account %s not found or could not be locked: %w", accountNumber, err))
+		}
+
+		locked[accountNumber] = account
+	}
+
+	if applied, err := s.alreadyApplied(ctx, transactionID, tx); applied || err != nil {
+		return err
+	}
+
+	reversed, err := s.ledgerService.GetReversedAmount(ctx, originalTransactionID, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if reversed.Add(amount).GreaterThan(original.Amount) {
+		return s.reject(ctx, tx, transactionID, fmt.Errorf("%w, %s remains", ErrReversalExceedsOriginal, original.Amount.Sub(reversed).String()))
+	}
+
+	legs, err := ReversalLegs(original, amount)
+	if err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	for _, leg := range legs {
+		account, ok := locked[leg.AccountNumber]
+		if !ok {
+			continue
+		}
+
+		if err := CheckAccountActivity(account, leg.Amount.IsNegative()); err != nil {
+			return s.reject(ctx, tx, transactionID, err)
+		}
+
+		if err := s.holdService.ReleaseExpired(ctx, account, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// Taking money back is a debit like any other and respects the balance policy
+		if leg.Amount.IsNegative() {
+			if err := s.accountService.CheckDebit(account, leg.Amount.Neg()); err != nil {
+				return s.reject(ctx, tx, transactionID, err)
+			}
+		}
+
+		newBalance := account.Balance.Add(leg.Amount)
+
+		if err := s.accountService.UpdateBalance(ctx, account.AccountNumber, newBalance, tx); err != nil {
+			return s.reject(ctx, tx, transactionID, err)
+		}
+
+		account.Balance = newBalance
+	}
+
+	entry := NewJournalEntry(transactionID, model.TransactionTypeReversal, amount, original.Currency, "", legs...)
+	entry.ReversesTransactionId = originalTransactionID
+
+	if err := s.ledgerService.PostEntry(ctx, entry, tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
+	}
+
+	if err := s.txLogService.AddReversal(ctx, originalTransactionID, transactionID, reversed.Add(amount)); err != nil {
+		log.Printf("Failed to link reversal %s to transaction %s: %v", transactionID, originalTransactionID, err)
+	}
+
+	return s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusCompleted)
+}
+
+// ProcessHoldCapture turns an active hold into a withdrawal of up to the held
+// amount. Whatever is not captured is released back to the available balance.
+func (s *TransactionService) ProcessHoldCapture(ctx context.Context, transactionID string, accountNumber string, holdID string, amount decimal.Decimal) error {
+	tx, err := s.accountService.Begin(ctx)
+	if err != nil {
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.reject(ctx, tx, transactionID, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	// Account first, then hold, the same order used when placing and voiding holds
+	account, err := s.accountService.LockAccount(ctx, accountNumber, tx)
+	if err != nil {
+		return s.reject(ctx, tx, transactionID, fmt.Errorf("account not found or could not be locked: %w", err))
+	}
+
+	if applied, err := s.alreadyApplied(ctx, transactionID, tx); applied || err != nil {
+		return err
+	}
+
+	if err := CheckAccountActivity(account, true); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := s.holdService.ReleaseExpired(ctx, account, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	hold, err := s.holdService.LockHold(ctx, account, holdID, tx)
+	if err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if amount.GreaterThan(hold.Amount) {
+		return s.reject(ctx, tx, transactionID, ErrCaptureExceedsHold)
+	}
+
+	if err := s.holdService.SaveCapture(ctx, hold, amount, transactionID, tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := s.accountService.UpdateHeldBalance(ctx, account.AccountNumber, account.HeldBalance.Sub(hold.Amount), tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := s.accountService.UpdateBalance(ctx, account.AccountNumber, account.Balance.Sub(amount), tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	entry := NewJournalEntry(transactionID, model.TransactionTypeWithdrawal, amount, account.Currency, "",
+		Leg(account.AccountNumber, amount.Neg(), account.Currency),
+		Leg(SystemCashAccount(account.Currency), amount, account.Currency),
+	)
+
+	if err := s.ledgerService.PostEntry(ctx, entry, tx); err != nil {
+		return s.reject(ctx, tx, transactionID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		err = fmt.Errorf("failed to commit transaction: %w", err)
+		s.txLogService.MarkFailed(ctx, transactionID, err.Error())
+		return err
+	}
+
 	return s.txLogService.UpdateTransactionStatus(ctx, transactionID, model.TransactionStatusCompleted)
 }
@@ -5,13 +5,19 @@ import (
 
 	"golang-exercise/internal/database/model"
 	"golang-exercise/internal/repository"
+
+	"github.com/shopspring/decimal"
 )
 
+// Logs exported per batch: the originals of their reversals are read together
+// and the batch is flushed to the client once written
+const HISTORY_EXPORT_BATCH_SIZE = 500
+
 type TransactionLogService struct {
-	txLogRepo *repository.TransactionLogRepository
+	txLogRepo repository.TransactionLogStore
 }
 
-func NewTransactionLogService(txLogRepo *repository.TransactionLogRepository) *TransactionLogService {
+func NewTransactionLogService(txLogRepo repository.TransactionLogStore) *TransactionLogService {
 	return &TransactionLogService{
 		txLogRepo: txLogRepo,
 	}
@@ -21,18 +27,54 @@ func (s *TransactionLogService) LogTransaction(ctx context.Context, txLog *model
 	return s.txLogRepo.Create(ctx, txLog)
 }
 
+// EnsureLogged writes the log unless it was already written, safe to call repeatedly
+func (s *TransactionLogService) EnsureLogged(ctx context.Context, txLog *model.TransactionLog) error {
+	return s.txLogRepo.CreateIfNotExists(ctx, txLog)
+}
+
 func (s *TransactionLogService) GetTransactionsByAccount(ctx context.Context, accountID uint, limit int64) ([]model.TransactionLog, error) {
 	return s.txLogRepo.GetByAccountID(ctx, accountID, limit)
 }
 
+// StreamTransactionsByAccount hands every log of the account to fn, oldest first
+func (s *TransactionLogService) StreamTransactionsByAccount(ctx context.Context, accountID uint, fn func(txLog *model.TransactionLog) error) error {
+	return s.txLogRepo.StreamByAccountID(ctx, accountID, fn)
+}
+
 func (s *TransactionLogService) GetTransactionByID(ctx context.Context, transactionID string) (*model.TransactionLog, error) {
 	return s.txLogRepo.GetByTransactionID(ctx, transactionID)
 }
 
+func (s *TransactionLogService) GetTransactionsByIDs(ctx context.Context, transactionIDs []string) ([]model.TransactionLog, error) {
+	return s.txLogRepo.GetByTransactionIDs(ctx, transactionIDs)
+}
+
 func (s *TransactionLogService) UpdateTransactionStatus(ctx context.Context, transactionID string, status model.TransactionStatus) error {
 	return s.txLogRepo.UpdateStatus(ctx, transactionID, status)
 }
 
+func (s *TransactionLogService) MarkFailed(ctx context.Context, transactionID string, reason string) error {
+	return s.txLogRepo.MarkFailed(ctx, transactionID, reason)
+}
+
+// RecordRetry puts the transaction back in progress while it waits for another attempt
+func (s *TransactionLogService) RecordRetry(ctx context.Context, transactionID string, retryCount int, reason string) error {
+	return s.txLogRepo.RecordAttempt(ctx, transactionID, retryCount, model.TransactionStatusInprogress, reason)
+}
+
+// MarkDeadLettered fails the transaction once it has been given up on
+func (s *TransactionLogService) MarkDeadLettered(ctx context.Context, transactionID string, retryCount int, reason string) error {
+	return s.txLogRepo.RecordAttempt(ctx, transactionID, retryCount, model.TransactionStatusFailed, reason)
+}
+
+func (s *TransactionLogService) LinkFee(ctx context.Context, transactionID string, feeTransactionID string, feeAmount decimal.Decimal) error {
+	return s.txLogRepo.LinkFee(ctx, transactionID, feeTransactionID, feeAmount)
+}
+
+func (s *TransactionLogService) AddReversal(ctx context.Context, transactionID string, reversalID string, reversedAmount decimal.Decimal) error {
+	return s.txLogRepo.AddReversal(ctx, transactionID, reversalID, reversedAmount)
+}
+
 func (s *TransactionLogService) GetTransactionsByStatus(ctx context.Context, status string, limit int64) ([]model.TransactionLog, error) {
 	return s.txLogRepo.GetByStatus(ctx, status, limit)
 }
@@ -44,3 +86,8 @@ func (s *TransactionLogService) GetAllTransactions(ctx context.Context, limit in
 func (s *TransactionLogService) GetTransactionHistory(ctx context.Context, accountID uint, limit int, offset int, startDate, endDate, status string) ([]model.TransactionLog, int, error) {
 	return s.txLogRepo.GetTransactionHistory(ctx, accountID, limit, offset, startDate, endDate, status)
 }
+
+// StreamTransactionHistory hands every log matching the history filters to fn, newest first
+func (s *TransactionLogService) StreamTransactionHistory(ctx context.Context, accountID uint, startDate, endDate, status string, fn func(txLog *model.TransactionLog) error) error {
+	return s.txLogRepo.StreamTransactionHistory(ctx, accountID, startDate, endDate, status, fn)
+}
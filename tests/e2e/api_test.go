@@ -12,7 +12,9 @@ import (
 	"golang-exercise/internal/database/model"
 	requestdto "golang-exercise/internal/dto/request"
 	"golang-exercise/internal/middleware"
+	"golang-exercise/internal/repository"
 	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
 	"golang-exercise/tests/helpers"
 
 	"github.com/gin-gonic/gin"
@@ -47,7 +49,8 @@ func (suite *APITestSuite) SetupSuite() {
 	// Setup router with middleware
 	suite.router = gin.New()
 	suite.router.Use(middleware.Logger())
-	router.SetupRouter(suite.router)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(), config.GetConfig().Idempotency.ReservationTTL())
+	router.SetupRouter(suite.router, idempotencyService)
 }
 
 func (suite *APITestSuite) SetupTest() {
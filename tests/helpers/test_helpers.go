@@ -53,7 +53,7 @@ func (td *TestDatabase) CreateTestAccount(accountNumber string) *model.Account {
 	}
 
 	repo := repository.NewAccountRepository()
-	err := repo.Create(context.Background(), account)
+	err := repo.Create(context.Background(), account, nil)
 	if err != nil {
 		return nil
 	}
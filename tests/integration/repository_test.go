@@ -67,7 +67,7 @@ func (suite *RepositoryTestSuite) TestAccountRepository_Create() {
 		AccountStatus: model.AccountActive,
 	}
 
-	err := suite.accountRepo.Create(ctx, account)
+	err := suite.accountRepo.Create(ctx, account, nil)
 	assert.NoError(suite.T(), err)
 	assert.NotZero(suite.T(), account.ID)
 
@@ -94,7 +94,7 @@ func (suite *RepositoryTestSuite) TestAccountRepository_GetByAccountNumber() {
 		AccountStatus: model.AccountActive,
 	}
 
-	err := suite.accountRepo.Create(ctx, account)
+	err := suite.accountRepo.Create(ctx, account, nil)
 	assert.NoError(suite.T(), err)
 
 	// Retrieve account
@@ -133,7 +133,7 @@ func (suite *RepositoryTestSuite) TestAccountRepository_Count() {
 		AccountStatus: model.AccountActive,
 	}
 
-	err = suite.accountRepo.Create(ctx, account)
+	err = suite.accountRepo.Create(ctx, account, nil)
 	assert.NoError(suite.T(), err)
 
 	// Count should be 1 now
@@ -156,7 +156,7 @@ func (suite *RepositoryTestSuite) TestAccountRepository_ConcurrentTransactions()
 		AccountStatus: model.AccountActive,
 	}
 
-	err := suite.accountRepo.Create(ctx, account)
+	err := suite.accountRepo.Create(ctx, account, nil)
 	assert.NoError(suite.T(), err)
 
 	// Test pessimistic locking with SELECT FOR UPDATE
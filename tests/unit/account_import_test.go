@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang-exercise/internal/accountimport"
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/handler"
+	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const importCSV = "\ufeffCurrency,First_Name,last_name,account_type,initial_balance,branch\n" +
+	"usd,Ada,Lovelace,checking,100,north\n" +
+	"USD,,Hopper,SAVINGS,abc,north\n" +
+	"EUR,Alan,Turing,SAVINGS,,south\n" +
+	"EURO,Grace,Hopper,BROKERAGE,-5,south\n" +
+	"USD,Edsger,Dijkstra,CHECKING,20.50,west\n"
+
+func TestAccountImportReader(t *testing.T) {
+	_, err := accountimport.NewReader(strings.NewReader("first_name,last_name\nAda,Lovelace\n"))
+	assert.EqualError(t, err, "missing columns: account_type, currency")
+
+	reader, err := accountimport.NewReader(strings.NewReader(importCSV))
+	require.NoError(t, err)
+
+	row, err := reader.Next()
+	require.NoError(t, err)
+	require.True(t, row.Valid())
+	assert.Equal(t, 2, row.Line)
+	assert.Equal(t, model.AccountTypeChecking, row.Account.AccountType)
+	assert.Equal(t, "USD", row.Account.Currency)
+	assert.Equal(t, "100", row.Account.InitialBalance.String())
+
+	row, err = reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first_name is required", `initial_balance "abc" is not a number`}, row.Errors)
+}
+
+func TestImportAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	// A dry run reports the same rows without creating anything
+	s := newServices()
+	reader, err := accountimport.NewReader(strings.NewReader(importCSV))
+	require.NoError(t, err)
+
+	report, err := s.accounts.ImportAccounts(ctx, reader, service.ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Rows)
+	assert.Equal(t, 3, report.Valid)
+	assert.Equal(t, 0, report.Created)
+	assert.Empty(t, report.Accounts)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 3, report.Errors[0].Line)
+	assert.Equal(t, 5, report.Errors[1].Line)
+	assert.Len(t, report.Errors[1].Errors, 3)
+
+	// Three valid rows in chunks of two
+	reader, err = accountimport.NewReader(strings.NewReader(importCSV))
+	require.NoError(t, err)
+
+	report, err = s.accounts.ImportAccounts(ctx, reader, service.ImportOptions{ChunkSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+	assert.Equal(t, 2, report.Failed)
+	require.Len(t, report.Accounts, 3)
+	assert.Equal(t, 6, report.Accounts[2].Line)
+
+	dijkstra := report.Accounts[2].AccountNumber
+	assert.Equal(t, "20.5", s.balance(t, dijkstra))
+
+	posted, err := s.ledger.GetPostedBalance(ctx, dijkstra)
+	require.NoError(t, err)
+	assert.Equal(t, "20.5", posted.String())
+
+	txLog, err := s.txLogs.GetTransactionByID(ctx, "OPEN_"+dijkstra)
+	require.NoError(t, err)
+	require.NotNil(t, txLog)
+	assert.Equal(t, model.TransactionStatusCompleted, txLog.Status)
+
+	// Accounts opened without a balance have nothing to log
+	txLog, err = s.txLogs.GetTransactionByID(ctx, "OPEN_"+report.Accounts[1].AccountNumber)
+	require.NoError(t, err)
+	assert.Nil(t, txLog)
+}
+
+func TestImportAccounts_ThousandsOfRows(t *testing.T) {
+	var file strings.Builder
+	file.WriteString("first_name,last_name,account_type,currency\n")
+
+	rows := 3000
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&file, "Ada,Lovelace %d,CHECKING,USD\n", i)
+	}
+
+	reader, err := accountimport.NewReader(strings.NewReader(file.String()))
+	require.NoError(t, err)
+
+	report, err := newServices().accounts.ImportAccounts(context.Background(), reader, service.ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Failed)
+	assert.Equal(t, rows, report.Created)
+
+	numbers := map[string]bool{}
+	for _, account := range report.Accounts {
+		numbers[account.AccountNumber] = true
+	}
+	assert.Len(t, numbers, rows)
+}
+
+func TestAccountHandler_ImportAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newServices()
+
+	accountHandler := handler.NewAccountHandler(s.accounts, s.transactions, s.txLogs, s.ledger, s.outbox, nil, s.fees, nil)
+	engine := gin.New()
+	router.SetupAccountRoutes(engine.Group("/api/v1"), accountHandler, func(c *gin.Context) { c.Next() })
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/accounts/import?dry_run=true", strings.NewReader(importCSV))
+	request.Header.Set("Content-Type", "text/csv")
+	engine.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"dry_run":true`)
+	assert.Contains(t, recorder.Body.String(), `"valid":3`)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", "accounts.csv")
+	require.NoError(t, err)
+	part.Write([]byte("first_name,last_name,account_type,currency,initial_balance\nAda,Lovelace,CHECKING,USD,5\n"))
+	require.NoError(t, writer.Close())
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/accounts/import", &form)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	engine.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"created":1`)
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/accounts/import", strings.NewReader("name\nAda\n")))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
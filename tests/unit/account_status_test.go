@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountStatus_CanTransitionTo(t *testing.T) {
+	for _, transition := range []struct {
+		from, to model.AccountStatus
+		allowed  bool
+	}{
+		{model.AccountActive, model.AccountFrozen, true},
+		{model.AccountActive, model.AccountClosed, true},
+		{model.AccountFrozen, model.AccountActive, true},
+		{model.AccountFrozen, model.AccountClosed, true},
+		{model.AccountActive, model.AccountActive, false},
+		{model.AccountFrozen, model.AccountFrozen, false},
+		// Closing is final
+		{model.AccountClosed, model.AccountActive, false},
+		{model.AccountClosed, model.AccountFrozen, false},
+		{model.AccountClosed, model.AccountClosed, false},
+	} {
+		assert.Equal(t, transition.allowed, transition.from.CanTransitionTo(transition.to), "%s to %s", transition.from, transition.to)
+	}
+}
+
+func TestAccountService_UpdateStatusRefusesToCloseWithFunds(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	_, err := s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountClosed, "")
+	assert.True(t, errors.Is(err, service.ErrInvalidStatusTransition))
+
+	// Money reserved by a hold keeps the account open too
+	hold, err := s.holds.PlaceHold(ctx, account.AccountNumber, dec("40"), time.Now().Add(time.Hour), "")
+	require.NoError(t, err)
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_1", account.AccountNumber, dec("60"), model.TransactionTypeWithdrawal, nil))
+
+	_, err = s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountClosed, "")
+	assert.True(t, errors.Is(err, service.ErrInvalidStatusTransition))
+	assert.Equal(t, model.AccountActive, s.account(t, account.AccountNumber).AccountStatus)
+
+	_, err = s.holds.VoidHold(ctx, account.AccountNumber, hold.HoldId)
+	require.NoError(t, err)
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_2", account.AccountNumber, dec("40"), model.TransactionTypeWithdrawal, nil))
+
+	closed, err := s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountClosed, "customer request")
+	require.NoError(t, err)
+	assert.Equal(t, model.AccountClosed, closed.AccountStatus)
+
+	_, err = s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountActive, "")
+	assert.True(t, errors.Is(err, service.ErrInvalidStatusTransition))
+}
+
+func TestProcessTransaction_RespectsAccountStatus(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+	other := s.openAccount(t, "100")
+
+	failed := func(transactionID string, cause error) {
+		t.Helper()
+
+		txLog, err := s.txLogs.GetTransactionByID(ctx, transactionID)
+		require.NoError(t, err)
+		assert.Equal(t, model.TransactionStatusFailed, txLog.Status)
+		assert.Contains(t, txLog.FailureReason, cause.Error())
+	}
+	logTransaction := func(transactionID string) {
+		require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: transactionID, FromAccountId: account.ID, ToAccountId: account.ID, Status: model.TransactionStatusPending}))
+	}
+
+	// A frozen account still takes money in but pays nothing out
+	_, err := s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountFrozen, "fraud review")
+	require.NoError(t, err)
+
+	logTransaction("TXN_DEPOSIT")
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_DEPOSIT", account.AccountNumber, dec("10"), model.TransactionTypeDeposit, nil))
+	assert.Equal(t, "110", s.balance(t, account.AccountNumber))
+
+	logTransaction("TXN_WITHDRAWAL")
+	err = s.transactions.ProcessTransaction(ctx, "TXN_WITHDRAWAL", account.AccountNumber, dec("10"), model.TransactionTypeWithdrawal, nil)
+	assert.True(t, errors.Is(err, service.ErrAccountFrozen))
+	failed("TXN_WITHDRAWAL", service.ErrAccountFrozen)
+
+	logTransaction("TXN_TRANSFER_OUT")
+	err = s.transactions.ProcessTransfer(ctx, "TXN_TRANSFER_OUT", account.AccountNumber, other.AccountNumber, dec("10"), nil)
+	assert.True(t, errors.Is(err, service.ErrAccountFrozen))
+	failed("TXN_TRANSFER_OUT", service.ErrAccountFrozen)
+
+	logTransaction("TXN_TRANSFER_IN")
+	require.NoError(t, s.transactions.ProcessTransfer(ctx, "TXN_TRANSFER_IN", other.AccountNumber, account.AccountNumber, dec("10"), nil))
+	assert.Equal(t, "120", s.balance(t, account.AccountNumber))
+	assert.Equal(t, "90", s.balance(t, other.AccountNumber))
+
+	// A closed account takes nothing in either direction
+	_, err = s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountActive, "")
+	require.NoError(t, err)
+	require.NoError(t, s.transactions.ProcessTransfer(ctx, "TXN_EMPTY", account.AccountNumber, other.AccountNumber, dec("120"), nil))
+	_, err = s.accounts.UpdateStatus(ctx, account.AccountNumber, model.AccountClosed, "")
+	require.NoError(t, err)
+
+	logTransaction("TXN_CLOSED_DEPOSIT")
+	err = s.transactions.ProcessTransaction(ctx, "TXN_CLOSED_DEPOSIT", account.AccountNumber, dec("10"), model.TransactionTypeDeposit, nil)
+	assert.True(t, errors.Is(err, service.ErrAccountClosed))
+	failed("TXN_CLOSED_DEPOSIT", service.ErrAccountClosed)
+
+	logTransaction("TXN_CLOSED_TRANSFER")
+	err = s.transactions.ProcessTransfer(ctx, "TXN_CLOSED_TRANSFER", other.AccountNumber, account.AccountNumber, dec("10"), nil)
+	assert.True(t, errors.Is(err, service.ErrAccountClosed))
+	failed("TXN_CLOSED_TRANSFER", service.ErrAccountClosed)
+
+	assert.Equal(t, "0", s.balance(t, account.AccountNumber))
+	assert.Equal(t, "210", s.balance(t, other.AccountNumber))
+}
@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setPolicies replaces the configured account policies for the test
+func setPolicies(t *testing.T, policies config.AccountPolicies) {
+	previous := config.GetConfig().AccountPolicies
+	config.GetConfig().AccountPolicies = policies
+	t.Cleanup(func() { config.GetConfig().AccountPolicies = previous })
+}
+
+func TestDefaultBalancePolicy_ParsesDecimalStrings(t *testing.T) {
+	setPolicies(t, config.AccountPolicies{
+		Checking: config.BalancePolicy{MinimumBalance: "100.10", OverdraftLimit: "0.30"},
+		Savings:  config.BalancePolicy{MinimumBalance: "not a number"},
+	})
+
+	checking := service.DefaultBalancePolicy(model.AccountTypeChecking)
+	assert.Equal(t, "100.1", checking.MinimumBalance.String())
+	assert.Equal(t, "99.8", checking.Floor().String())
+
+	// Invalid and unset amounts fall back to zero
+	savings := service.DefaultBalancePolicy(model.AccountTypeSaving)
+	assert.True(t, savings.Floor().IsZero())
+}
+
+func TestCheckDebit(t *testing.T) {
+	setPolicies(t, config.AccountPolicies{
+		Checking: config.BalancePolicy{MinimumBalance: "10", OverdraftLimit: "5"},
+	})
+	s := newServices()
+
+	account := &model.Account{AccountType: model.AccountTypeChecking, Balance: dec("100"), HeldBalance: dec("20")}
+
+	// The floor is 10 - 5 = 5 and held funds are not available
+	assert.NoError(t, s.accounts.CheckDebit(account, dec("75")))
+	assert.ErrorIs(t, s.accounts.CheckDebit(account, dec("75.01")), service.ErrInsufficientFunds)
+
+	// The account's own overrides win over the default of its type
+	minimum := dec("0")
+	overdraft := dec("50")
+	account.MinimumBalance = &minimum
+	account.OverdraftLimit = &overdraft
+	assert.Equal(t, "-50", s.accounts.BalancePolicy(account).Floor().String())
+	assert.NoError(t, s.accounts.CheckDebit(account, dec("130")))
+	assert.ErrorIs(t, s.accounts.CheckDebit(account, dec("130.01")), service.ErrInsufficientFunds)
+}
+
+func TestProcessReversal_RespectsBalancePolicy(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	from := s.openAccount(t, "100")
+	to := s.openAccount(t, "0")
+
+	require.NoError(t, s.transactions.ProcessTransfer(ctx, "TXN_1", from.AccountNumber, to.AccountNumber, dec("50"), nil))
+
+	minimum := dec("20")
+	_, err := s.accounts.UpdateBalancePolicy(ctx, to.AccountNumber, &minimum, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: "REV_1", Status: model.TransactionStatusPending, Timestamp: time.Now()}))
+
+	// Taking all 50 back would leave the payee below its minimum of 20
+	err = s.transactions.ProcessReversal(ctx, "REV_1", "TXN_1", dec("50"))
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+	assert.Equal(t, "50", s.balance(t, to.AccountNumber))
+
+	require.NoError(t, s.transactions.ProcessReversal(ctx, "REV_2", "TXN_1", dec("30")))
+	assert.Equal(t, "20", s.balance(t, to.AccountNumber))
+	assert.Equal(t, "80", s.balance(t, from.AccountNumber))
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerService_GetBalanceAtWithSnapshots(t *testing.T) {
+	ctx := context.Background()
+	ledgerRepo := memory.NewLedgerRepository(memory.NewStore())
+	ledger := service.NewLedgerService(ledgerRepo)
+
+	day1 := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day1.AddDate(0, 0, 2)
+	postAt(t, ledger, "TXN_1", "CHK1", "100", day1.Add(10*time.Hour))
+	postAt(t, ledger, "TXN_2", "CHK1", "50", day2.Add(10*time.Hour))
+	postAt(t, ledger, "TXN_3", "CHK1", "-30", day3.Add(10*time.Hour))
+
+	// Snapshots that differ from the postings show whether they are used
+	require.NoError(t, ledgerRepo.CreateSnapshot(ctx, &model.BalanceSnapshot{AccountNumber: "CHK1", AsOf: day2, Balance: dec("1000")}))
+	require.NoError(t, ledgerRepo.CreateSnapshot(ctx, &model.BalanceSnapshot{AccountNumber: "CHK1", AsOf: day3, Balance: dec("2000")}))
+
+	balanceAt := func(at time.Time) string {
+		balance, err := ledger.GetBalanceAt(ctx, "CHK1", at)
+		require.NoError(t, err)
+		return balance.String()
+	}
+
+	// Before the first snapshot the postings are summed from the start
+	assert.Equal(t, "0", balanceAt(day1))
+	assert.Equal(t, "100", balanceAt(day1.Add(12*time.Hour)))
+
+	// Exactly at a snapshot it is the balance
+	assert.Equal(t, "1000", balanceAt(day2))
+	assert.Equal(t, "2000", balanceAt(day3))
+
+	// Between snapshots the later postings are added to the earlier one
+	assert.Equal(t, "1000", balanceAt(day2.Add(10*time.Hour)))
+	assert.Equal(t, "1050", balanceAt(day2.Add(12*time.Hour)))
+	assert.Equal(t, "1970", balanceAt(day3.Add(12*time.Hour)))
+
+	// AsOf includes a posting made at that very instant, At does not
+	asOf, err := ledger.GetBalanceAsOf(ctx, "CHK1", day2.Add(10*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "1050", asOf.String())
+
+	asOf, err = ledger.GetBalanceAsOf(ctx, "CHK1", day1.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "0", asOf.String())
+}
+
+func TestLedgerService_SnapshotBalances(t *testing.T) {
+	ctx := context.Background()
+	ledgerRepo := memory.NewLedgerRepository(memory.NewStore())
+	ledger := service.NewLedgerService(ledgerRepo)
+
+	// The midnight SnapshotBalances settles on
+	now := time.Now().UTC()
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if now.Sub(asOf) < service.BALANCE_SNAPSHOT_SETTLE_DELAY {
+		asOf = asOf.AddDate(0, 0, -1)
+	}
+
+	postAt(t, ledger, "TXN_OLD", "CHK1", "10", asOf.Add(-48*time.Hour))
+	postAt(t, ledger, "TXN_BEFORE", "CHK1", "20", asOf.Add(-time.Nanosecond))
+	postAt(t, ledger, "TXN_AT", "CHK1", "7", asOf)
+
+	require.NoError(t, ledger.SnapshotBalances(ctx))
+
+	// The snapshot covers the postings before midnight only
+	snapshot, err := ledgerRepo.GetLatestSnapshot(ctx, "CHK1", asOf)
+	require.NoError(t, err)
+	assert.True(t, snapshot.AsOf.Equal(asOf))
+	assert.Equal(t, "30", snapshot.Balance.String())
+
+	_, err = ledgerRepo.GetLatestSnapshot(ctx, "CHK1", asOf.Add(-time.Nanosecond))
+	assert.Error(t, err)
+
+	balance, err := ledger.GetBalanceAt(ctx, "CHK1", asOf.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "37", balance.String())
+
+	// Accounts without postings since their snapshot are skipped
+	accountNumbers, err := ledgerRepo.ListAccountsToSnapshot(ctx, asOf)
+	require.NoError(t, err)
+	assert.Empty(t, accountNumbers)
+	require.NoError(t, ledger.SnapshotBalances(ctx))
+}
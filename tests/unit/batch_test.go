@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/handler"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBatch_RejectsInvalidItemsTogether(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "50")
+
+	_, _, err := s.batches.CreateBatch(ctx, &requestdto.CreateBatch{Items: []requestdto.MoveMoneyFromAccount{
+		{AccountNumber: account.AccountNumber, Amount: dec("10"), Type: model.TransactionTypeDeposit},
+		{AccountNumber: "UNKNOWN", Amount: dec("10"), Type: model.TransactionTypeDeposit},
+		{AccountNumber: account.AccountNumber, Amount: dec("0"), Type: model.TransactionTypeDeposit},
+		// Only the earlier deposit makes room for this withdrawal
+		{AccountNumber: account.AccountNumber, Amount: dec("55"), Type: model.TransactionTypeWithdrawal},
+		{AccountNumber: account.AccountNumber, Amount: dec("10"), Type: model.TransactionTypeWithdrawal},
+	}})
+
+	var invalid *service.BatchValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Len(t, invalid.Items, 3)
+	assert.Equal(t, 1, invalid.Items[0].Index)
+	assert.Equal(t, 2, invalid.Items[1].Index)
+	assert.Equal(t, 4, invalid.Items[2].Index)
+
+	// Nothing of a rejected batch is queued
+	sent, err := s.outbox.ProcessPending(ctx, 10, func(*dto.TransactionMessage) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	_, _, err = s.batches.CreateBatch(ctx, &requestdto.CreateBatch{})
+	assert.True(t, errors.Is(err, service.ErrInvalidBatch))
+}
+
+func TestBatchProgress_AggregatesItemOutcomes(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "20")
+
+	batch, items, err := s.batches.CreateBatch(ctx, &requestdto.CreateBatch{Reference: "payroll", Items: []requestdto.MoveMoneyFromAccount{
+		{AccountNumber: account.AccountNumber, Amount: dec("100"), Type: model.TransactionTypeDeposit},
+		{AccountNumber: account.AccountNumber, Amount: dec("30"), Type: model.TransactionTypeWithdrawal},
+	}})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.NotEqual(t, items[0].TransactionId, items[1].TransactionId)
+
+	progress, err := s.batches.GetBatchProgress(ctx, batch.BatchId)
+	require.NoError(t, err)
+	assert.Equal(t, service.BatchStatusInProgress, progress.Status)
+	assert.Equal(t, 2, progress.Pending)
+
+	// Relay the deposit only, straight into the worker's processing
+	sent, err := s.outbox.ProcessPending(ctx, 1, func(txMsg *dto.TransactionMessage) error {
+		return s.transactions.ProcessTransaction(ctx, txMsg.ID, txMsg.AccountNumber, txMsg.Amount, txMsg.Type, txMsg.Fx)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, sent)
+
+	progress, err = s.batches.GetBatchProgress(ctx, batch.BatchId)
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Completed)
+	assert.Equal(t, 1, progress.Pending)
+	assert.Equal(t, model.TransactionStatusCompleted, progress.Items[0].Status)
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: items[1].TransactionId, Status: model.TransactionStatusPending}))
+	require.NoError(t, s.txLogs.MarkFailed(ctx, items[1].TransactionId, "declined"))
+
+	progress, err = s.batches.GetBatchProgress(ctx, batch.BatchId)
+	require.NoError(t, err)
+	assert.Equal(t, service.BatchStatusCompletedWithFailures, progress.Status)
+	assert.Equal(t, "declined", progress.Items[1].FailureReason)
+	assert.Equal(t, "120", s.balance(t, account.AccountNumber))
+
+	_, err = s.batches.GetBatchProgress(ctx, "BATCH_MISSING")
+	assert.True(t, errors.Is(err, service.ErrBatchNotFound))
+}
+
+func TestBatchHandler_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newServices()
+	account := s.openAccount(t, "0")
+
+	noIdempotency := func(c *gin.Context) { c.Next() }
+	engine := gin.New()
+	v1 := engine.Group("/api/v1")
+	router.SetupTransactionRoutes(v1, handler.NewTransactionHandler(s.accounts, s.txLogs, s.outbox, s.ledger), noIdempotency)
+	router.SetupBatchRoutes(v1, handler.NewBatchHandler(s.batches), noIdempotency)
+
+	recorder := httptest.NewRecorder()
+	body := `{"items":[{"account_number":"` + account.AccountNumber + `","amount":"25","type":"DEPOSIT"},{"account_number":"NOPE","amount":"5","type":"DEPOSIT"}]}`
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/transactions/batch", strings.NewReader(body)))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"index":1`)
+
+	recorder = httptest.NewRecorder()
+	body = `{"items":[{"account_number":"` + account.AccountNumber + `","amount":"25","type":"DEPOSIT"}]}`
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/transactions/batch", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	batchID := strings.Split(strings.Split(recorder.Body.String(), `"BatchID":"`)[1], `"`)[0]
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/batches/"+batchID, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"pending":1`)
+}
+
+func TestCreateBatch_ChecksAccountStatusAndFees(t *testing.T) {
+	ctx := context.Background()
+	setPolicies(t, config.AccountPolicies{})
+	s := newServices()
+	frozen := s.openAccount(t, "50")
+	account := s.openAccount(t, "10")
+
+	_, err := s.accounts.UpdateStatus(ctx, frozen.AccountNumber, model.AccountFrozen, "")
+	require.NoError(t, err)
+
+	feeService := service.NewFeeService(memory.NewFeeRepository(memory.NewStore()), s.accounts, s.ledger, s.txLogs, config.Fees{
+		TransactionFees: []config.TransactionFee{{AccountType: "CHECKING", TransactionType: "WITHDRAWAL", Flat: "1"}},
+	})
+	batches := service.NewBatchService(memory.NewBatchRepository(memory.NewStore()), s.accounts, nil, feeService, s.outbox, s.txLogs)
+
+	_, _, err = batches.CreateBatch(ctx, &requestdto.CreateBatch{Items: []requestdto.MoveMoneyFromAccount{
+		// A frozen account still takes deposits
+		{AccountNumber: frozen.AccountNumber, Amount: dec("10"), Type: model.TransactionTypeDeposit},
+		{AccountNumber: frozen.AccountNumber, Amount: dec("10"), Type: model.TransactionTypeWithdrawal},
+		// 4.50 and 4.50 fit the balance, but not with a 1.00 fee each
+		{AccountNumber: account.AccountNumber, Amount: dec("4.5"), Type: model.TransactionTypeWithdrawal},
+		{AccountNumber: account.AccountNumber, Amount: dec("4.5"), Type: model.TransactionTypeWithdrawal},
+	}})
+
+	var invalid *service.BatchValidationError
+	require.True(t, errors.As(err, &invalid))
+	require.Len(t, invalid.Items, 2)
+	assert.Equal(t, 1, invalid.Items[0].Index)
+	assert.Contains(t, invalid.Items[0].Error, service.ErrAccountFrozen.Error())
+	assert.Equal(t, 3, invalid.Items[1].Index)
+}
@@ -0,0 +1,223 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	"golang-exercise/internal/messaging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, deliveries <-chan messaging.Delivery) messaging.Delivery {
+	select {
+	case delivery := <-deliveries:
+		return delivery
+	case <-time.After(time.Second):
+		t.Fatal("no delivery")
+		return nil
+	}
+}
+
+func TestLocalBroker_AckNackRequeue(t *testing.T) {
+	broker := messaging.NewLocalBroker(messaging.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond})
+	defer broker.Close()
+
+	var deliveries <-chan messaging.Delivery
+	require.NoError(t, broker.Subscribe("ledger_queue", 1, func(ch <-chan messaging.Delivery) { deliveries = ch }))
+
+	require.NoError(t, broker.PublishTransaction(&dto.TransactionMessage{ID: "TXN_1"}))
+	require.NoError(t, broker.PublishTransaction(&dto.TransactionMessage{ID: "TXN_2"}))
+
+	// Prefetch 1 holds back the second message until the first is settled
+	first := receive(t, deliveries)
+	assert.Equal(t, "TXN_1", first.MessageID())
+	select {
+	case <-deliveries:
+		t.Fatal("delivered beyond prefetch")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// A requeued message goes to the back of the queue
+	require.NoError(t, first.Nack(true))
+	assert.True(t, errors.Is(first.Ack(), messaging.ErrAlreadySettled))
+
+	second := receive(t, deliveries)
+	assert.Equal(t, "TXN_2", second.MessageID())
+	require.NoError(t, second.Ack())
+
+	redelivered := receive(t, deliveries)
+	assert.Equal(t, "TXN_1", redelivered.MessageID())
+
+	// A retry comes back after the delay with its attempt count
+	require.NoError(t, redelivered.Retry(1, errors.New("timeout")))
+	require.NoError(t, redelivered.Ack())
+
+	retried := receive(t, deliveries)
+	assert.Equal(t, 1, retried.Attempts())
+
+	require.NoError(t, retried.DeadLetter(1, errors.New("gave up")))
+	require.NoError(t, retried.Ack())
+
+	assert.Equal(t, 0, broker.Pending())
+	require.Len(t, broker.DeadLettered(), 1)
+	assert.Equal(t, "gave up", broker.DeadLettered()[0].LastError)
+}
+
+func TestTransactionConsumer_LocalBroker(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	from := s.openAccount(t, "100")
+	to := s.openAccount(t, "0")
+
+	retryPolicy := messaging.RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}
+	broker := messaging.NewLocalBroker(retryPolicy)
+	defer broker.Close()
+
+	consumer := messaging.NewTransactionConsumer(broker, s.accounts, s.transactions, s.txLogs, retryPolicy)
+	require.NoError(t, consumer.StartConsuming(context.Background()))
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: "TXN_1", FromAccountId: from.ID, ToAccountId: to.ID, Status: model.TransactionStatusPending}))
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: "TXN_2", FromAccountId: from.ID, ToAccountId: from.ID, Status: model.TransactionStatusPending}))
+
+	require.NoError(t, broker.PublishTransaction(&dto.TransactionMessage{
+		ID:              "TXN_1",
+		Type:            model.TransactionTypeTransfer,
+		AccountNumber:   from.AccountNumber,
+		ToAccountNumber: to.AccountNumber,
+		Amount:          dec("40"),
+	}))
+
+	// Insufficient funds never succeed, the message is dead-lettered without retries
+	require.NoError(t, broker.PublishTransaction(&dto.TransactionMessage{
+		ID:            "TXN_2",
+		Type:          model.TransactionTypeWithdrawal,
+		AccountNumber: from.AccountNumber,
+		Amount:        dec("500"),
+	}))
+
+	assert.Eventually(t, func() bool { return broker.Pending() == 0 }, time.Second, 5*time.Millisecond)
+
+	stopCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	require.NoError(t, consumer.Stop(stopCtx))
+
+	assert.Equal(t, "60", s.balance(t, from.AccountNumber))
+	assert.Equal(t, "40", s.balance(t, to.AccountNumber))
+
+	dead := broker.DeadLettered()
+	require.Len(t, dead, 1)
+	assert.Equal(t, "TXN_2", dead[0].ID)
+}
+
+// refusedDelivery is a delivery whose retry and dead-letter copies the broker refuses
+type refusedDelivery struct {
+	body   []byte
+	nacked chan bool
+}
+
+func (d *refusedDelivery) MessageID() string { return "TXN_1" }
+func (d *refusedDelivery) Body() []byte      { return d.body }
+func (d *refusedDelivery) Attempts() int     { return 0 }
+func (d *refusedDelivery) Ack() error        { return nil }
+
+func (d *refusedDelivery) Nack(requeue bool) error {
+	d.nacked <- requeue
+	return nil
+}
+
+func (d *refusedDelivery) Retry(attempt int, cause error) error { return messaging.ErrNotConnected }
+
+func (d *refusedDelivery) DeadLetter(attempts int, cause error) error {
+	return messaging.ErrNotConnected
+}
+
+type channelSubscriber struct {
+	deliveries chan messaging.Delivery
+}
+
+func (subscriber *channelSubscriber) Subscribe(queueName string, prefetch int, handle func(<-chan messaging.Delivery)) error {
+	handle(subscriber.deliveries)
+	return nil
+}
+
+func (subscriber *channelSubscriber) Unsubscribe() error {
+	close(subscriber.deliveries)
+	return nil
+}
+
+func TestTransactionConsumer_BacksOffWhenRetryIsRefused(t *testing.T) {
+	s := newServices()
+	retryPolicy := messaging.RetryPolicy{MaxRetries: 2, BaseDelay: 50 * time.Millisecond}
+	subscriber := &channelSubscriber{deliveries: make(chan messaging.Delivery, 1)}
+
+	consumer := messaging.NewTransactionConsumer(subscriber, s.accounts, s.transactions, s.txLogs, retryPolicy)
+	require.NoError(t, consumer.StartConsuming(context.Background()))
+
+	// An unknown account is not a permanent failure, so a retry is attempted
+	delivery := &refusedDelivery{body: []byte(`{"id":"TXN_1","type":"DEPOSIT","account_number":"MISSING","amount":"10"}`), nacked: make(chan bool, 1)}
+	started := time.Now()
+	subscriber.deliveries <- delivery
+
+	select {
+	case requeue := <-delivery.nacked:
+		assert.True(t, requeue)
+		assert.GreaterOrEqual(t, time.Since(started), retryPolicy.Delay(1))
+	case <-time.After(time.Second):
+		t.Fatal("delivery was not requeued")
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, consumer.Stop(stopCtx))
+}
+
+func TestProcessTransaction_CompletedClearsFailureReason(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "0")
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: "TXN_1", FromAccountId: account.ID, ToAccountId: account.ID, Status: model.TransactionStatusPending}))
+	require.NoError(t, s.txLogs.RecordRetry(ctx, "TXN_1", 1, "connection refused"))
+
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_1", account.AccountNumber, dec("10"), model.TransactionTypeDeposit, nil))
+
+	txLog, err := s.txLogs.GetTransactionByID(ctx, "TXN_1")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransactionStatusCompleted, txLog.Status)
+	assert.Equal(t, 1, txLog.RetryCount)
+	assert.Empty(t, txLog.FailureReason)
+}
+
+func TestTransactionConsumer_RequeuesWhenCancelled(t *testing.T) {
+	s := newServices()
+	retryPolicy := messaging.RetryPolicy{MaxRetries: 2, BaseDelay: time.Hour}
+	subscriber := &channelSubscriber{deliveries: make(chan messaging.Delivery, 1)}
+
+	// A cancelled context stands for a drain that timed out
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	consumer := messaging.NewTransactionConsumer(subscriber, s.accounts, s.transactions, s.txLogs, retryPolicy)
+	require.NoError(t, consumer.StartConsuming(ctx))
+
+	// The failure is not counted as an attempt, the message goes straight back
+	delivery := &refusedDelivery{body: []byte(`{"id":"TXN_1","type":"DEPOSIT","account_number":"MISSING","amount":"10"}`), nacked: make(chan bool, 1)}
+	subscriber.deliveries <- delivery
+
+	select {
+	case requeue := <-delivery.nacked:
+		assert.True(t, requeue)
+	case <-time.After(time.Second):
+		t.Fatal("delivery was not requeued")
+	}
+
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), time.Second)
+	defer cancelStop()
+	require.NoError(t, consumer.Stop(stopCtx))
+}
@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/messaging"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// confirmChannel records the message ids in publish order, so the delivery
+// tag of a message is its position plus one
+type confirmChannel struct {
+	mu         sync.Mutex
+	messageIDs []string
+}
+
+func (c *confirmChannel) Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messageIDs = append(c.messageIDs, msg.MessageId)
+	return nil
+}
+
+func (c *confirmChannel) Close() error { return nil }
+
+func (c *confirmChannel) deliveryTag(messageID string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, id := range c.messageIDs {
+		if id == messageID {
+			return uint64(i + 1)
+		}
+	}
+
+	return 0
+}
+
+func newTestConfirmer() (*messaging.Confirmer, *confirmChannel, chan amqp.Confirmation, chan amqp.Return) {
+	channel := &confirmChannel{}
+	confirms := make(chan amqp.Confirmation)
+	returns := make(chan amqp.Return, messaging.PUBLISH_CONFIRM_BUFFER)
+
+	return messaging.NewConfirmer(channel, confirms, returns), channel, confirms, returns
+}
+
+func settled(t *testing.T, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("publish was not settled")
+		return nil
+	}
+}
+
+func TestConfirmer_ConcurrentPublishes(t *testing.T) {
+	confirmer, channel, confirms, _ := newTestConfirmer()
+
+	const publishes = 20
+	results := make([]<-chan error, publishes)
+
+	var wg sync.WaitGroup
+	for i := range publishes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := confirmer.Publish("", "ledger_queue", amqp.Publishing{MessageId: fmt.Sprintf("MSG_%d", i)})
+			assert.NoError(t, err)
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	// Confirmations arrive out of order, every odd message is refused
+	for i := publishes - 1; i >= 0; i-- {
+		confirms <- amqp.Confirmation{DeliveryTag: channel.deliveryTag(fmt.Sprintf("MSG_%d", i)), Ack: i%2 == 0}
+	}
+
+	for i, result := range results {
+		err := settled(t, result)
+		if i%2 == 0 {
+			assert.NoError(t, err, i)
+		} else {
+			assert.True(t, errors.Is(err, messaging.ErrPublishNacked), i)
+		}
+	}
+}
+
+func TestConfirmer_ReturnBeforeAck(t *testing.T) {
+	confirmer, _, confirms, returns := newTestConfirmer()
+
+	returnedResult, err := confirmer.Publish("", "nowhere", amqp.Publishing{MessageId: "MSG_RETURNED"})
+	require.NoError(t, err)
+	routedResult, err := confirmer.Publish("", "ledger_queue", amqp.Publishing{MessageId: "MSG_ROUTED"})
+	require.NoError(t, err)
+
+	// An unroutable message is still acked, its return comes first
+	returns <- amqp.Return{MessageId: "MSG_RETURNED", ReplyText: "NO_ROUTE"}
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+
+	err = settled(t, returnedResult)
+	assert.True(t, errors.Is(err, messaging.ErrPublishReturned))
+	assert.Contains(t, err.Error(), "NO_ROUTE")
+	assert.NoError(t, settled(t, routedResult))
+}
+
+func TestConfirmer_Nack(t *testing.T) {
+	confirmer, _, confirms, _ := newTestConfirmer()
+
+	result, err := confirmer.Publish("", "ledger_queue", amqp.Publishing{MessageId: "MSG_1"})
+	require.NoError(t, err)
+
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: false}
+	assert.True(t, errors.Is(settled(t, result), messaging.ErrPublishNacked))
+}
+
+func TestConfirmer_ChannelCloseFailsPending(t *testing.T) {
+	confirmer, _, confirms, _ := newTestConfirmer()
+
+	first, err := confirmer.Publish("", "ledger_queue", amqp.Publishing{MessageId: "MSG_1"})
+	require.NoError(t, err)
+	second, err := confirmer.Publish("", "ledger_queue", amqp.Publishing{MessageId: "MSG_2"})
+	require.NoError(t, err)
+
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	close(confirms)
+
+	assert.NoError(t, settled(t, first))
+	assert.True(t, errors.Is(settled(t, second), messaging.ErrNotConnected))
+
+	// Once closed the confirmer refuses new publishes
+	require.Eventually(t, func() bool {
+		_, err := confirmer.Publish("", "ledger_queue", amqp.Publishing{MessageId: "MSG_3"})
+		return errors.Is(err, messaging.ErrNotConnected)
+	}, time.Second, time.Millisecond)
+}
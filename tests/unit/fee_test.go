@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/fee"
+	"golang-exercise/internal/handler"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionFee_Quote(t *testing.T) {
+	rule := fee.TransactionFee{Flat: dec("0.25"), Percentage: dec("0.001"), Min: dec("0.5"), Max: dec("5")}
+
+	// Flat plus percentage, rounded to cents
+	assert.Equal(t, "1.48", rule.Quote(dec("1234.5")).String())
+	// Raised to the minimum
+	assert.Equal(t, "0.5", rule.Quote(dec("10")).String())
+	// Capped at the maximum
+	assert.Equal(t, "5", rule.Quote(dec("100000")).String())
+
+	uncapped := fee.TransactionFee{Percentage: dec("0.01")}
+	assert.Equal(t, "1000", uncapped.Quote(dec("100000")).String())
+	assert.Equal(t, "0.01", uncapped.Quote(dec("0.5")).String())
+}
+
+func TestMaintenanceFee_Charge(t *testing.T) {
+	rule := fee.MaintenanceFee{Amount: dec("5"), WaiveAbove: dec("1500")}
+
+	assert.Equal(t, "5", rule.Charge(dec("1499.99")).String())
+	assert.True(t, rule.Charge(dec("1500")).IsZero())
+
+	never := fee.MaintenanceFee{Amount: dec("5")}
+	assert.Equal(t, "5", never.Charge(dec("1000000")).String())
+}
+
+func TestSchedule_Quote(t *testing.T) {
+	schedule := fee.Schedule{
+		TransactionFees: []fee.TransactionFee{
+			{AccountType: model.AccountTypeChecking, TransactionType: model.TransactionTypeWithdrawal, Flat: dec("1")},
+		},
+		MaintenanceFees: []fee.MaintenanceFee{
+			{AccountType: model.AccountTypeChecking, Amount: dec("5")},
+		},
+	}
+
+	assert.Equal(t, "1", schedule.Quote(model.AccountTypeChecking, model.TransactionTypeWithdrawal, dec("50")).String())
+	assert.True(t, schedule.Quote(model.AccountTypeChecking, model.TransactionTypeDeposit, dec("50")).IsZero())
+	assert.True(t, schedule.Quote(model.AccountTypeSaving, model.TransactionTypeWithdrawal, dec("50")).IsZero())
+
+	assert.NotNil(t, schedule.MaintenanceFeeFor(model.AccountTypeChecking))
+	assert.Nil(t, schedule.MaintenanceFeeFor(model.AccountTypeSaving))
+}
+
+func TestNewFeeService_ParsesDecimalStrings(t *testing.T) {
+	store := memory.NewStore()
+	feeService := service.NewFeeService(memory.NewFeeRepository(store), nil, nil, nil, config.Fees{
+		TransactionFees: []config.TransactionFee{
+			{AccountType: "CHECKING", TransactionType: "WITHDRAWAL", Flat: "0.10", Percentage: "0.001", Max: "not a number"},
+		},
+		MaintenanceFees: []config.MaintenanceFee{
+			{AccountType: "CHECKING", Amount: "4.99", WaiveAbove: "1500.00"},
+		},
+	})
+
+	// 0.10 + 0.1% of 30.00 is exactly 0.13, the invalid max is treated as uncapped
+	assert.Equal(t, "0.13", feeService.Quote(model.AccountTypeChecking, model.TransactionTypeWithdrawal, dec("30")).String())
+	assert.Equal(t, "1000.1", feeService.Quote(model.AccountTypeChecking, model.TransactionTypeWithdrawal, dec("1000000")).String())
+
+	maintenance := feeService.Schedule().MaintenanceFeeFor(model.AccountTypeChecking)
+	require.NotNil(t, maintenance)
+	assert.Equal(t, "4.99", maintenance.Amount.String())
+	assert.Equal(t, "1500", maintenance.WaiveAbove.String())
+}
+
+func TestProcessTransaction_DepositFeeRespectsBalancePolicy(t *testing.T) {
+	ctx := context.Background()
+	setPolicies(t, config.AccountPolicies{})
+
+	store := memory.NewStore()
+	txLogService := service.NewTransactionLogService(memory.NewTransactionLogRepository(store))
+	ledgerService := service.NewLedgerService(memory.NewLedgerRepository(store))
+	accountService := service.NewAccountService(memory.NewAccountRepository(store), ledgerService, txLogService)
+	holdService := service.NewHoldService(memory.NewHoldRepository(store), accountService)
+	feeService := service.NewFeeService(memory.NewFeeRepository(store), accountService, ledgerService, txLogService, config.Fees{
+		TransactionFees: []config.TransactionFee{{AccountType: "CHECKING", TransactionType: "DEPOSIT", Flat: "2"}},
+	})
+	transactionService := service.NewTransactionService(accountService, txLogService, ledgerService, holdService, feeService)
+
+	account, err := accountService.CreateAccount(ctx, &requestdto.CreateAccount{
+		FirstName:   "Ada",
+		LastName:    "Lovelace",
+		AccountType: model.AccountTypeChecking,
+		Currency:    "USD",
+	})
+	require.NoError(t, err)
+
+	// The 2.00 fee on a 1.00 deposit would leave the empty account at -1.00
+	require.NoError(t, txLogService.LogTransaction(ctx, &model.TransactionLog{TransactionId: "TXN_1", FromAccountId: account.ID, ToAccountId: account.ID, Status: model.TransactionStatusPending}))
+	err = transactionService.ProcessTransaction(ctx, "TXN_1", account.AccountNumber, dec("1"), model.TransactionTypeDeposit, nil)
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+
+	txLog, err := txLogService.GetTransactionByID(ctx, "TXN_1")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransactionStatusFailed, txLog.Status)
+
+	// A deposit that covers its fee goes through
+	require.NoError(t, txLogService.LogTransaction(ctx, &model.TransactionLog{TransactionId: "TXN_2", FromAccountId: account.ID, ToAccountId: account.ID, Status: model.TransactionStatusPending}))
+	require.NoError(t, transactionService.ProcessTransaction(ctx, "TXN_2", account.AccountNumber, dec("5"), model.TransactionTypeDeposit, nil))
+
+	stored, err := accountService.GetAccount(ctx, &requestdto.GetAccount{AccountNumber: account.AccountNumber})
+	require.NoError(t, err)
+	assert.Equal(t, "3", stored.Balance.String())
+}
+
+func TestFeeHandler_QuoteFeeValidatesTransactionType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newServices()
+
+	engine := gin.New()
+	feeService := service.NewFeeService(memory.NewFeeRepository(memory.NewStore()), s.accounts, s.ledger, s.txLogs, config.Fees{})
+	router.SetupFeeRoutes(engine.Group("/api/v1"), handler.NewFeeHandler(s.accounts, feeService))
+
+	quote := func(body string) int {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/fees/quote", strings.NewReader(body)))
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, quote(`{"account_type":"CHECKING","transaction_type":"WITHDRAWAL","amount":"10"}`))
+	assert.Equal(t, http.StatusBadRequest, quote(`{"account_type":"CHECKING","transaction_type":"TRANSFER","amount":"10"}`))
+	assert.Equal(t, http.StatusBadRequest, quote(`{"account_type":"CHECKING","transaction_type":"WITHDRAWL","amount":"10"}`))
+}
+
+func TestAccountHandler_ProcessFundsChecksTheFee(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setPolicies(t, config.AccountPolicies{})
+	s := newServices()
+	account := s.openAccount(t, "10")
+
+	feeService := service.NewFeeService(memory.NewFeeRepository(memory.NewStore()), s.accounts, s.ledger, s.txLogs, config.Fees{
+		TransactionFees: []config.TransactionFee{{AccountType: "CHECKING", TransactionType: "WITHDRAWAL", Flat: "1"}},
+	})
+	accountHandler := handler.NewAccountHandler(s.accounts, s.transactions, s.txLogs, s.ledger, s.outbox, nil, feeService, nil)
+	engine := gin.New()
+	router.SetupAccountRoutes(engine.Group("/api/v1"), accountHandler, func(c *gin.Context) { c.Next() })
+
+	withdraw := func(amount string) int {
+		recorder := httptest.NewRecorder()
+		body := `{"account_number":"` + account.AccountNumber + `","amount":"` + amount + `","type":"WITHDRAWAL"}`
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/accounts/funds", strings.NewReader(body)))
+		return recorder.Code
+	}
+
+	// 9.50 fits the balance but not together with its 1.00 fee
+	assert.Equal(t, http.StatusBadRequest, withdraw("9.5"))
+	assert.Equal(t, http.StatusOK, withdraw("9"))
+}
@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFxService_Convert(t *testing.T) {
+	ctx := context.Background()
+	fx := service.NewFxService(memory.NewFxRateRepository(memory.NewStore()))
+
+	now := time.Now()
+	require.NoError(t, fx.LoadRates(ctx, []model.FxRate{
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: dec("0.9"), EffectiveAt: now.Add(-48 * time.Hour)},
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: dec("1.235"), EffectiveAt: now.Add(-time.Hour)},
+	}))
+
+	// The target amount is rounded half up to the cent, the rate is kept whole
+	conversion, err := fx.Convert(ctx, dec("1"), "USD", "EUR", now)
+	require.NoError(t, err)
+	assert.Equal(t, "1.24", conversion.TargetAmount.String())
+	assert.Equal(t, "1.235", conversion.Rate.String())
+	assert.Equal(t, "1", conversion.SourceAmount.String())
+
+	conversion, err = fx.Convert(ctx, dec("10.01"), "USD", "EUR", now)
+	require.NoError(t, err)
+	assert.Equal(t, "12.36", conversion.TargetAmount.String())
+
+	// The rate in effect at the time is used, not the latest one
+	conversion, err = fx.Convert(ctx, dec("10"), "USD", "EUR", now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "9", conversion.TargetAmount.String())
+
+	// No conversion is needed within a currency
+	conversion, err = fx.Convert(ctx, dec("10"), "USD", "USD", now)
+	require.NoError(t, err)
+	assert.Nil(t, conversion)
+}
+
+func TestFxService_InverseRate(t *testing.T) {
+	ctx := context.Background()
+	fx := service.NewFxService(memory.NewFxRateRepository(memory.NewStore()))
+
+	require.NoError(t, fx.LoadRates(ctx, []model.FxRate{
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: dec("1.25")},
+		{BaseCurrency: "USD", QuoteCurrency: "JPY", Rate: dec("3")},
+	}))
+
+	// Only EUR/USD is loaded, USD/EUR is its inverse
+	rate, err := fx.GetRate(ctx, "USD", "EUR", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "0.8", rate.String())
+
+	conversion, err := fx.Convert(ctx, dec("10"), "USD", "EUR", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "8", conversion.TargetAmount.String())
+
+	// An inverse that does not terminate keeps ten places before the amount is rounded
+	rate, err = fx.GetRate(ctx, "JPY", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "0.3333333333", rate.String())
+
+	conversion, err = fx.Convert(ctx, dec("100"), "JPY", "USD", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "33.33", conversion.TargetAmount.String())
+}
+
+func TestFxService_MissingRate(t *testing.T) {
+	ctx := context.Background()
+	fx := service.NewFxService(memory.NewFxRateRepository(memory.NewStore()))
+
+	effectiveAt := time.Now()
+	require.NoError(t, fx.LoadRates(ctx, []model.FxRate{
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: dec("0.9"), EffectiveAt: effectiveAt},
+	}))
+
+	// Neither the pair nor its inverse is loaded
+	_, err := fx.Convert(ctx, dec("10"), "USD", "GBP", effectiveAt)
+	assert.True(t, errors.Is(err, service.ErrNoFxRate))
+
+	// A rate is not used before it takes effect, in either direction
+	_, err = fx.Convert(ctx, dec("10"), "USD", "EUR", effectiveAt.Add(-time.Second))
+	assert.True(t, errors.Is(err, service.ErrNoFxRate))
+
+	_, err = fx.GetRate(ctx, "EUR", "USD", effectiveAt.Add(-time.Second))
+	assert.True(t, errors.Is(err, service.ErrNoFxRate))
+}
+
+func TestFxLegs_BalanceEachCurrency(t *testing.T) {
+	conversion := &model.FxConversion{
+		SourceAmount:   dec("100"),
+		SourceCurrency: "USD",
+		TargetAmount:   dec("91.37"),
+		TargetCurrency: "EUR",
+		Rate:           dec("0.9137"),
+	}
+
+	legs := service.FxLegs(conversion)
+	require.Len(t, legs, 2)
+	assert.Equal(t, service.Leg("SYSTEM_FX_USD", dec("100"), "USD"), legs[0])
+	assert.Equal(t, service.Leg("SYSTEM_FX_EUR", dec("-91.37"), "EUR"), legs[1])
+
+	// USD cash comes in, the fx accounts exchange it and EUR reaches the account
+	ledger := service.NewLedgerService(memory.NewLedgerRepository(memory.NewStore()))
+	entry := service.NewJournalEntry("TXN_FX", model.TransactionTypeDeposit, dec("91.37"), "EUR", "",
+		append([]model.Posting{
+			service.Leg("CHK1", dec("91.37"), "EUR"),
+			service.Leg(service.SystemCashAccount("USD"), dec("-100"), "USD"),
+		}, legs...)...,
+	)
+	require.NoError(t, ledger.PostEntry(context.Background(), entry, nil))
+}
+
+// unavailableRates fails every lookup of the direct pair, the inverse is loaded
+type unavailableRates struct {
+	*memory.FxRateRepository
+}
+
+func (repo unavailableRates) GetEffective(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (*model.FxRate, error) {
+	if baseCurrency == "USD" {
+		return nil, errors.New("connection refused")
+	}
+
+	return repo.FxRateRepository.GetEffective(ctx, baseCurrency, quoteCurrency, at)
+}
+
+func TestFxService_LookupErrorIsNotAMissingRate(t *testing.T) {
+	ctx := context.Background()
+	rates := unavailableRates{memory.NewFxRateRepository(memory.NewStore())}
+	fx := service.NewFxService(rates)
+
+	require.NoError(t, fx.LoadRates(ctx, []model.FxRate{
+		{BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: dec("1.25")},
+	}))
+
+	// The inverse is loaded, but it must not hide that the direct lookup failed
+	_, err := fx.GetRate(ctx, "USD", "EUR", time.Now())
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, service.ErrNoFxRate))
+	assert.Contains(t, err.Error(), "connection refused")
+}
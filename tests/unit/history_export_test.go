@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/handler"
+	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamTransactionHistory_ReadsEveryLog(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "0")
+
+	total := service.HISTORY_EXPORT_BATCH_SIZE + 20
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < total; i++ {
+		require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{
+			TransactionId: fmt.Sprintf("TXN_%04d", i),
+			ToAccountId:   account.ID,
+			Amount:        dec("1"),
+			Type:          model.TransactionTypeDeposit,
+			Status:        model.TransactionStatusCompleted,
+			Timestamp:     start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	seen := map[string]bool{}
+	inserted := false
+	err := s.txLogs.StreamTransactionHistory(ctx, account.ID, "", "", "", func(txLog *model.TransactionLog) error {
+		assert.False(t, seen[txLog.TransactionId], "%s streamed twice", txLog.TransactionId)
+		seen[txLog.TransactionId] = true
+
+		// A log written meanwhile neither repeats nor drops one
+		if !inserted {
+			inserted = true
+			return s.txLogs.LogTransaction(ctx, &model.TransactionLog{
+				TransactionId: "TXN_LATE",
+				ToAccountId:   account.ID,
+				Amount:        dec("1"),
+				Type:          model.TransactionTypeDeposit,
+				Status:        model.TransactionStatusCompleted,
+				Timestamp:     time.Now(),
+			})
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, seen, total)
+}
+
+func TestTransactionHandler_ExportHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	// Reversals are exported against the log they reverse
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{
+		TransactionId:         "TXN_REVERSAL",
+		ToAccountId:           account.ID,
+		Amount:                dec("10"),
+		Currency:              "USD",
+		Type:                  model.TransactionTypeReversal,
+		Status:                model.TransactionStatusPending,
+		OriginalTransactionId: "TXN_DEPOSIT",
+	}))
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{
+		TransactionId: "TXN_DEPOSIT",
+		ToAccountId:   account.ID,
+		Amount:        dec("40"),
+		Currency:      "USD",
+		Type:          model.TransactionTypeDeposit,
+		Status:        model.TransactionStatusPending,
+		Memo:          "rent, <June>",
+		Timestamp:     time.Now().Add(time.Minute),
+	}))
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_DEPOSIT", account.AccountNumber, dec("40"), model.TransactionTypeDeposit, nil))
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{
+		TransactionId: "TXN_WITHDRAWAL",
+		FromAccountId: account.ID,
+		Amount:        dec("15"),
+		Currency:      "USD",
+		Type:          model.TransactionTypeWithdrawal,
+		Status:        model.TransactionStatusPending,
+		Timestamp:     time.Now().Add(2 * time.Minute),
+	}))
+
+	engine := gin.New()
+	router.SetupTransactionRoutes(engine.Group("/api/v1"), handler.NewTransactionHandler(s.accounts, s.txLogs, s.outbox, s.ledger), func(c *gin.Context) { c.Next() })
+
+	get := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/transactions/account/"+account.AccountNumber+"/history?"+query, nil))
+		return recorder
+	}
+
+	recorder := get("format=csv")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), account.AccountNumber+"_history.csv")
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, []string{"date", "transaction_id", "type", "status", "description", "amount", "currency"}, records[0])
+	assert.Equal(t, []string{"TXN_WITHDRAWAL", "PENDING", "-15.00", "USD"}, []string{records[1][1], records[1][3], records[1][5], records[1][6]})
+	assert.Equal(t, []string{"TXN_DEPOSIT", "rent, <June>", "40.00"}, []string{records[2][1], records[2][4], records[2][5]})
+	assert.Equal(t, []string{"TXN_REVERSAL", "-10.00"}, []string{records[3][1], records[3][5]})
+
+	// The status filter of the history applies to exports too
+	records, err = csv.NewReader(get("format=csv&status=PENDING").Body).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	// Statements carry the balances of every transaction, so they are not filtered
+	assert.Equal(t, http.StatusBadRequest, get("format=ofx&status=PENDING").Code)
+	assert.Equal(t, http.StatusBadRequest, get("format=camt053&status=COMPLETED").Code)
+
+	// OFX lists the completed transactions only
+	recorder = get("format=ofx")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	assert.Equal(t, 2, strings.Count(body, "<STMTTRN>"))
+	assert.Contains(t, body, "<TRNAMT>40.00</TRNAMT>")
+	assert.Contains(t, body, "<MEMO>rent, &lt;June&gt;</MEMO>")
+	assert.Contains(t, body, "<BALAMT>140.00</BALAMT>")
+	assert.NotContains(t, body, "TXN_WITHDRAWAL")
+
+	recorder = get("format=camt053")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var document struct {
+		Statement struct {
+			Balances []struct {
+				Code   string `xml:"Tp>CdOrPrtry>Cd"`
+				Amount string `xml:"Amt"`
+			} `xml:"Bal"`
+			Entries []struct {
+				Ref    string `xml:"NtryRef"`
+				Amount string `xml:"Amt"`
+				Side   string `xml:"CdtDbtInd"`
+				Status string `xml:"Sts>Cd"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &document))
+	require.Len(t, document.Statement.Balances, 2)
+	assert.Equal(t, "CLBD", document.Statement.Balances[1].Code)
+	assert.Equal(t, "140.00", document.Statement.Balances[1].Amount)
+	require.Len(t, document.Statement.Entries, 4)
+	assert.Equal(t, "TXN_WITHDRAWAL", document.Statement.Entries[0].Ref)
+	assert.Equal(t, "15.00", document.Statement.Entries[0].Amount)
+	assert.Equal(t, "DBIT", document.Statement.Entries[0].Side)
+	assert.Equal(t, "PDNG", document.Statement.Entries[0].Status)
+	assert.Equal(t, "BOOK", document.Statement.Entries[1].Status)
+
+	assert.Equal(t, http.StatusBadRequest, get("format=pdf").Code)
+}
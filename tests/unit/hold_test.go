@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *services) account(t *testing.T, accountNumber string) *model.Account {
+	account, err := s.accounts.GetAccount(context.Background(), &requestdto.GetAccount{AccountNumber: accountNumber})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestHold_AvailableBalance(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	hold, err := s.holds.PlaceHold(ctx, account.AccountNumber, dec("30"), time.Now().Add(time.Hour), "")
+	require.NoError(t, err)
+
+	stored := s.account(t, account.AccountNumber)
+	assert.Equal(t, "100", stored.Balance.String())
+	assert.Equal(t, "30", stored.HeldBalance.String())
+	assert.Equal(t, "70", stored.AvailableBalance().String())
+
+	// Held funds can neither be held again nor withdrawn
+	_, err = s.holds.PlaceHold(ctx, account.AccountNumber, dec("70.01"), time.Now().Add(time.Hour), "")
+	assert.True(t, errors.Is(err, service.ErrInsufficientFunds))
+
+	err = s.transactions.ProcessTransaction(ctx, "TXN_1", account.AccountNumber, dec("71"), model.TransactionTypeWithdrawal, nil)
+	assert.True(t, errors.Is(err, service.ErrInsufficientFunds))
+
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_2", account.AccountNumber, dec("70"), model.TransactionTypeWithdrawal, nil))
+	assert.True(t, s.account(t, account.AccountNumber).AvailableBalance().IsZero())
+
+	// Voiding gives the funds back
+	_, err = s.holds.VoidHold(ctx, account.AccountNumber, hold.HoldId)
+	require.NoError(t, err)
+
+	stored = s.account(t, account.AccountNumber)
+	assert.True(t, stored.HeldBalance.IsZero())
+	assert.Equal(t, "30", stored.AvailableBalance().String())
+}
+
+func TestProcessHoldCapture_RejectsOverCapture(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	hold, err := s.holds.PlaceHold(ctx, account.AccountNumber, dec("30"), time.Now().Add(time.Hour), "")
+	require.NoError(t, err)
+
+	err = s.transactions.ProcessHoldCapture(ctx, "TXN_1", account.AccountNumber, hold.HoldId, dec("30.01"))
+	assert.True(t, errors.Is(err, service.ErrCaptureExceedsHold))
+
+	// Nothing moved and the hold can still be captured
+	stored := s.account(t, account.AccountNumber)
+	assert.Equal(t, "100", stored.Balance.String())
+	assert.Equal(t, "30", stored.HeldBalance.String())
+
+	// A partial capture releases the rest of the hold
+	require.NoError(t, s.transactions.ProcessHoldCapture(ctx, "TXN_2", account.AccountNumber, hold.HoldId, dec("20")))
+
+	stored = s.account(t, account.AccountNumber)
+	assert.Equal(t, "80", stored.Balance.String())
+	assert.True(t, stored.HeldBalance.IsZero())
+
+	captured, err := s.holds.GetHold(ctx, account.AccountNumber, hold.HoldId)
+	require.NoError(t, err)
+	assert.Equal(t, model.HoldCaptured, captured.Status)
+	assert.Equal(t, "20", captured.CapturedAmount.String())
+	assert.Equal(t, "TXN_2", captured.CaptureTransactionId)
+
+	err = s.transactions.ProcessHoldCapture(ctx, "TXN_3", account.AccountNumber, hold.HoldId, dec("5"))
+	assert.True(t, errors.Is(err, service.ErrHoldNotActive))
+}
+
+func TestProcessHoldCapture_RejectsExpiredHold(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	hold, err := s.holds.PlaceHold(ctx, account.AccountNumber, dec("30"), time.Now().Add(-time.Second), "")
+	require.NoError(t, err)
+
+	err = s.transactions.ProcessHoldCapture(ctx, "TXN_1", account.AccountNumber, hold.HoldId, dec("30"))
+	assert.True(t, errors.Is(err, service.ErrHoldNotActive))
+
+	// Nothing was debited, the rejected capture rolls back and the sweep releases the hold
+	assert.Equal(t, "100", s.balance(t, account.AccountNumber))
+	require.NoError(t, s.holds.ExpireDueHolds(ctx))
+
+	stored := s.account(t, account.AccountNumber)
+	assert.True(t, stored.HeldBalance.IsZero())
+	assert.Equal(t, "100", stored.AvailableBalance().String())
+
+	expired, err := s.holds.GetHold(ctx, account.AccountNumber, hold.HoldId)
+	require.NoError(t, err)
+	assert.Equal(t, model.HoldExpired, expired.Status)
+	assert.NotNil(t, expired.ReleasedAt)
+}
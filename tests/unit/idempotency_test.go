@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/middleware"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyBegin_TakesOverStaleReservation(t *testing.T) {
+	ctx := context.Background()
+	idempotency := service.NewIdempotencyService(memory.NewIdempotencyRepository(memory.NewStore()), 20*time.Millisecond)
+
+	key, replay, err := idempotency.Begin(ctx, "key-1", "POST /funds", "abc")
+	require.NoError(t, err)
+	assert.False(t, replay)
+
+	// The first request is still running
+	_, _, err = idempotency.Begin(ctx, "key-1", "POST /funds", "abc")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+
+	// Past the TTL it counts as abandoned, and only one retry takes it over
+	time.Sleep(30 * time.Millisecond)
+	retried, replay, err := idempotency.Begin(ctx, "key-1", "POST /funds", "abc")
+	require.NoError(t, err)
+	assert.False(t, replay)
+	assert.Equal(t, key.ID, retried.ID)
+
+	_, _, err = idempotency.Begin(ctx, "key-1", "POST /funds", "abc")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyInProgress)
+
+	_, _, err = idempotency.Begin(ctx, "key-1", "POST /funds", "other")
+	assert.ErrorIs(t, err, service.ErrIdempotencyKeyReused)
+
+	require.NoError(t, idempotency.Complete(ctx, retried, http.StatusCreated, []byte(`{}`)))
+	_, replay, err = idempotency.Begin(ctx, "key-1", "POST /funds", "abc")
+	require.NoError(t, err)
+	assert.True(t, replay)
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	idempotency := service.NewIdempotencyService(memory.NewIdempotencyRepository(memory.NewStore()), time.Hour)
+
+	calls := 0
+	engine := gin.New()
+	engine.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	engine.POST("/funds", middleware.Idempotency(idempotency), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"success": true})
+	})
+
+	post := func() int {
+		request := httptest.NewRequest(http.MethodPost, "/funds", strings.NewReader(`{"amount":"10"}`))
+		request.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, post())
+	assert.Equal(t, http.StatusCreated, post())
+	assert.Equal(t, http.StatusCreated, post())
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/interest"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func day(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+func TestDayCount_DaysInYear(t *testing.T) {
+	assert.Equal(t, int64(365), interest.Actual365Fixed.DaysInYear(day("2024-06-01")))
+	assert.Equal(t, int64(360), interest.Actual360.DaysInYear(day("2024-06-01")))
+	assert.Equal(t, int64(366), interest.ActualActual.DaysInYear(day("2024-06-01")))
+	assert.Equal(t, int64(365), interest.ActualActual.DaysInYear(day("2025-06-01")))
+	assert.Equal(t, int64(365), interest.ActualActual.DaysInYear(day("2100-06-01")))
+	assert.Equal(t, int64(366), interest.ActualActual.DaysInYear(day("2000-06-01")))
+}
+
+func TestParseDayCount(t *testing.T) {
+	dayCount, err := interest.ParseDayCount("")
+	require.NoError(t, err)
+	assert.Equal(t, interest.Actual365Fixed, dayCount)
+
+	dayCount, err = interest.ParseDayCount("ACT/360")
+	require.NoError(t, err)
+	assert.Equal(t, interest.Actual360, dayCount)
+
+	_, err = interest.ParseDayCount("30/360")
+	assert.Error(t, err)
+}
+
+func TestRateFor_Tiers(t *testing.T) {
+	tiers := []interest.Tier{
+		{MinBalance: dec("10000"), AnnualRate: dec("0.025")},
+		{MinBalance: dec("0"), AnnualRate: dec("0.02")},
+		{MinBalance: dec("50000"), AnnualRate: dec("0.03")},
+	}
+
+	assert.True(t, interest.RateFor(tiers, dec("-5")).IsZero())
+	assert.True(t, dec("0.02").Equal(interest.RateFor(tiers, dec("9999.99"))))
+	assert.True(t, dec("0.025").Equal(interest.RateFor(tiers, dec("10000"))))
+	assert.True(t, dec("0.03").Equal(interest.RateFor(tiers, dec("75000"))))
+	assert.True(t, interest.RateFor(nil, dec("100")).IsZero())
+}
+
+func TestDailyAmount(t *testing.T) {
+	// 10000 at 3.65% over 365 days is exactly 1 per day
+	assert.True(t, dec("1").Equal(interest.DailyAmount(dec("10000"), dec("0.0365"), day("2025-03-01"), interest.Actual365Fixed)))
+
+	// Kept at accrual precision instead of being rounded to cents
+	amount := interest.DailyAmount(dec("1000"), dec("0.02"), day("2025-03-01"), interest.Actual365Fixed)
+	assert.Equal(t, "0.0547945205", amount.String())
+
+	amount = interest.DailyAmount(dec("1000"), dec("0.02"), day("2024-03-01"), interest.ActualActual)
+	assert.Equal(t, "0.0546448087", amount.String())
+
+	amount = interest.DailyAmount(dec("1000"), dec("0.02"), day("2025-03-01"), interest.Actual360)
+	assert.Equal(t, "0.0555555556", amount.String())
+
+	assert.True(t, interest.DailyAmount(dec("-100"), dec("0.02"), day("2025-03-01"), interest.Actual365Fixed).IsZero())
+	assert.True(t, interest.DailyAmount(dec("0"), dec("0.02"), day("2025-03-01"), interest.Actual365Fixed).IsZero())
+}
+
+func TestPayable_RoundsDownAndCarriesRemainder(t *testing.T) {
+	paid, remainder := interest.Payable(dec("1.6986301355"))
+	assert.Equal(t, "1.69", paid.String())
+	assert.Equal(t, "0.0086301355", remainder.String())
+
+	paid, remainder = interest.Payable(dec("0.0099999999"))
+	assert.True(t, paid.IsZero())
+	assert.Equal(t, "0.0099999999", remainder.String())
+
+	paid, remainder = interest.Payable(dec("2.5"))
+	assert.Equal(t, "2.5", paid.String())
+	assert.True(t, remainder.IsZero())
+}
+
+func TestMonthOfAccruals_PaysRoundedTotal(t *testing.T) {
+	accrued := decimal.Zero
+	for current := day("2025-01-01"); !current.After(day("2025-01-31")); current = current.AddDate(0, 0, 1) {
+		accrued = accrued.Add(interest.DailyAmount(dec("1000"), dec("0.02"), current, interest.Actual365Fixed))
+	}
+
+	assert.True(t, interest.IsMonthEnd(day("2025-01-31")))
+	assert.False(t, interest.IsMonthEnd(day("2025-01-30")))
+	assert.True(t, interest.IsMonthEnd(day("2024-02-29")))
+
+	paid, remainder := interest.Payable(accrued)
+	assert.Equal(t, "1.69", paid.String())
+	assert.True(t, paid.Add(remainder).Equal(accrued))
+}
+
+func TestDate_TruncatesToUTCDay(t *testing.T) {
+	local := time.Date(2025, 1, 1, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	assert.Equal(t, day("2025-01-02"), interest.Date(local))
+}
+
+func TestInterestService_CatchUpAccruesEachDayOnItsOwnBalance(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	accountRepo := memory.NewAccountRepository(store)
+	interestRepo := memory.NewInterestRepository(store, accountRepo)
+	txLogService := service.NewTransactionLogService(memory.NewTransactionLogRepository(store))
+	ledgerService := service.NewLedgerService(memory.NewLedgerRepository(store))
+	accountService := service.NewAccountService(accountRepo, ledgerService, txLogService)
+	interestService := service.NewInterestService(interestRepo, accountService, ledgerService, txLogService, config.Interest{
+		SavingsTiers: []config.InterestTier{
+			{MinBalance: "0", AnnualRate: "0.0365"},
+			{MinBalance: "5000.00", AnnualRate: "0.073"},
+		},
+	})
+
+	// Tiers are read exactly from their decimal strings
+	assert.Equal(t, "0.0365", interestService.RateFor(dec("4999.99")).String())
+	assert.Equal(t, "0.073", interestService.RateFor(dec("5000")).String())
+
+	account, err := accountService.CreateAccount(ctx, &requestdto.CreateAccount{
+		FirstName:   "Ada",
+		LastName:    "Lovelace",
+		AccountType: model.AccountTypeSaving,
+		Currency:    "USD",
+	})
+	require.NoError(t, err)
+
+	// The worker was down for the last three days
+	yesterday := interest.Date(time.Now()).AddDate(0, 0, -1)
+	require.NoError(t, interestRepo.UpdateAccrued(ctx, account.AccountNumber, decimal.Zero, yesterday.AddDate(0, 0, -3), nil))
+
+	postAt(t, ledgerService, "TXN_1", account.AccountNumber, "1000", yesterday.AddDate(0, 0, -2).Add(12*time.Hour))
+	postAt(t, ledgerService, "TXN_2", account.AccountNumber, "2000", yesterday.AddDate(0, 0, -1).Add(12*time.Hour))
+	postAt(t, ledgerService, "TXN_3", account.AccountNumber, "100000", time.Now())
+
+	require.NoError(t, interestService.AccrueDue(ctx))
+
+	accruals, err := interestService.ListAccruals(ctx, account.AccountNumber, 0)
+	require.NoError(t, err)
+	require.Len(t, accruals, 3)
+
+	// Newest first. A month end in the window adds the few cents it paid to the later days.
+	for i, expected := range []string{"3000", "3000", "1000"} {
+		assert.True(t, accruals[i].AccrualDate.Equal(yesterday.AddDate(0, 0, -i)))
+		assert.True(t, accruals[i].Balance.GreaterThanOrEqual(dec(expected)), accruals[i].Balance.String())
+		assert.True(t, accruals[i].Balance.LessThan(dec(expected).Add(dec("1"))), accruals[i].Balance.String())
+		assert.True(t, dec("0.0365").Equal(accruals[i].AnnualRate))
+	}
+}
@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerService_PostEntryValidates(t *testing.T) {
+	ctx := context.Background()
+	ledger := service.NewLedgerService(memory.NewLedgerRepository(memory.NewStore()))
+	cash := service.SystemCashAccount("USD")
+
+	for name, entry := range map[string]*model.JournalEntry{
+		"no transaction id": service.NewJournalEntry("", model.TransactionTypeDeposit, dec("10"), "USD", "",
+			service.Leg("CHK1", dec("10"), "USD"),
+			service.Leg(cash, dec("-10"), "USD"),
+		),
+		"no postings": service.NewJournalEntry("TXN_EMPTY", model.TransactionTypeDeposit, dec("10"), "USD", ""),
+		"single posting": service.NewJournalEntry("TXN_SINGLE", model.TransactionTypeDeposit, dec("10"), "USD", "",
+			service.Leg("CHK1", dec("10"), "USD"),
+		),
+		"zero posting": service.NewJournalEntry("TXN_ZERO", model.TransactionTypeDeposit, dec("10"), "USD", "",
+			service.Leg("CHK1", dec("10"), "USD"),
+			service.Leg(cash, dec("-10"), "USD"),
+			service.Leg("CHK2", dec("0"), "USD"),
+		),
+		"unbalanced": service.NewJournalEntry("TXN_UNBALANCED", model.TransactionTypeDeposit, dec("10"), "USD", "",
+			service.Leg("CHK1", dec("10"), "USD"),
+			service.Leg(cash, dec("-9.99"), "USD"),
+		),
+		// Sums to zero overall, but each currency must balance on its own
+		"mixed currency": service.NewJournalEntry("TXN_MIXED", model.TransactionTypeDeposit, dec("10"), "USD", "",
+			service.Leg("CHK1", dec("10"), "USD"),
+			service.Leg(service.SystemCashAccount("EUR"), dec("-10"), "EUR"),
+		),
+	} {
+		err := ledger.PostEntry(ctx, entry, nil)
+		assert.Error(t, err, name)
+
+		if entry.TransactionId != "" {
+			posted, err := ledger.IsPosted(ctx, entry.TransactionId, nil)
+			require.NoError(t, err)
+			assert.False(t, posted, name)
+		}
+	}
+
+	unbalanced := service.NewJournalEntry("TXN_UNBALANCED", model.TransactionTypeDeposit, dec("10"), "USD", "",
+		service.Leg("CHK1", dec("10"), "USD"),
+		service.Leg(cash, dec("-9.99"), "USD"),
+	)
+	assert.EqualError(t, ledger.PostEntry(ctx, unbalanced, nil), "journal entry TXN_UNBALANCED is unbalanced by 0.01 USD")
+
+	balanced := service.NewJournalEntry("TXN_OK", model.TransactionTypeDeposit, dec("10"), "USD", "",
+		service.Leg("CHK1", dec("10"), "USD"),
+		service.Leg(cash, dec("-10"), "USD"),
+	)
+	require.NoError(t, ledger.PostEntry(ctx, balanced, nil))
+
+	balance, err := ledger.GetPostedBalance(ctx, "CHK1")
+	require.NoError(t, err)
+	assert.Equal(t, "10", balance.String())
+}
+
+func TestReversalLegs(t *testing.T) {
+	ctx := context.Background()
+	ledger := service.NewLedgerService(memory.NewLedgerRepository(memory.NewStore()))
+	cash := service.SystemCashAccount("USD")
+
+	// A full reversal mirrors every leg
+	deposit := service.NewJournalEntry("TXN_DEPOSIT", model.TransactionTypeDeposit, dec("100"), "USD", "",
+		service.Leg("CHK1", dec("100"), "USD"),
+		service.Leg(cash, dec("-100"), "USD"),
+	)
+	legs, err := service.ReversalLegs(deposit, dec("100"))
+	require.NoError(t, err)
+	require.Len(t, legs, 2)
+	assert.Equal(t, "CHK1", legs[0].AccountNumber)
+	assert.Equal(t, "-100", legs[0].Amount.String())
+	assert.Equal(t, cash, legs[1].AccountNumber)
+	assert.Equal(t, "100", legs[1].Amount.String())
+
+	// A third does not divide evenly, customers get whole cents and cash absorbs the rounding
+	split := service.NewJournalEntry("TXN_SPLIT", model.TransactionTypeDeposit, dec("3"), "USD", "",
+		service.Leg("CHK1", dec("1"), "USD"),
+		service.Leg("CHK2", dec("1"), "USD"),
+		service.Leg(cash, dec("-2"), "USD"),
+	)
+	legs, err = service.ReversalLegs(split, dec("1"))
+	require.NoError(t, err)
+	require.Len(t, legs, 3)
+	assert.Equal(t, "-0.33", legs[0].Amount.String())
+	assert.Equal(t, "-0.33", legs[1].Amount.String())
+	assert.Equal(t, cash, legs[2].AccountNumber)
+	assert.Equal(t, "0.66", legs[2].Amount.String())
+	require.NoError(t, ledger.PostEntry(ctx, service.NewJournalEntry("TXN_SPLIT_REV", model.TransactionTypeReversal, dec("1"), "USD", "", legs...), nil))
+
+	// Without a system leg in the currency the rounding goes to cash
+	transfer := service.NewJournalEntry("TXN_TRANSFER", model.TransactionTypeTransfer, dec("3"), "USD", "",
+		service.Leg("CHK1", dec("1"), "USD"),
+		service.Leg("CHK2", dec("1"), "USD"),
+		service.Leg("CHK3", dec("-2"), "USD"),
+	)
+	legs, err = service.ReversalLegs(transfer, dec("1"))
+	require.NoError(t, err)
+	require.Len(t, legs, 4)
+	assert.Equal(t, "-0.33", legs[0].Amount.String())
+	assert.Equal(t, "-0.33", legs[1].Amount.String())
+	assert.Equal(t, "0.67", legs[2].Amount.String())
+	assert.Equal(t, cash, legs[3].AccountNumber)
+	assert.Equal(t, "-0.01", legs[3].Amount.String())
+	require.NoError(t, ledger.PostEntry(ctx, service.NewJournalEntry("TXN_TRANSFER_REV", model.TransactionTypeReversal, dec("1"), "USD", "", legs...), nil))
+
+	// A reversal that leaves a posting without a cent is refused
+	_, err = service.ReversalLegs(split, dec("0.01"))
+	assert.ErrorIs(t, err, service.ErrReversalTooSmall)
+	assert.True(t, service.IsPermanent(err))
+
+	// Every currency of an fx entry is scaled and balanced on its own
+	exchanged := service.NewJournalEntry("TXN_FX", model.TransactionTypeDeposit, dec("91.37"), "EUR", "",
+		service.Leg("CHK1", dec("91.37"), "EUR"),
+		service.Leg(service.SystemFxAccount("USD"), dec("100"), "USD"),
+		service.Leg(service.SystemFxAccount("EUR"), dec("-91.37"), "EUR"),
+		service.Leg(cash, dec("-100"), "USD"),
+	)
+	legs, err = service.ReversalLegs(exchanged, dec("30"))
+	require.NoError(t, err)
+	require.Len(t, legs, 4)
+	assert.Equal(t, "-30", legs[0].Amount.String())
+	assert.Equal(t, "-32.83", legs[1].Amount.String())
+	assert.Equal(t, "30", legs[2].Amount.String())
+	assert.Equal(t, "32.83", legs[3].Amount.String())
+	require.NoError(t, ledger.PostEntry(ctx, service.NewJournalEntry("TXN_FX_REV", model.TransactionTypeReversal, dec("30"), "EUR", "", legs...), nil))
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	dto "golang-exercise/internal/dto"
+	"golang-exercise/internal/repository"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxService_ProcessPendingLeasesMessages(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	outboxRepo := memory.NewOutboxRepository(store)
+	outboxService := service.NewOutboxService(outboxRepo, service.NewTransactionLogService(memory.NewTransactionLogRepository(store)), 0)
+
+	enqueue := func(transactionID string) {
+		txLog := &model.TransactionLog{TransactionId: transactionID, Status: model.TransactionStatusPending}
+		require.NoError(t, outboxService.EnqueueTransaction(ctx, txLog, &dto.TransactionMessage{ID: transactionID}, nil))
+	}
+	enqueue("TXN_1")
+	enqueue("TXN_2")
+
+	// Publishing runs after the claim has committed, so the rows are not locked
+	// and another relay is kept away by the lease alone
+	var published []string
+	sent, err := outboxService.ProcessPending(ctx, 10, func(txMsg *dto.TransactionMessage) error {
+		published = append(published, txMsg.ID)
+
+		return repository.Transaction(ctx, outboxRepo, func(tx repository.Tx) error {
+			claimed, err := outboxRepo.ClaimPending(ctx, 10, time.Now().Add(time.Minute), tx)
+			assert.Empty(t, claimed)
+			return err
+		})
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, []string{"TXN_1", "TXN_2"}, published)
+
+	// A lease that expired is claimed again, a live one is skipped
+	enqueue("TXN_3")
+	claimed, err := outboxRepo.ClaimPending(ctx, 10, time.Now().Add(-time.Second), nil)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	claimed, err = outboxRepo.ClaimPending(ctx, 10, time.Now().Add(time.Minute), nil)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	sent, err = outboxService.ProcessPending(ctx, 10, func(*dto.TransactionMessage) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// A failed publish backs off instead of being retried on the next run
+	enqueue("TXN_4")
+	sent, err = outboxService.ProcessPending(ctx, 10, func(*dto.TransactionMessage) error { return errors.New("broker down") })
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = outboxService.ProcessPending(ctx, 10, func(*dto.TransactionMessage) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	assert.Equal(t, service.OUTBOX_RETRY_BASE_DELAY, service.OutboxRetryDelay(1))
+	assert.Equal(t, 2*service.OUTBOX_RETRY_BASE_DELAY, service.OutboxRetryDelay(2))
+	assert.Equal(t, 8*service.OUTBOX_RETRY_BASE_DELAY, service.OutboxRetryDelay(4))
+	assert.Equal(t, service.OUTBOX_RETRY_MAX_DELAY, service.OutboxRetryDelay(50))
+}
+
+func TestOutboxService_GivesUpWithTheCause(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	txLogService := service.NewTransactionLogService(memory.NewTransactionLogRepository(store))
+	outboxService := service.NewOutboxService(memory.NewOutboxRepository(store), txLogService, 1)
+
+	txLog := &model.TransactionLog{TransactionId: "TXN_1", Status: model.TransactionStatusPending}
+	require.NoError(t, outboxService.EnqueueTransaction(ctx, txLog, &dto.TransactionMessage{ID: "TXN_1"}, nil))
+
+	sent, err := outboxService.ProcessPending(ctx, 10, func(*dto.TransactionMessage) error { return errors.New("broker down") })
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	failed, err := txLogService.GetTransactionByID(ctx, "TXN_1")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransactionStatusFailed, failed.Status)
+	assert.Contains(t, failed.FailureReason, "broker down")
+}
@@ -0,0 +1,29 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+
+	"golang-exercise/internal/messaging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartition_IsStablePerAccount(t *testing.T) {
+	first := messaging.Partition("CHK1001", 8)
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, messaging.Partition("CHK1001", 8))
+	}
+
+	seen := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		partition := messaging.Partition(fmt.Sprintf("CHK%d", i), 8)
+		assert.True(t, partition >= 0 && partition < 8)
+		seen[partition] = true
+	}
+
+	// Accounts spread over the workers
+	assert.Greater(t, len(seen), 4)
+	assert.Equal(t, 0, messaging.Partition("CHK1001", 1))
+}
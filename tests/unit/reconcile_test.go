@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/reconcile"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffect(t *testing.T) {
+	deposit := &model.TransactionLog{Type: model.TransactionTypeDeposit, FromAccountId: 1, ToAccountId: 1, Amount: dec("100")}
+	effect, ok := reconcile.Effect(deposit, 1, nil)
+	require.True(t, ok)
+	assert.Equal(t, "100", effect.String())
+
+	converted := dec("90")
+	transfer := &model.TransactionLog{Type: model.TransactionTypeTransfer, FromAccountId: 1, ToAccountId: 2, Amount: dec("100"), ConvertedAmount: &converted}
+	effect, _ = reconcile.Effect(transfer, 1, nil)
+	assert.Equal(t, "-100", effect.String())
+	effect, _ = reconcile.Effect(transfer, 2, nil)
+	assert.Equal(t, "90", effect.String())
+
+	// A partial reversal undoes the same share on each side
+	reversal := &model.TransactionLog{Type: model.TransactionTypeReversal, FromAccountId: 2, ToAccountId: 1, Amount: dec("50"), OriginalTransactionId: "TXN_1"}
+	effect, ok = reconcile.Effect(reversal, 2, transfer)
+	require.True(t, ok)
+	assert.Equal(t, "-45", effect.String())
+
+	_, ok = reconcile.Effect(reversal, 2, nil)
+	assert.False(t, ok)
+}
+
+func TestReconcileAccount(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-2 * time.Hour)
+	account := reconcile.Account{ID: 1, AccountNumber: "CHK1", Balance: dec("170")}
+
+	logs := []model.TransactionLog{
+		{TransactionId: "OPEN_CHK1", Type: model.TransactionTypeOpeningBalance, ToAccountId: 1, FromAccountId: 1, Amount: dec("100"), Status: model.TransactionStatusCompleted, Timestamp: old},
+		// Balance committed but the Mongo status update was lost
+		{TransactionId: "TXN_1", Type: model.TransactionTypeDeposit, ToAccountId: 1, FromAccountId: 1, Amount: dec("50"), Status: model.TransactionStatusInprogress, Timestamp: old},
+		// Never reached the worker
+		{TransactionId: "TXN_2", Type: model.TransactionTypeDeposit, ToAccountId: 1, FromAccountId: 1, Amount: dec("10"), Status: model.TransactionStatusPending, Timestamp: old},
+		// Still being processed
+		{TransactionId: "TXN_3", Type: model.TransactionTypeDeposit, ToAccountId: 1, FromAccountId: 1, Amount: dec("20"), Status: model.TransactionStatusInprogress, Timestamp: now.Add(-time.Minute)},
+	}
+
+	posted := map[string]reconcile.Posting{
+		"OPEN_CHK1": {Amount: dec("100"), PostedAt: old},
+		"TXN_1":     {Amount: dec("50"), PostedAt: old},
+		"TXN_3":     {Amount: dec("20"), PostedAt: now},
+	}
+
+	report := reconcile.ReconcileAccount(account, logs, posted, now, time.Hour)
+
+	assert.Equal(t, "120", report.ReplayedBalance.String())
+	assert.Equal(t, "50", report.Drift.String())
+
+	kinds := map[string]reconcile.Discrepancy{}
+	for _, discrepancy := range report.Discrepancies {
+		kinds[discrepancy.TransactionId+"/"+string(discrepancy.Kind)] = discrepancy
+	}
+
+	require.Len(t, report.Discrepancies, 3)
+	assert.Equal(t, model.TransactionStatusCompleted, kinds["TXN_1/STUCK_STATUS"].RepairTo)
+	assert.Equal(t, model.TransactionStatusFailed, kinds["TXN_2/STUCK_STATUS"].RepairTo)
+	assert.Contains(t, kinds, "/BALANCE_DRIFT")
+}
+
+func TestReconcileAccount_MissingLogAndMismatch(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-2 * time.Hour)
+	account := reconcile.Account{ID: 1, AccountNumber: "CHK1", Balance: dec("95")}
+
+	logs := []model.TransactionLog{
+		{TransactionId: "TXN_1", Type: model.TransactionTypeDeposit, ToAccountId: 1, FromAccountId: 1, Amount: dec("100"), Status: model.TransactionStatusCompleted, Timestamp: old},
+	}
+
+	posted := map[string]reconcile.Posting{
+		"TXN_1":     {Amount: dec("99"), PostedAt: old},
+		"FEE_TXN_1": {Amount: dec("-4"), PostedAt: old},
+	}
+
+	report := reconcile.ReconcileAccount(account, logs, posted, now, time.Hour)
+
+	kinds := []reconcile.Kind{}
+	for _, discrepancy := range report.Discrepancies {
+		kinds = append(kinds, discrepancy.Kind)
+	}
+
+	assert.Equal(t, []reconcile.Kind{reconcile.KindAmountMismatch, reconcile.KindMissingLog, reconcile.KindBalanceDrift}, kinds)
+	assert.True(t, report.Drift.Equal(decimal.NewFromInt(-5)))
+
+	summary := reconcile.NewReport(now, false)
+	summary.Add(report)
+	summary.Add(reconcile.AccountReport{AccountNumber: "CHK2"})
+	assert.Equal(t, 2, summary.AccountsChecked)
+	assert.Equal(t, 1, summary.AccountsWithIssues)
+	assert.Equal(t, 1, summary.Discrepancies[reconcile.KindMissingLog])
+}
+
+func TestReconcileAccount_PreLedgerAccount(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	migratedAt := time.Date(2025, 9, 20, 9, 0, 0, 0, time.UTC)
+	legacy := migratedAt.Add(-30 * 24 * time.Hour)
+	account := reconcile.Account{ID: 1, AccountNumber: "CHK1", Balance: dec("170"), LedgerStartedAt: migratedAt}
+
+	logs := []model.TransactionLog{
+		// Completed before the ledger existed, so never posted
+		{TransactionId: "TXN_OLD_1", Type: model.TransactionTypeDeposit, ToAccountId: 1, Amount: dec("200"), Status: model.TransactionStatusCompleted, Timestamp: legacy},
+		{TransactionId: "TXN_OLD_2", Type: model.TransactionTypeWithdrawal, FromAccountId: 1, Amount: dec("50"), Status: model.TransactionStatusCompleted, Timestamp: legacy.Add(time.Hour)},
+		{TransactionId: "TXN_NEW", Type: model.TransactionTypeDeposit, ToAccountId: 1, Amount: dec("20"), Status: model.TransactionStatusCompleted, Timestamp: now.Add(-2 * time.Hour)},
+	}
+
+	// The migration carried the balance of 150 over as an opening entry without a log
+	posted := map[string]reconcile.Posting{
+		"OPEN_CHK1": {Amount: dec("150"), PostedAt: migratedAt, Migrated: true},
+		"TXN_NEW":   {Amount: dec("20"), PostedAt: now.Add(-2 * time.Hour)},
+	}
+
+	report := reconcile.ReconcileAccount(account, logs, posted, now, time.Hour)
+	assert.Empty(t, report.Discrepancies)
+	assert.Equal(t, "170", report.ReplayedBalance.String())
+
+	// A log completed after the cutover still has to be posted
+	logs = append(logs, model.TransactionLog{TransactionId: "TXN_LOST", Type: model.TransactionTypeDeposit, ToAccountId: 1, Amount: dec("5"), Status: model.TransactionStatusCompleted, Timestamp: now.Add(-2 * time.Hour)})
+	report = reconcile.ReconcileAccount(account, logs, posted, now, time.Hour)
+	require.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, reconcile.KindUnposted, report.Discrepancies[0].Kind)
+	assert.Equal(t, reconcile.KindBalanceDrift, report.Discrepancies[1].Kind)
+}
+
+func TestReconciliationService_InMemory(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{
+		TransactionId: "TXN_DEPOSIT",
+		ToAccountId:   account.ID,
+		Amount:        dec("40"),
+		Type:          model.TransactionTypeDeposit,
+		Status:        model.TransactionStatusPending,
+		Timestamp:     time.Now(),
+	}))
+	require.NoError(t, s.transactions.ProcessTransaction(ctx, "TXN_DEPOSIT", account.AccountNumber, dec("40"), model.TransactionTypeDeposit, nil))
+
+	report, err := s.reconciliation.Run(ctx, account.AccountNumber, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.AccountsChecked)
+	assert.Equal(t, 0, report.AccountsWithIssues)
+}
@@ -0,0 +1,72 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"golang-exercise/internal/recurrence"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utc(value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed.UTC()
+}
+
+func TestCron_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		after    string
+		expected string
+	}{
+		{"first of the month", "0 9 1 * *", "2025-01-15T10:00:00Z", "2025-02-01T09:00:00Z"},
+		{"strictly after", "0 9 1 * *", "2025-02-01T09:00:00Z", "2025-03-01T09:00:00Z"},
+		{"monthly shorthand", "@monthly", "2025-12-31T23:59:00Z", "2026-01-01T00:00:00Z"},
+		{"step minutes", "*/15 * * * *", "2025-01-01T10:07:30Z", "2025-01-01T10:15:00Z"},
+		{"weekdays", "30 8 * * 1-5", "2025-01-03T09:00:00Z", "2025-01-06T08:30:00Z"},
+		{"list of hours", "0 6,18 * * *", "2025-01-01T07:00:00Z", "2025-01-01T18:00:00Z"},
+		{"day of month or week", "0 0 13 * 5", "2025-06-01T00:00:00Z", "2025-06-06T00:00:00Z"},
+		{"leap day", "0 0 29 2 *", "2025-01-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := recurrence.Parse(test.spec, time.Time{})
+			require.NoError(t, err)
+
+			assert.Equal(t, utc(test.expected), rule.Next(utc(test.after)))
+		})
+	}
+}
+
+func TestCron_NeverMatches(t *testing.T) {
+	rule, err := recurrence.Parse("0 0 31 2 *", time.Time{})
+	require.NoError(t, err)
+
+	assert.True(t, rule.Next(utc("2025-01-01T00:00:00Z")).IsZero())
+}
+
+func TestInterval_Next(t *testing.T) {
+	anchor := utc("2025-01-01T12:00:00Z")
+
+	rule, err := recurrence.Parse("@every 24h", anchor)
+	require.NoError(t, err)
+
+	assert.Equal(t, anchor, rule.Next(anchor.Add(-time.Second)))
+	assert.Equal(t, utc("2025-01-02T12:00:00Z"), rule.Next(anchor))
+	assert.Equal(t, utc("2025-01-05T12:00:00Z"), rule.Next(utc("2025-01-04T13:00:00Z")))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "@every 30s", "@every soon"} {
+		_, err := recurrence.Parse(spec, time.Time{})
+		assert.Error(t, err, spec)
+	}
+}
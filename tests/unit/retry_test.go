@@ -0,0 +1,38 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/messaging"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := messaging.RetryPolicy{MaxRetries: 4, BaseDelay: 500 * time.Millisecond}
+
+	assert.Equal(t, 500*time.Millisecond, policy.Delay(1))
+	assert.Equal(t, time.Second, policy.Delay(2))
+	assert.Equal(t, 4*time.Second, policy.Delay(4))
+}
+
+func TestNewRetryPolicy_Defaults(t *testing.T) {
+	policy := messaging.NewRetryPolicy(config.RabbitMQ{})
+
+	assert.Equal(t, messaging.DEFAULT_MAX_RETRIES, policy.MaxRetries)
+	assert.Equal(t, messaging.DEFAULT_RETRY_BASE_DELAY, policy.BaseDelay)
+	assert.Equal(t, "ledger_queue.retry.2", messaging.RetryQueueName("ledger_queue", 2))
+}
+
+func TestIsPermanent(t *testing.T) {
+	assert.True(t, service.IsPermanent(service.ErrInsufficientFunds))
+	assert.True(t, service.IsPermanent(fmt.Errorf("%w, 10 remains", service.ErrReversalExceedsOriginal)))
+	assert.True(t, service.IsPermanent(service.ErrAccountFrozen))
+
+	assert.False(t, service.IsPermanent(errors.New("failed to begin transaction: connection refused")))
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleService_RunDue(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	from := s.openAccount(t, "100")
+	to := s.openAccount(t, "0")
+
+	// Due in the same poll, each is run and gets its own transaction
+	startAt := time.Now().Add(-time.Minute)
+	var scheduleIDs []string
+	for i := 0; i < 3; i++ {
+		schedule, err := s.schedules.CreateSchedule(ctx, &requestdto.CreateSchedule{
+			FromAccountNumber: from.AccountNumber,
+			ToAccountNumber:   to.AccountNumber,
+			Amount:            dec("5"),
+			StartAt:           &startAt,
+		})
+		require.NoError(t, err)
+		scheduleIDs = append(scheduleIDs, schedule.ScheduleId)
+	}
+
+	require.NoError(t, s.schedules.RunDue(ctx))
+
+	transactionIDs := map[string]bool{}
+	for _, scheduleID := range scheduleIDs {
+		runs, err := s.schedules.ListRuns(ctx, scheduleID, 0)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, model.ScheduleRunEnqueued, runs[0].Status)
+		transactionIDs[runs[0].TransactionId] = true
+
+		schedule, err := s.schedules.GetSchedule(ctx, scheduleID)
+		require.NoError(t, err)
+		assert.Equal(t, model.ScheduleCompleted, schedule.Status)
+	}
+	assert.Len(t, transactionIDs, 3)
+
+	// Nothing is due anymore
+	require.NoError(t, s.schedules.RunDue(ctx))
+	runs, err := s.schedules.ListRuns(ctx, scheduleIDs[0], 0)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
+
+func TestScheduleService_UpdateClearsEndAt(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	from := s.openAccount(t, "100")
+	to := s.openAccount(t, "0")
+
+	startAt := time.Now().Add(time.Hour)
+	endAt := startAt.Add(72 * time.Hour)
+	schedule, err := s.schedules.CreateSchedule(ctx, &requestdto.CreateSchedule{
+		FromAccountNumber: from.AccountNumber,
+		ToAccountNumber:   to.AccountNumber,
+		Amount:            dec("5"),
+		Recurrence:        "@daily",
+		StartAt:           &startAt,
+		EndAt:             &endAt,
+	})
+	require.NoError(t, err)
+
+	_, err = s.schedules.UpdateSchedule(ctx, schedule.ScheduleId, &requestdto.UpdateSchedule{EndAt: &endAt, ClearEndAt: true})
+	assert.Error(t, err)
+
+	schedule, err = s.schedules.UpdateSchedule(ctx, schedule.ScheduleId, &requestdto.UpdateSchedule{ClearEndAt: true})
+	require.NoError(t, err)
+	assert.Nil(t, schedule.EndAt)
+	assert.Equal(t, model.ScheduleActive, schedule.Status)
+
+	stored, err := s.schedules.GetSchedule(ctx, schedule.ScheduleId)
+	require.NoError(t, err)
+	assert.Nil(t, stored.EndAt)
+}
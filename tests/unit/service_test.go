@@ -0,0 +1,197 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang-exercise/config"
+	"golang-exercise/internal/database/model"
+	requestdto "golang-exercise/internal/dto/request"
+	"golang-exercise/internal/handler"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/router"
+	"golang-exercise/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type services struct {
+	accounts       *service.AccountService
+	transactions   *service.TransactionService
+	holds          *service.HoldService
+	fees           *service.FeeService
+	txLogs         *service.TransactionLogService
+	ledger         *service.LedgerService
+	outbox         *service.OutboxService
+	batches        *service.BatchService
+	fx             *service.FxService
+	schedules      *service.ScheduleService
+	interest       *service.InterestService
+	statements     *service.StatementService
+	reconciliation *service.ReconciliationService
+	idempotency    *service.IdempotencyService
+}
+
+func newServices() *services {
+	store := memory.NewStore()
+	accountRepo := memory.NewAccountRepository(store)
+	ledgerRepo := memory.NewLedgerRepository(store)
+	outboxRepo := memory.NewOutboxRepository(store)
+
+	txLogService := service.NewTransactionLogService(memory.NewTransactionLogRepository(store))
+	ledgerService := service.NewLedgerService(ledgerRepo)
+	accountService := service.NewAccountService(accountRepo, ledgerService, txLogService)
+	holdService := service.NewHoldService(memory.NewHoldRepository(store), accountService)
+	feeService := service.NewFeeService(memory.NewFeeRepository(store), accountService, ledgerService, txLogService, config.Fees{})
+	outboxService := service.NewOutboxService(outboxRepo, txLogService, 0)
+	fxService := service.NewFxService(memory.NewFxRateRepository(store))
+	reconciliationRepo := memory.NewReconciliationRepository(store, accountRepo, ledgerRepo)
+	reconciliationService := service.NewReconciliationService(accountRepo, ledgerRepo, outboxRepo, reconciliationRepo, txLogService, config.Reconciliation{})
+
+	return &services{
+		accounts:       accountService,
+		transactions:   service.NewTransactionService(accountService, txLogService, ledgerService, holdService, feeService),
+		holds:          holdService,
+		fees:           feeService,
+		txLogs:         txLogService,
+		ledger:         ledgerService,
+		outbox:         outboxService,
+		batches:        service.NewBatchService(memory.NewBatchRepository(store), accountService, nil, feeService, outboxService, txLogService),
+		fx:             fxService,
+		schedules:      service.NewScheduleService(memory.NewScheduleRepository(store), accountService, fxService, outboxService),
+		interest:       service.NewInterestService(memory.NewInterestRepository(store, accountRepo), accountService, ledgerService, txLogService, config.Interest{}),
+		statements:     service.NewStatementService(memory.NewStatementRepository(store), accountService, ledgerService),
+		reconciliation: reconciliationService,
+		idempotency:    service.NewIdempotencyService(memory.NewIdempotencyRepository(store), config.Idempotency{}.ReservationTTL()),
+	}
+}
+
+func (s *services) openAccount(t *testing.T, balance string) *model.Account {
+	account, err := s.accounts.CreateAccount(context.Background(), &requestdto.CreateAccount{
+		FirstName:      "Ada",
+		LastName:       "Lovelace",
+		AccountType:    model.AccountTypeChecking,
+		Currency:       "USD",
+		InitialBalance: dec(balance),
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func (s *services) balance(t *testing.T, accountNumber string) string {
+	account, err := s.accounts.GetAccount(context.Background(), &requestdto.GetAccount{AccountNumber: accountNumber})
+	require.NoError(t, err)
+
+	return account.Balance.String()
+}
+
+func TestProcessTransfer_InMemory(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	from := s.openAccount(t, "100")
+	to := s.openAccount(t, "0")
+
+	require.NoError(t, s.transactions.ProcessTransfer(ctx, "TXN_1", from.AccountNumber, to.AccountNumber, dec("40"), nil))
+
+	assert.Equal(t, "60", s.balance(t, from.AccountNumber))
+	assert.Equal(t, "40", s.balance(t, to.AccountNumber))
+
+	posted, err := s.ledger.GetPostedBalance(ctx, to.AccountNumber)
+	require.NoError(t, err)
+	assert.Equal(t, "40", posted.String())
+
+	// A redelivered message is recognised by its journal entry
+	require.NoError(t, s.transactions.ProcessTransfer(ctx, "TXN_1", from.AccountNumber, to.AccountNumber, dec("40"), nil))
+	assert.Equal(t, "60", s.balance(t, from.AccountNumber))
+}
+
+func TestProcessTransaction_RollsBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "50")
+
+	require.NoError(t, s.txLogs.LogTransaction(ctx, &model.TransactionLog{TransactionId: "TXN_1", FromAccountId: account.ID, ToAccountId: account.ID, Status: model.TransactionStatusPending}))
+
+	err := s.transactions.ProcessTransaction(ctx, "TXN_1", account.AccountNumber, dec("80"), model.TransactionTypeWithdrawal, nil)
+	assert.True(t, errors.Is(err, service.ErrInsufficientFunds))
+	assert.Equal(t, "50", s.balance(t, account.AccountNumber))
+
+	txLog, err := s.txLogs.GetTransactionByID(ctx, "TXN_1")
+	require.NoError(t, err)
+	assert.Equal(t, model.TransactionStatusFailed, txLog.Status)
+	assert.Contains(t, txLog.FailureReason, service.ErrInsufficientFunds.Error())
+
+	posted, err := s.ledger.IsPosted(ctx, "TXN_1", nil)
+	require.NoError(t, err)
+	assert.False(t, posted)
+}
+
+func TestProcessTransaction_ConcurrentWithdrawalsHoldTheLock(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "100")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			transactionID := "TXN_" + string(rune('A'+i))
+			if err := s.transactions.ProcessTransaction(ctx, transactionID, account.AccountNumber, dec("30"), model.TransactionTypeWithdrawal, nil); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Without the row lock the withdrawals would read the same balance and all succeed
+	assert.Equal(t, 3, succeeded)
+	assert.Equal(t, "10", s.balance(t, account.AccountNumber))
+}
+
+func TestAccountHandler_InMemory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newServices()
+
+	accountHandler := handler.NewAccountHandler(s.accounts, s.transactions, s.txLogs, s.ledger, s.outbox, nil, s.fees, nil)
+	engine := gin.New()
+	router.SetupAccountRoutes(engine.Group("/api/v1"), accountHandler, func(c *gin.Context) { c.Next() })
+
+	recorder := httptest.NewRecorder()
+	body := `{"first_name":"Ada","last_name":"Lovelace","account_type":"CHECKING","currency":"USD","initial_balance":"25"}`
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/accounts/", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var created struct {
+		Data struct {
+			Account model.Account `json:"account"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	accountNumber := created.Data.Account.AccountNumber
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/accounts/"+accountNumber+"/balance", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"Balance":"25"`)
+
+	// The opening balance is logged like any other transaction
+	txLog, err := s.txLogs.GetTransactionByID(context.Background(), "OPEN_"+accountNumber)
+	require.NoError(t, err)
+	assert.Equal(t, model.TransactionStatusCompleted, txLog.Status)
+}
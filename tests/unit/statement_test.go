@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"golang-exercise/internal/database/model"
+	"golang-exercise/internal/repository/memory"
+	"golang-exercise/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// postAt books amount on the account against cash at the given instant
+func postAt(t *testing.T, ledger *service.LedgerService, transactionID string, accountNumber string, amount string, at time.Time) {
+	legs := []model.Posting{
+		service.Leg(accountNumber, dec(amount), "USD"),
+		service.Leg(service.SystemCashAccount("USD"), dec(amount).Neg(), "USD"),
+	}
+	for i := range legs {
+		legs[i].CreatedAt = at
+	}
+
+	entry := service.NewJournalEntry(transactionID, model.TransactionTypeDeposit, dec(amount).Abs(), "USD", transactionID, legs...)
+	entry.CreatedAt = at
+	require.NoError(t, ledger.PostEntry(context.Background(), entry, nil))
+}
+
+func TestStatementService_GenerateStatement(t *testing.T) {
+	ctx := context.Background()
+	s := newServices()
+	account := s.openAccount(t, "0")
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	postAt(t, s.ledger, "TXN_BEFORE", account.AccountNumber, "50", start.Add(-time.Second))
+	postAt(t, s.ledger, "TXN_FIRST", account.AccountNumber, "30", start)
+	postAt(t, s.ledger, "TXN_MIDDLE", account.AccountNumber, "-20", start.AddDate(0, 0, 14))
+	postAt(t, s.ledger, "TXN_AFTER", account.AccountNumber, "100", end)
+
+	statement, err := s.statements.GenerateStatement(ctx, account.AccountNumber, "2025-03")
+	require.NoError(t, err)
+
+	// The period includes its first instant and excludes the next month's
+	assert.Equal(t, "50", statement.OpeningBalance.String())
+	require.Len(t, statement.Lines, 2)
+	assert.Equal(t, "TXN_FIRST", statement.Lines[0].TransactionId)
+	assert.Equal(t, "80", statement.Lines[0].Balance.String())
+	assert.Equal(t, "TXN_MIDDLE", statement.Lines[1].TransactionId)
+	assert.Equal(t, "60", statement.Lines[1].Balance.String())
+	assert.Equal(t, "30", statement.TotalCredits.String())
+	assert.Equal(t, "20", statement.TotalDebits.String())
+	assert.Equal(t, "60", statement.ClosingBalance.String())
+
+	var buffer bytes.Buffer
+	require.NoError(t, service.WriteStatementCSV(&buffer, statement))
+
+	records, err := csv.NewReader(&buffer).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, []string{"date", "transaction_id", "type", "description", "amount", "balance"}, records[0])
+	assert.Equal(t, []string{"2025-03-01T00:00:00Z", "", "", "Opening balance", "", "50.00"}, records[1])
+	assert.Equal(t, []string{"2025-03-01T00:00:00Z", "TXN_FIRST", "DEPOSIT", "TXN_FIRST", "30.00", "80.00"}, records[2])
+	assert.Equal(t, []string{"TXN_MIDDLE", "-20.00", "60.00"}, []string{records[3][1], records[3][4], records[3][5]})
+	assert.Equal(t, []string{"2025-04-01T00:00:00Z", "", "", "Closing balance", "", "60.00"}, records[4])
+
+	// The current month is still open
+	_, err = s.statements.GenerateStatement(ctx, account.AccountNumber, time.Now().UTC().Format(service.STATEMENT_PERIOD_LAYOUT))
+	assert.ErrorIs(t, err, service.ErrStatementPeriodOpen)
+}
+
+func TestStatementService_GenerateDueCoversAccountsOpenInPeriod(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	accountRepo := memory.NewAccountRepository(store)
+	ledgerService := service.NewLedgerService(memory.NewLedgerRepository(store))
+	txLogService := service.NewTransactionLogService(memory.NewTransactionLogRepository(store))
+	accountService := service.NewAccountService(accountRepo, ledgerService, txLogService)
+	statementService := service.NewStatementService(memory.NewStatementRepository(store), accountService, ledgerService)
+
+	now := time.Now().UTC()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, -1, 0)
+	period := start.Format(service.STATEMENT_PERIOD_LAYOUT)
+
+	createAccount := func(accountNumber string, createdAt time.Time, closedAt *time.Time) {
+		account := &model.Account{
+			Model:         gorm.Model{CreatedAt: createdAt},
+			AccountNumber: accountNumber,
+			Currency:      "USD",
+			AccountType:   model.AccountTypeChecking,
+			AccountStatus: model.AccountActive,
+		}
+		if closedAt != nil {
+			account.AccountStatus = model.AccountClosed
+			account.StatusChangedAt = closedAt
+		}
+		require.NoError(t, accountRepo.Create(ctx, account, nil))
+	}
+
+	closedBefore := start.Add(-time.Second)
+	closedDuring := start.AddDate(0, 0, 10)
+	createAccount("CHK_OPEN", start.AddDate(0, -2, 0), nil)
+	createAccount("CHK_CLOSED_BEFORE", start.AddDate(0, -2, 0), &closedBefore)
+	createAccount("CHK_CLOSED_DURING", start.AddDate(0, -2, 0), &closedDuring)
+	createAccount("CHK_OPENED_DURING", start.AddDate(0, 0, 20), nil)
+	createAccount("CHK_OPENED_AFTER", end, nil)
+
+	require.NoError(t, statementService.GenerateDue(ctx))
+
+	for accountNumber, issued := range map[string]bool{
+		"CHK_OPEN":          true,
+		"CHK_CLOSED_BEFORE": false,
+		"CHK_CLOSED_DURING": true,
+		"CHK_OPENED_DURING": true,
+		"CHK_OPENED_AFTER":  false,
+	} {
+		statements, err := statementService.ListStatements(ctx, accountNumber)
+		require.NoError(t, err)
+
+		if issued {
+			require.Len(t, statements, 1, accountNumber)
+			assert.Equal(t, period, statements[0].Period)
+		} else {
+			assert.Empty(t, statements, accountNumber)
+		}
+	}
+}